@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/Shevchenkko/payment_system/internal/app"
+)
+
+func main() {
+	// run admin command
+	app.Admin(os.Args[1:])
+}
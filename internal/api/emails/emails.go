@@ -1,9 +1,10 @@
+// Package emails implements service.EmailsAPI transports: SMTP delivery,
+// mbox file writer and in-memory capture for local runs and tests.
 package emails
 
 import (
 	"context"
 	"fmt"
-	"os"
 
 	// third party
 	gomail "gopkg.in/mail.v2"
@@ -12,31 +13,84 @@ import (
 	"github.com/Shevchenkko/payment_system/internal/service"
 )
 
+const (
+	// TLSOpportunistic - STARTTLS is used when server supports it.
+	TLSOpportunistic = "opportunistic"
+	// TLSStartTLS - STARTTLS is required, sending fails when server does not support it.
+	TLSStartTLS = "starttls"
+	// TLSImplicit - connection is encrypted from the start, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSNone - email is sent in the clear.
+	TLSNone = "none"
+)
+
+// Config - represents SMTP transport settings, emails are sent without auth when Username is empty.
+type Config struct {
+	Host     string
+	Port     int
+	TLS      string
+	Username string
+	Password string
+	From     string
+}
+
 // Emails - represents api which is used for emails.
-type Emails struct{}
+type Emails struct {
+	dialer *gomail.Dialer
+	from   string
+}
+
+// New - creates new instance of SMTP emails api.
+func New(cfg Config) (*Emails, error) {
+	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
+	switch cfg.TLS {
+	case "", TLSOpportunistic:
+		d.SSL = false
+		d.StartTLSPolicy = gomail.OpportunisticStartTLS
+	case TLSStartTLS:
+		d.SSL = false
+		d.StartTLSPolicy = gomail.MandatoryStartTLS
+	case TLSImplicit:
+		d.SSL = true
+	case TLSNone:
+		d.SSL = false
+		d.StartTLSPolicy = gomail.NoStartTLS
+	default:
+		return nil, fmt.Errorf("unknown mail tls mode %q", cfg.TLS)
+	}
+
+	from := cfg.From
+	if from == "" {
+		from = cfg.Username
+	}
 
-// NewEmails - creates new instance of emails api.
-func New() *Emails {
-	return &Emails{}
+	return &Emails{dialer: d, from: from}, nil
 }
 
+// SendEmail - delivers email through SMTP server.
 func (e *Emails) SendEmail(ctx context.Context, inp service.SendEmailInput) error {
-	// build email
+	err := e.dialer.DialAndSend(newMessage(e.from, inp))
+	if err != nil {
+		return fmt.Errorf("send email to %s: %w", inp.To, err)
+	}
+
+	return nil
+}
+
+// newMessage - builds email message, plain text body goes first when set.
+func newMessage(from string, inp service.SendEmailInput) *gomail.Message {
 	m := gomail.NewMessage()
 	m.SetHeaders(map[string][]string{
-		"From":    {os.Getenv("MAIL_USERNAME")},
+		"From":    {from},
 		"To":      {inp.To},
 		"Subject": {inp.Subject},
 	})
-	m.SetBody(inp.ContentType, inp.Body)
-
-	// sending email
-	d := gomail.NewDialer(os.Getenv("MAIL_HOST"), 587, os.Getenv("MAIL_USERNAME"), os.Getenv("MAIL_APP_PASSWORD"))
-	err := d.DialAndSend(m)
-	if err != nil {
-		fmt.Println("ERROR", err)
-		return err
+	if inp.TextBody != "" {
+		m.SetBody("text/plain", inp.TextBody)
+		m.AddAlternative(inp.ContentType, inp.Body)
+	} else {
+		m.SetBody(inp.ContentType, inp.Body)
 	}
 
-	return nil
+	return m
 }
@@ -0,0 +1,69 @@
+package emails
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// File - represents api which appends emails to mbox file instead of sending them.
+type File struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewFile - creates new instance of mbox file emails api.
+func NewFile(path string, from string) *File {
+	return &File{path: path, from: from}
+}
+
+// SendEmail - appends email to mbox file.
+func (f *File) SendEmail(ctx context.Context, inp service.SendEmailInput) error {
+	var raw bytes.Buffer
+	if _, err := newMessage(f.from, inp).WriteTo(&raw); err != nil {
+		return err
+	}
+
+	// mbox separates messages by "From " lines, so such lines of message are quoted
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "From %s %s\n", mboxSender(f.from), time.Now().UTC().Format(time.ANSIC))
+	for _, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(raw.String(), "\r\n", "\n"), "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			entry.WriteString(">")
+		}
+		entry.WriteString(line)
+		entry.WriteString("\n")
+	}
+	entry.WriteString("\n")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("send email to %s: %w", inp.To, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(entry.String()); err != nil {
+		return fmt.Errorf("send email to %s: %w", inp.To, err)
+	}
+
+	return nil
+}
+
+// mboxSender - returns envelope sender of mbox "From " line.
+func mboxSender(from string) string {
+	if from == "" {
+		return "MAILER-DAEMON"
+	}
+	return from
+}
@@ -0,0 +1,57 @@
+package emails
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// Memory - represents api which keeps emails in memory instead of sending them.
+// Used in local runs and tests to inspect sent emails.
+type Memory struct {
+	mu     sync.Mutex
+	emails []service.CapturedEmail
+}
+
+// NewMemory - creates new instance of in-memory emails api.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// SendEmail - captures email.
+func (m *Memory) SendEmail(ctx context.Context, inp service.SendEmailInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emails = append(m.emails, service.CapturedEmail{
+		To:          inp.To,
+		Subject:     inp.Subject,
+		ContentType: inp.ContentType,
+		Body:        inp.Body,
+		TextBody:    inp.TextBody,
+		SentAt:      time.Now(),
+	})
+
+	return nil
+}
+
+// CapturedEmails - returns captured emails, oldest first.
+func (m *Memory) CapturedEmails() []service.CapturedEmail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	emails := make([]service.CapturedEmail, len(m.emails))
+	copy(emails, m.emails)
+	return emails
+}
+
+// ClearCapturedEmails - removes captured emails.
+func (m *Memory) ClearCapturedEmails() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emails = nil
+}
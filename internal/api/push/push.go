@@ -0,0 +1,116 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// DefaultURL - FCM legacy http endpoint.
+const DefaultURL = "https://fcm.googleapis.com/fcm/send"
+
+// Config - represents push server settings, URL can point to FCM compatible stub.
+type Config struct {
+	URL       string
+	ServerKey string
+}
+
+// Push - represents api which sends notifications to devices through FCM compatible http server.
+type Push struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New - creates new instance of push api.
+func New(cfg Config) *Push {
+	if cfg.URL == "" {
+		cfg.URL = DefaultURL
+	}
+	return &Push{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Channel - returns name of notification channel.
+func (p *Push) Channel() string {
+	return "push"
+}
+
+// message - represents push server request body.
+type message struct {
+	To           string            `json:"to"`
+	Notification notification      `json:"notification"`
+	Data         map[string]string `json:"data"`
+}
+
+// notification - represents notification shown on device.
+type notification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// response - represents push server response body.
+type response struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+	Results []struct {
+		MessageID string `json:"message_id"`
+		Error     string `json:"error"`
+	} `json:"results"`
+}
+
+// SendNotification - posts notification to device token, fails when server rejects the token.
+func (p *Push) SendNotification(ctx context.Context, inp service.SendNotificationInput) error {
+	payload, err := json.Marshal(message{
+		To: inp.To,
+		Notification: notification{
+			Title: inp.Title,
+			Body:  inp.Body,
+		},
+		Data: map[string]string{
+			"event":      inp.Event,
+			"deliveryId": strconv.Itoa(inp.DeliveryID),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+p.cfg.ServerKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("push server responded with status %d", resp.StatusCode)
+	}
+
+	var result response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&result); err != nil {
+		return fmt.Errorf("invalid push server response: %w", err)
+	}
+	if result.Failure > 0 {
+		reason := "unknown error"
+		if len(result.Results) > 0 && result.Results[0].Error != "" {
+			reason = result.Results[0].Error
+		}
+		return fmt.Errorf("push server rejected notification: %s", reason)
+	}
+
+	return nil
+}
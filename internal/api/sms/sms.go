@@ -0,0 +1,82 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// Config - represents sms gateway settings, Token is sent as bearer token when set.
+type Config struct {
+	URL    string
+	Token  string
+	Sender string
+}
+
+// SMS - represents api which sends notifications as sms through http gateway.
+type SMS struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New - creates new instance of sms api.
+func New(cfg Config) *SMS {
+	return &SMS{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Channel - returns name of notification channel.
+func (s *SMS) Channel() string {
+	return "sms"
+}
+
+// message - represents sms gateway request body.
+type message struct {
+	To        string `json:"to"`
+	From      string `json:"from,omitempty"`
+	Text      string `json:"text"`
+	Reference string `json:"reference"`
+}
+
+// SendNotification - posts sms to gateway, any 2xx response means sms is accepted.
+func (s *SMS) SendNotification(ctx context.Context, inp service.SendNotificationInput) error {
+	payload, err := json.Marshal(message{
+		To:        inp.To,
+		From:      s.cfg.Sender,
+		Text:      "PaySystem: " + inp.Title + ". " + inp.Body,
+		Reference: strconv.Itoa(inp.DeliveryID),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms gateway responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
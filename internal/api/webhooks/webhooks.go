@@ -0,0 +1,91 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// Webhooks - represents api which is used for webhook deliveries.
+type Webhooks struct {
+	client *http.Client
+}
+
+// New - creates new instance of webhooks api.
+// Deliveries connect only to public addresses, checked after host is resolved, and go without proxy.
+func New() *Webhooks {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: publicAddressOnly,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Webhooks{client: &http.Client{Transport: transport, Timeout: 10 * time.Second}}
+}
+
+// SendWebhook - posts signed payload to webhook url and returns response status code.
+// Receiver verifies X-Webhook-Signature as hex HMAC-SHA256 of "<timestamp>.<body>" with webhook secret.
+func (w *Webhooks) SendWebhook(ctx context.Context, inp service.SendWebhookInput) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inp.URL, bytes.NewReader(inp.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", inp.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(inp.DeliveryID))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(inp.Secret, timestamp, inp.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook receiver responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign - returns hex HMAC-SHA256 signature of timestamp and payload.
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicAddressOnly - refuses connections to loopback, private and link-local addresses.
+func publicAddressOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !utils.IsPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
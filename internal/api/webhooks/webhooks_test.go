@@ -0,0 +1,460 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// receiver - represents local webhook receiver recording requests it got.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []receivedRequest
+}
+
+// receivedRequest - represents request got by receiver.
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver - starts receiver responding with status.
+func newReceiver(t *testing.T, status int) *receiver {
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// respond - changes status receiver responds with.
+func (r *receiver) respond(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// received - returns requests got by receiver.
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// memoryRepo - represents in-memory webhooks repository.
+type memoryRepo struct {
+	webhooks   []domain.Webhook
+	deliveries []domain.WebhookDelivery
+}
+
+func (m *memoryRepo) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	webhook.ID = len(m.webhooks) + 1
+	m.webhooks = append(m.webhooks, *webhook)
+	return webhook, nil
+}
+
+func (m *memoryRepo) SearchWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	for _, w := range m.webhooks {
+		if w.UserID == userId {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *memoryRepo) GetWebhookByID(ctx context.Context, webhookId int) (*domain.Webhook, error) {
+	for i := range m.webhooks {
+		if m.webhooks[i].ID == webhookId {
+			webhook := m.webhooks[i]
+			return &webhook, nil
+		}
+	}
+	return nil, &service.Error{Message: "Webhook not found"}
+}
+
+func (m *memoryRepo) DeleteWebhook(ctx context.Context, webhookId int) error {
+	return nil
+}
+
+func (m *memoryRepo) GetAccountWebhooks(ctx context.Context, accountId int) ([]domain.Webhook, error) {
+	return m.webhooks, nil
+}
+
+func (m *memoryRepo) CreateWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	for _, d := range deliveries {
+		d.ID = len(m.deliveries) + 1
+		m.deliveries = append(m.deliveries, d)
+	}
+	return nil
+}
+
+func (m *memoryRepo) SearchWebhookDeliveries(ctx context.Context, filter *domain.Filter, webhookId int) (*service.SearchWebhookDeliveries, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookId {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return &service.SearchWebhookDeliveries{Data: deliveries}, nil
+}
+
+func (m *memoryRepo) GetWebhookDeliveryByID(ctx context.Context, deliveryId int) (*domain.WebhookDelivery, error) {
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == deliveryId {
+			delivery := m.deliveries[i]
+			return &delivery, nil
+		}
+	}
+	return nil, &service.Error{Message: "Delivery not found"}
+}
+
+func (m *memoryRepo) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status != "PENDING" || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		d.Webhook, _ = m.GetWebhookByID(ctx, d.WebhookID)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+func (m *memoryRepo) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == delivery.ID {
+			updated := *delivery
+			updated.Webhook = nil
+			m.deliveries[i] = updated
+		}
+	}
+	return nil
+}
+
+// newTestService - creates webhooks service delivering to local receivers,
+// user 1 has webhook to url subscribed to payment.sent.
+func newTestService(url string) (*service.WebhooksService, *memoryRepo) {
+	repo := &memoryRepo{}
+	repo.webhooks = append(repo.webhooks, domain.Webhook{
+		ID:     1,
+		UserID: 1,
+		URL:    url,
+		Secret: "secret",
+		Events: "payment.sent",
+		Status: "ACTIVE",
+	})
+
+	// private address guard is left out, receivers listen on loopback
+	api := &Webhooks{client: &http.Client{Timeout: 5 * time.Second}}
+	return service.NewWebhooksService(service.Repositories{Webhooks: repo}, service.APIs{Webhooks: api}), repo
+}
+
+func TestSendWebhookSignature(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	w := &Webhooks{client: r.Client()}
+	payload := []byte(`{"event":"payment.sent","data":{"paymentId":7}}`)
+
+	code, err := w.SendWebhook(context.Background(), service.SendWebhookInput{
+		URL:        r.URL,
+		Secret:     "secret",
+		Event:      "payment.sent",
+		DeliveryID: 42,
+		Payload:    payload,
+	})
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("SendWebhook() = %d, %v, want 200, nil", code, err)
+	}
+
+	requests := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if string(req.body) != string(payload) {
+		t.Errorf("body = %s, want %s", req.body, payload)
+	}
+	if got := req.header.Get("X-Webhook-Event"); got != "payment.sent" {
+		t.Errorf("X-Webhook-Event = %q, want payment.sent", got)
+	}
+	if got := req.header.Get("X-Webhook-Delivery"); got != "42" {
+		t.Errorf("X-Webhook-Delivery = %q, want 42", got)
+	}
+
+	// receiver recomputes signature over "<timestamp>.<body>"
+	timestamp := req.header.Get("X-Webhook-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("X-Webhook-Timestamp = %q, want unix time", timestamp)
+	}
+	want := "sha256=" + Sign("secret", timestamp, payload)
+	if got := req.header.Get("X-Webhook-Signature"); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		// HMAC-SHA256 of "1700000000.{}" with key "secret"
+		{"secret", "1700000000", "{}", "b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.payload)); got != tt.want {
+			t.Errorf("Sign(%q, %q, %q) = %q, want %q", tt.secret, tt.timestamp, tt.payload, got, tt.want)
+		}
+	}
+
+	base := Sign("secret", "1700000000", []byte("{}"))
+	if Sign("secret", "1700000001", []byte("{}")) == base {
+		t.Errorf("Sign() does not depend on timestamp")
+	}
+	if Sign("secret", "1700000000", []byte("[]")) == base {
+		t.Errorf("Sign() does not depend on body")
+	}
+	if Sign("other", "1700000000", []byte("{}")) == base {
+		t.Errorf("Sign() does not depend on secret")
+	}
+}
+
+func TestSendWebhookRefusesPrivateAddress(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+
+	_, err := New().SendWebhook(context.Background(), service.SendWebhookInput{
+		URL:     r.URL,
+		Secret:  "secret",
+		Event:   "payment.sent",
+		Payload: []byte("{}"),
+	})
+	if err == nil {
+		t.Fatalf("SendWebhook() to %s error = nil, want refused", r.URL)
+	}
+	if len(r.received()) != 0 {
+		t.Errorf("receiver on loopback got request")
+	}
+}
+
+func TestCreateWebhookRejectsPrivateURL(t *testing.T) {
+	s, _ := newTestService("")
+	urls := []string{
+		"http://localhost/hook",
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"ftp://example.com/hook",
+		"/hook",
+	}
+
+	for _, url := range urls {
+		t.Run(url, func(t *testing.T) {
+			_, err := s.CreateWebhook(context.Background(), 1, &service.WebhookInput{URL: url, Events: []string{"payment.sent"}})
+			if _, ok := err.(*service.Error); !ok {
+				t.Errorf("CreateWebhook(%q) error = %v, want service error", url, err)
+			}
+		})
+	}
+
+	webhook, err := s.CreateWebhook(context.Background(), 1, &service.WebhookInput{URL: "https://93.184.216.34/hook", Events: []string{"payment.sent"}})
+	if err != nil {
+		t.Fatalf("CreateWebhook() with public address error = %v", err)
+	}
+	if webhook.Secret == "" {
+		t.Errorf("CreateWebhook() did not return signing secret")
+	}
+}
+
+func TestDeliverWebhooksBackoff(t *testing.T) {
+	r := newReceiver(t, http.StatusInternalServerError)
+	s, repo := newTestService(r.URL)
+	ctx := context.Background()
+
+	if err := s.PublishEvent(ctx, "payment.sent", 1, map[string]int{"paymentId": 7}); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("PublishEvent() scheduled %d deliveries, want 1", len(repo.deliveries))
+	}
+
+	now := time.Now()
+	for attempt := 1; attempt <= 8; attempt++ {
+		before := time.Now()
+		delivered, err := s.DeliverWebhooks(ctx, now)
+		if err != nil || delivered != 0 {
+			t.Fatalf("attempt %d: DeliverWebhooks() = %d, %v, want 0, nil", attempt, delivered, err)
+		}
+
+		delivery := repo.deliveries[0]
+		if delivery.Attempts != attempt {
+			t.Fatalf("attempt %d: attempts = %d", attempt, delivery.Attempts)
+		}
+		if delivery.ResponseCode != http.StatusInternalServerError || delivery.LastError == "" {
+			t.Errorf("attempt %d: response code = %d, last error = %q", attempt, delivery.ResponseCode, delivery.LastError)
+		}
+		if attempt == 8 {
+			if delivery.Status != "FAILED" || delivery.NextAttemptAt != nil {
+				t.Fatalf("after 8 attempts status = %s, next attempt = %v, want FAILED without next attempt", delivery.Status, delivery.NextAttemptAt)
+			}
+			break
+		}
+
+		// 30s, 1m, 2m, ... between attempts
+		if delivery.Status != "PENDING" || delivery.NextAttemptAt == nil {
+			t.Fatalf("attempt %d: status = %s, next attempt = %v, want PENDING with next attempt", attempt, delivery.Status, delivery.NextAttemptAt)
+		}
+		want := 30 * time.Second << (attempt - 1)
+		delay := delivery.NextAttemptAt.Sub(before)
+		if delay < want || delay > want+time.Second {
+			t.Errorf("attempt %d: retry in %s, want %s", attempt, delay, want)
+		}
+
+		// not due before its time
+		if _, err := s.DeliverWebhooks(ctx, delivery.NextAttemptAt.Add(-time.Second)); err != nil {
+			t.Fatalf("DeliverWebhooks() error = %v", err)
+		}
+		if got := repo.deliveries[0].Attempts; got != attempt {
+			t.Fatalf("delivery retried before it was due, attempts = %d", got)
+		}
+		now = *delivery.NextAttemptAt
+	}
+
+	if got := len(r.received()); got != 8 {
+		t.Errorf("receiver got %d requests, want 8", got)
+	}
+
+	// failed delivery is not retried any more
+	if _, err := s.DeliverWebhooks(ctx, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+	if got := len(r.received()); got != 8 {
+		t.Errorf("failed delivery was sent again, receiver got %d requests", got)
+	}
+}
+
+func TestDeliveryLog(t *testing.T) {
+	r := newReceiver(t, http.StatusAccepted)
+	s, _ := newTestService(r.URL)
+	ctx := context.Background()
+
+	if err := s.PublishEvent(ctx, "payment.sent", 1, map[string]int{"paymentId": 7}); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+	if err := s.PublishEvent(ctx, "account.locked", 1, map[string]int{"accountId": 1}); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+	delivered, err := s.DeliverWebhooks(ctx, time.Now())
+	if err != nil || delivered != 1 {
+		t.Fatalf("DeliverWebhooks() = %d, %v, want 1, nil", delivered, err)
+	}
+
+	log, err := s.SearchWebhookDeliveries(ctx, nil, 1, 1)
+	if err != nil {
+		t.Fatalf("SearchWebhookDeliveries() error = %v", err)
+	}
+	if len(log.Data) != 1 {
+		t.Fatalf("delivery log has %d deliveries, want 1 of subscribed event", len(log.Data))
+	}
+	delivery := log.Data[0]
+	if delivery.Event != "payment.sent" || delivery.Status != "DELIVERED" || delivery.Attempts != 1 ||
+		delivery.ResponseCode != http.StatusAccepted || delivery.DeliveredAt == nil || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery log entry = %+v", delivery)
+	}
+
+	var payload struct {
+		Event string         `json:"event"`
+		Data  map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+		t.Fatalf("payload %q is not json: %v", delivery.Payload, err)
+	}
+	if payload.Event != "payment.sent" || payload.Data["paymentId"] != 7 {
+		t.Errorf("payload = %+v", payload)
+	}
+	if body := string(r.received()[0].body); body != delivery.Payload {
+		t.Errorf("receiver got %s, logged payload %s", body, delivery.Payload)
+	}
+
+	if _, err := s.SearchWebhookDeliveries(ctx, nil, 2, 1); err == nil {
+		t.Errorf("delivery log of webhook is visible to other user")
+	}
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	r := newReceiver(t, http.StatusBadGateway)
+	s, repo := newTestService(r.URL)
+	ctx := context.Background()
+
+	if err := s.PublishEvent(ctx, "payment.sent", 1, map[string]int{"paymentId": 7}); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+	if _, err := s.ReplayWebhookDelivery(ctx, 1, 1); err == nil {
+		t.Errorf("ReplayWebhookDelivery() of scheduled delivery error = nil")
+	}
+
+	// fail delivery
+	repo.deliveries[0].Attempts = 7
+	if _, err := s.DeliverWebhooks(ctx, time.Now()); err != nil {
+		t.Fatalf("DeliverWebhooks() error = %v", err)
+	}
+	if repo.deliveries[0].Status != "FAILED" {
+		t.Fatalf("status = %s, want FAILED", repo.deliveries[0].Status)
+	}
+
+	if _, err := s.ReplayWebhookDelivery(ctx, 2, 1); err == nil {
+		t.Errorf("ReplayWebhookDelivery() by other user error = nil")
+	}
+
+	replayed, err := s.ReplayWebhookDelivery(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("ReplayWebhookDelivery() error = %v", err)
+	}
+	if replayed.Status != "PENDING" || replayed.Attempts != 0 || replayed.NextAttemptAt == nil {
+		t.Errorf("replayed delivery = %+v, want PENDING without attempts", replayed)
+	}
+
+	r.respond(http.StatusOK)
+	delivered, err := s.DeliverWebhooks(ctx, time.Now())
+	if err != nil || delivered != 1 {
+		t.Fatalf("DeliverWebhooks() after replay = %d, %v, want 1, nil", delivered, err)
+	}
+	if d := repo.deliveries[0]; d.Status != "DELIVERED" || d.Attempts != 1 || d.LastError != "" {
+		t.Errorf("delivery after replay = %+v", d)
+	}
+
+	requests := r.received()
+	if len(requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(requests))
+	}
+	if string(requests[0].body) != string(requests[1].body) {
+		t.Errorf("replay sent different payload")
+	}
+	if requests[0].header.Get("X-Webhook-Delivery") != requests[1].header.Get("X-Webhook-Delivery") {
+		t.Errorf("replay sent different delivery id")
+	}
+}
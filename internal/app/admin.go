@@ -0,0 +1,484 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// adminCommand - represents command of admin tool.
+type adminCommand struct {
+	usage string
+	run   func(ctx context.Context, a *adminTool, args []string) error
+}
+
+// adminCommands - represents commands of admin tool by name.
+var adminCommands = map[string]adminCommand{
+	"migrate": {
+		usage: "  migrate schema and data like app does on start, other commands never change schema",
+		run:   adminMigrate,
+	},
+	"create-admin": {
+		usage: "-name <full name> -email <email> [-password <password>]  create admin user or grant admin role to existing one",
+		run:   adminCreateAdmin,
+	},
+	"list-roles": {
+		usage: "  list roles with their permissions",
+		run:   adminListRoles,
+	},
+	"grant-role": {
+		usage: "-user <id|email> -role <role>  grant role to user",
+		run:   adminGrantRole,
+	},
+	"revoke-role": {
+		usage: "-user <id|email> -role <role>  revoke role from user, user without roles keeps user role",
+		run:   adminRevokeRole,
+	},
+	"lock-user": {
+		usage: "-user <id|email>  lock user and revoke their sessions",
+		run:   adminLockUser,
+	},
+	"unlock-user": {
+		usage: "-user <id|email>  unlock user",
+		run:   adminUnlockUser,
+	},
+	"lock-account": {
+		usage: "-card <card number>  lock bank account",
+		run:   adminLockAccount,
+	},
+	"unlock-account": {
+		usage: "-card <card number>  unlock bank account",
+		run:   adminUnlockAccount,
+	},
+	"reset-password": {
+		usage: "-user <id|email> [-password <password>]  set new password and revoke sessions of user",
+		run:   adminResetPassword,
+	},
+	"list-accounts": {
+		usage: "[-user <id|email>] [-page <n>] [-list <n>] [-sort <field [asc|desc]>]  list bank accounts",
+		run:   adminListAccounts,
+	},
+}
+
+// adminTool - represents admin tool, connected to the database after command arguments are parsed.
+type adminTool struct {
+	l        logger.Interface
+	out      io.Writer
+	repos    service.Repositories
+	services service.Services
+}
+
+// Admin - runs admin command with the same configuration as app, so operators can manage users,
+// roles and accounts without http api.
+func Admin(args []string) {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printAdminUsage(os.Stdout)
+		return
+	}
+	command, ok := adminCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printAdminUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	a := &adminTool{
+		l:   logger.New(os.Getenv("LOG_LEVEL")),
+		out: os.Stdout,
+	}
+	err := command.run(context.Background(), a, args[1:])
+	if err != nil {
+		var serviceErr *service.Error
+		if errors.As(err, &serviceErr) {
+			err = errors.New(serviceErr.Message)
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// printAdminUsage - writes commands of admin tool.
+func printAdminUsage(w io.Writer) {
+	names := make([]string, 0, len(adminCommands))
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: admin <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s\n", name, adminCommands[name].usage)
+	}
+}
+
+// connect - connects to mysql and creates services like app.Run does, schema is migrated only by migrate command.
+func (a *adminTool) connect() *mysql.MySQL {
+	sql := connectMySQL(a.l)
+	a.repos = newRepositories(sql)
+	a.services = newServices(a.repos, newAPIs(a.l))
+
+	return sql
+}
+
+// adminMigrate - migrates schema and data.
+func adminMigrate(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	migrateMySQL(a.l, a.connect())
+	fmt.Fprintln(a.out, "schema and data migrated")
+
+	return nil
+}
+
+// user - returns user by id or email.
+func (a *adminTool) user(ctx context.Context, value string) (*domain.User, error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		return a.repos.Users.GetUserByID(ctx, id)
+	}
+	return a.repos.Users.GetUser(ctx, value)
+}
+
+// changeRoles - replaces roles of user with changed ones and writes roles user has now.
+func (a *adminTool) changeRoles(ctx context.Context, user *domain.User, change func(roles []string) ([]string, error)) error {
+	current, err := a.services.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	roles, err := change(roleNames(current))
+	if err != nil {
+		return err
+	}
+
+	assigned, err := a.services.AssignUserRoles(ctx, user.ID, roles)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "user #%d %s roles: %s\n", user.ID, user.Email, strings.Join(roleNames(assigned), ", "))
+	return nil
+}
+
+// adminCreateAdmin - creates admin user, existing user with the email is granted admin role.
+func adminCreateAdmin(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	name := flags.String("name", "", "full name of admin")
+	email := flags.String("email", "", "email admin logs in with")
+	password := flags.String("password", "", "password, generated when empty")
+	_ = flags.Parse(args)
+	if *name == "" || *email == "" {
+		return errors.New("-name and -email are required")
+	}
+
+	a.connect()
+	user, err := a.repos.Users.GetUser(ctx, *email)
+	if err != nil {
+		if _, ok := err.(*service.Error); !ok {
+			return err
+		}
+
+		generated := *password == ""
+		if generated {
+			*password, err = randomPassword()
+			if err != nil {
+				return err
+			}
+		}
+		user, err = a.repos.Users.CreateUser(ctx, &service.RegisterUserInput{
+			FullName: *name,
+			Email:    *email,
+			Password: *password,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "created user #%d %s\n", user.ID, user.Email)
+		if generated {
+			fmt.Fprintf(a.out, "generated password: %s\n", *password)
+		}
+	} else if *password != "" {
+		return errors.New("user already exists, use reset-password to change their password")
+	}
+
+	return a.changeRoles(ctx, user, func(roles []string) ([]string, error) {
+		return []string{domain.RoleAdmin}, nil
+	})
+}
+
+// adminListRoles - writes roles with their permissions.
+func adminListRoles(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("list-roles", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	a.connect()
+	roles, err := a.services.SearchRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tPERMISSIONS")
+	for _, role := range roles {
+		permissions := make([]string, 0, len(role.Permissions))
+		for _, p := range role.Permissions {
+			permissions = append(permissions, p.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", role.Name, strings.Join(permissions, ", "))
+	}
+	return w.Flush()
+}
+
+// adminGrantRole - grants role to user.
+func adminGrantRole(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("grant-role", flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of user")
+	role := flags.String("role", "", "role to grant")
+	_ = flags.Parse(args)
+	if *userFlag == "" || *role == "" {
+		return errors.New("-user and -role are required")
+	}
+
+	a.connect()
+	user, err := a.user(ctx, *userFlag)
+	if err != nil {
+		return err
+	}
+
+	return a.changeRoles(ctx, user, func(roles []string) ([]string, error) {
+		for _, r := range roles {
+			if r == *role {
+				return nil, fmt.Errorf("user already has role %s", *role)
+			}
+		}
+		return append(roles, *role), nil
+	})
+}
+
+// adminRevokeRole - revokes role from user, user left without roles gets user role.
+func adminRevokeRole(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("revoke-role", flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of user")
+	role := flags.String("role", "", "role to revoke")
+	_ = flags.Parse(args)
+	if *userFlag == "" || *role == "" {
+		return errors.New("-user and -role are required")
+	}
+
+	a.connect()
+	user, err := a.user(ctx, *userFlag)
+	if err != nil {
+		return err
+	}
+
+	return a.changeRoles(ctx, user, func(roles []string) ([]string, error) {
+		kept := make([]string, 0, len(roles))
+		for _, r := range roles {
+			if r != *role {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == len(roles) {
+			return nil, fmt.Errorf("user has no role %s", *role)
+		}
+		if len(kept) == 0 {
+			kept = append(kept, domain.RoleUser)
+		}
+		return kept, nil
+	})
+}
+
+// adminLockUser - locks user.
+func adminLockUser(ctx context.Context, a *adminTool, args []string) error {
+	return adminChangeUserStatus(ctx, a, "lock-user", args, func(ctx context.Context, userId int64) (string, error) {
+		return a.services.LockUser(ctx, userId)
+	})
+}
+
+// adminUnlockUser - unlocks user.
+func adminUnlockUser(ctx context.Context, a *adminTool, args []string) error {
+	return adminChangeUserStatus(ctx, a, "unlock-user", args, func(ctx context.Context, userId int64) (string, error) {
+		return a.services.UnlockUser(ctx, userId)
+	})
+}
+
+// adminChangeUserStatus - changes status of user and writes the result.
+func adminChangeUserStatus(ctx context.Context, a *adminTool, name string, args []string, change func(ctx context.Context, userId int64) (string, error)) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of user")
+	_ = flags.Parse(args)
+	if *userFlag == "" {
+		return errors.New("-user is required")
+	}
+
+	a.connect()
+	user, err := a.user(ctx, *userFlag)
+	if err != nil {
+		return err
+	}
+
+	status, err := change(ctx, int64(user.ID))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "user #%d %s: %s\n", user.ID, user.Email, status)
+	return nil
+}
+
+// adminLockAccount - locks bank account as user with accounts:lock permission.
+func adminLockAccount(ctx context.Context, a *adminTool, args []string) error {
+	return adminChangeAccountStatus(ctx, a, "lock-account", args, func(ctx context.Context, inp *service.ChangeBankAccountInput) (string, error) {
+		return a.services.BlockBankAccount(ctx, 0, domain.Permissions{domain.PermissionAccountsLock}, inp)
+	})
+}
+
+// adminUnlockAccount - unlocks bank account as user with accounts:lock permission.
+func adminUnlockAccount(ctx context.Context, a *adminTool, args []string) error {
+	return adminChangeAccountStatus(ctx, a, "unlock-account", args, func(ctx context.Context, inp *service.ChangeBankAccountInput) (string, error) {
+		return a.services.UnlockBankAccount(ctx, 0, domain.Permissions{domain.PermissionAccountsLock}, inp)
+	})
+}
+
+// adminChangeAccountStatus - changes status of bank account and writes the result.
+func adminChangeAccountStatus(ctx context.Context, a *adminTool, name string, args []string, change func(ctx context.Context, inp *service.ChangeBankAccountInput) (string, error)) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	card := flags.Int64("card", 0, "card number of bank account")
+	_ = flags.Parse(args)
+	if *card == 0 {
+		return errors.New("-card is required")
+	}
+
+	a.connect()
+	status, err := change(ctx, &service.ChangeBankAccountInput{CardNumber: *card})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "bank account %d: %s\n", *card, status)
+	return nil
+}
+
+// adminResetPassword - sets new password of user and revokes their sessions.
+func adminResetPassword(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of user")
+	password := flags.String("password", "", "new password, generated when empty")
+	_ = flags.Parse(args)
+	if *userFlag == "" {
+		return errors.New("-user is required")
+	}
+
+	generated := *password == ""
+	if generated {
+		var err error
+		*password, err = randomPassword()
+		if err != nil {
+			return err
+		}
+	}
+
+	a.connect()
+	user, err := a.user(ctx, *userFlag)
+	if err != nil {
+		return err
+	}
+
+	err = a.services.SetUserPassword(ctx, user.ID, *password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "password of user #%d %s changed, sessions revoked\n", user.ID, user.Email)
+	if generated {
+		fmt.Fprintf(a.out, "generated password: %s\n", *password)
+	}
+	return nil
+}
+
+// adminListAccounts - writes page of bank accounts of user or of all users.
+func adminListAccounts(ctx context.Context, a *adminTool, args []string) error {
+	flags := flag.NewFlagSet("list-accounts", flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of account member, all accounts when empty")
+	page := flags.Int("page", 1, "page number")
+	list := flags.Int("list", domain.DefaultSearchLimit, "accounts on page")
+	sortBy := flags.String("sort", "", "field to sort by, for example \"balance desc\"")
+	_ = flags.Parse(args)
+
+	filter := &domain.Filter{Page: *page, List: *list}
+	if *sortBy != "" {
+		filter.SortBy = []string{*sortBy}
+	}
+	filter.Validate()
+	if err := filter.ValidateSortBy(domain.BankAccountSortFields); err != nil {
+		return err
+	}
+
+	a.connect()
+	userId := 0
+	permissions := domain.Permissions{domain.PermissionAccountsReadAll}
+	if *userFlag != "" {
+		user, err := a.user(ctx, *userFlag)
+		if err != nil {
+			return err
+		}
+		userId = user.ID
+		permissions = nil
+	}
+
+	accounts, err := a.services.SearchBankAccounts(ctx, filter, userId, permissions)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCARD\tIBAN\tCLIENT\tTYPE\tSTATUS\tBALANCE")
+	for _, account := range accounts.Data {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%.2f\n",
+			account.ID, account.CardNumber, account.IBAN, account.Client, account.Type, account.Status, account.Balance)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if p := accounts.Pagination; p != nil && p.Total != nil {
+		fmt.Fprintf(a.out, "page %d, %d of %d accounts\n", p.Page, len(accounts.Data), *p.Total)
+	}
+	return nil
+}
+
+// roleNames - returns names of roles.
+func roleNames(roles []domain.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// randomPassword - generates password given to user by operator.
+func randomPassword() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
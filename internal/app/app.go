@@ -1,8 +1,10 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -13,9 +15,13 @@ import (
 	"github.com/Shevchenkko/payment_system/pkg/httpserver"
 	"github.com/Shevchenkko/payment_system/pkg/logger"
 	"github.com/Shevchenkko/payment_system/pkg/mysql"
+	"github.com/Shevchenkko/payment_system/pkg/scheduler"
 
 	// internal
 	"github.com/Shevchenkko/payment_system/internal/api/emails"
+	"github.com/Shevchenkko/payment_system/internal/api/push"
+	"github.com/Shevchenkko/payment_system/internal/api/sms"
+	"github.com/Shevchenkko/payment_system/internal/api/webhooks"
 	"github.com/Shevchenkko/payment_system/internal/controller"
 	"github.com/Shevchenkko/payment_system/internal/domain"
 	"github.com/Shevchenkko/payment_system/internal/repository"
@@ -29,6 +35,112 @@ func Run() {
 	l := logger.New(os.Getenv("LOG_LEVEL"))
 
 	// init repository
+	sql := newMySQL(l)
+
+	// init repositories and services
+	repositories := newRepositories(sql)
+	services := newServices(repositories, newAPIs(l))
+
+	// init framework of choice
+	handler := gin.New()
+
+	// init router
+	controller.NewRouter(handler, services, l, repositories)
+
+	// init and run background jobs
+	jobs := scheduler.New(scheduler.ErrorHandler(func(name string, err error) {
+		l.Error("app - Run - scheduler job failed", "job", name, "err", err)
+	}))
+	jobs.Add("deposits.maturity", time.Hour, func(ctx context.Context) error {
+		_, err := services.Deposits.ProcessMaturedDeposits(ctx, time.Now())
+		return err
+	})
+	jobs.Add("loans.installments", time.Hour, func(ctx context.Context) error {
+		_, err := services.Loans.ProcessLoanInstallments(ctx, time.Now())
+		return err
+	})
+	jobs.Add("payments.categorize", time.Minute, func(ctx context.Context) error {
+		_, err := services.Spending.CategorizePayments(ctx)
+		return err
+	})
+	jobs.Add("business_days.close", time.Hour, func(ctx context.Context) error {
+		_, err := services.CloseBusinessDays(ctx, time.Now())
+		return err
+	})
+	jobs.Add("webhooks.delivery", 15*time.Second, func(ctx context.Context) error {
+		_, err := services.DeliverWebhooks(ctx, time.Now())
+		return err
+	})
+	jobs.Add("emails.delivery", 15*time.Second, func(ctx context.Context) error {
+		_, err := services.DeliverEmails(ctx, time.Now())
+		return err
+	})
+	jobs.Add("notifications.delivery", 15*time.Second, func(ctx context.Context) error {
+		_, err := services.DeliverNotifications(ctx, time.Now())
+		return err
+	})
+	jobs.Add("sessions.cleanup", 24*time.Hour, func(ctx context.Context) error {
+		_, err := services.DeleteExpiredSessions(ctx, time.Now())
+		return err
+	})
+	reconcile := reconcileInputFromEnv(l)
+	jobs.Add("balances.reconciliation", 24*time.Hour, func(ctx context.Context) error {
+		report, err := services.Reconcile(ctx, reconcile)
+		if err != nil {
+			return err
+		}
+		if report.Run.Discrepancies > 0 {
+			l.Warn("app - Run - balance discrepancies found", "run", report.Run.ID, "discrepancies", report.Run.Discrepancies, "frozen", report.Run.Frozen)
+		}
+		return nil
+	})
+	jobs.Start()
+
+	// init and run http server
+	httpServer := httpserver.New(handler, httpserver.Port(os.Getenv("HTTP_PORT")), httpserver.ReadTimeout(60*time.Second), httpserver.WriteTimeout(60*time.Second))
+
+	// waiting signal
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	var err error
+	select {
+	case s := <-interrupt:
+		l.Info("app - Run - signal: " + s.String())
+
+	case err = <-httpServer.Notify():
+		l.Error("app - Run - httpServer.Notify", "err", err)
+	}
+
+	// shutdown http server
+	err = httpServer.Shutdown()
+	if err != nil {
+		l.Error("app - Run - httpServer.Shutdown", "err", err)
+	}
+
+	// shutdown background jobs
+	err = jobs.Shutdown()
+	if err != nil {
+		l.Error("app - Run - jobs.Shutdown", "err", err)
+	}
+}
+
+// dataMigration - represents data migration run after schema automigration.
+type dataMigration struct {
+	name    string
+	migrate func(ctx context.Context) (int64, error)
+}
+
+// newMySQL - connects to mysql and migrates schema and data.
+func newMySQL(l logger.Interface) *mysql.MySQL {
+	sql := connectMySQL(l)
+	migrateMySQL(l, sql)
+
+	return sql
+}
+
+// connectMySQL - connects to mysql without touching schema.
+func connectMySQL(l logger.Interface) *mysql.MySQL {
 	sql, err := mysql.New(mysql.MySQLConfig{
 		User:     os.Getenv("MYSQL_USER"),
 		Password: os.Getenv("MYSQL_PASSWORD"),
@@ -39,70 +151,257 @@ func Run() {
 		l.Fatal("failed to connect to mysql", "err", err)
 	}
 
-	err = sql.DB.AutoMigrate(
+	return sql
+}
+
+// migrateMySQL - migrates schema and data, run on app start and by admin migrate command.
+func migrateMySQL(l logger.Interface, sql *mysql.MySQL) {
+	// balances before top ups were recorded become opening top ups once
+	openingBalances := !sql.DB.Migrator().HasTable(&domain.TopUp{})
+
+	err := sql.DB.AutoMigrate(
+		&domain.Permission{},
+		&domain.Role{},
 		&domain.User{},
 		&domain.BankAccount{},
+		&domain.AccountMember{},
 		&domain.MessageLog{},
 		&domain.Payment{},
+		&domain.TopUp{},
+		&domain.DepositProduct{},
+		&domain.Deposit{},
+		&domain.DepositInterest{},
+		&domain.LoanProduct{},
+		&domain.Loan{},
+		&domain.LoanInstallment{},
+		&domain.LoanRepayment{},
+		&domain.ReconciliationRun{},
+		&domain.Discrepancy{},
+		&domain.BusinessDay{},
+		&domain.DailyPaymentVolume{},
+		&domain.BalanceSnapshot{},
+		&domain.Webhook{},
+		&domain.WebhookDelivery{},
+		&domain.Merchant{},
+		&domain.MerchantAPIKey{},
+		&domain.PaymentLink{},
+		&domain.Split{},
+		&domain.SplitShare{},
+		&domain.CategoryRule{},
+		&domain.Budget{},
+		&domain.Notification{},
+		&domain.NotificationPreference{},
+		&domain.OutboxEmail{},
+		&domain.DeviceToken{},
+		&domain.NotificationDelivery{},
+		&domain.Session{},
 	)
 
 	if err != nil {
 		l.Fatal("automigration failed", "err", err)
 	}
 
-	// init apis
-	apis := service.APIs{
-		Emails: emails.New(),
+	// seed roles, key ownership of rows created before by user id and give old accounts their owner
+	migrations := []dataMigration{
+		{"roles", repository.NewRolesRepo(sql).MigrateRoles},
+		{"bank account owners", repository.NewBankAccountsRepo(sql).MigrateClientIDs},
+		{"account members", repository.NewBankAccountsRepo(sql).MigrateAccountMembers},
+		{"payment senders", repository.NewPaymentsRepo(sql).MigrateClientIDs},
+		{"payment sent times", repository.NewPaymentsRepo(sql).MigrateSentAt},
+		{"message log clients", repository.NewMessageLogsRepo(sql).MigrateClientIDs},
+		{"frozen accounts", repository.NewReconciliationRepo(sql).MigrateFrozenAccounts},
+	}
+	if openingBalances {
+		migrations = append(migrations, dataMigration{"opening balances", repository.NewReconciliationRepo(sql).CreateOpeningBalances})
+	}
+	for _, m := range migrations {
+		count, err := m.migrate(context.Background())
+		if err != nil {
+			l.Fatal("data migration failed", "migration", m.name, "err", err)
+		}
+		if count > 0 {
+			l.Info("data migrated", "migration", m.name, "count", count)
+		}
+	}
+}
+
+// newRepositories - creates all repositories.
+func newRepositories(sql *mysql.MySQL) service.Repositories {
+	return service.Repositories{
+		Users:          repository.NewUsersRepo(sql),
+		Banks:          repository.NewBankAccountsRepo(sql),
+		Payments:       repository.NewPaymentsRepo(sql),
+		Messages:       repository.NewMessageLogsRepo(sql),
+		Deposits:       repository.NewDepositsRepo(sql),
+		Loans:          repository.NewLoansRepo(sql),
+		Reconciliation: repository.NewReconciliationRepo(sql),
+		BusinessDays:   repository.NewBusinessDaysRepo(sql),
+		Webhooks:       repository.NewWebhooksRepo(sql),
+		Merchants:      repository.NewMerchantsRepo(sql),
+		PaymentLinks:   repository.NewPaymentLinksRepo(sql),
+		Splits:         repository.NewSplitsRepo(sql),
+		Spending:       repository.NewSpendingRepo(sql),
+		Budgets:        repository.NewBudgetsRepo(sql),
+		Notifications:  repository.NewNotificationsRepo(sql),
+		Emails:         repository.NewEmailsRepo(sql),
+		Roles:          repository.NewRolesRepo(sql),
+		Transactions:   repository.NewTransactionsRepo(sql),
+	}
+}
+
+// newAPIs - creates all apis.
+func newAPIs(l logger.Interface) service.APIs {
+	return service.APIs{
+		Emails:   newEmailsAPI(l),
+		Webhooks: webhooks.New(),
+		Channels: newNotificationChannels(),
 	}
+}
 
-	// init repositories
-	repositories := service.Repositories{
-		Users:    repository.NewUsersRepo(sql),
-		Banks:    repository.NewBankAccountsRepo(sql),
-		Payments: repository.NewPaymentsRepo(sql),
-		Messages: repository.NewMessageLogsRepo(sql),
+// newNotificationChannels - creates sms api when SMS_GATEWAY_URL is set and push api when PUSH_SERVER_KEY is set.
+func newNotificationChannels() []service.NotificationChannel {
+	var channels []service.NotificationChannel
+	if url := os.Getenv("SMS_GATEWAY_URL"); url != "" {
+		channels = append(channels, sms.New(sms.Config{
+			URL:    url,
+			Token:  os.Getenv("SMS_GATEWAY_TOKEN"),
+			Sender: os.Getenv("SMS_SENDER"),
+		}))
 	}
+	if key := os.Getenv("PUSH_SERVER_KEY"); key != "" {
+		channels = append(channels, push.New(push.Config{
+			URL:       os.Getenv("PUSH_URL"),
+			ServerKey: key,
+		}))
+	}
+
+	return channels
+}
 
-	services := service.Services{
+// newEmailsAPI - creates emails api chosen by MAIL_TRANSPORT: smtp (default), file or memory.
+func newEmailsAPI(l logger.Interface) service.EmailsAPI {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = os.Getenv("MAIL_USERNAME")
+	}
+
+	switch transport := os.Getenv("MAIL_TRANSPORT"); transport {
+	case "", "smtp":
+		port := 587
+		if value := os.Getenv("MAIL_PORT"); value != "" {
+			var err error
+			port, err = strconv.Atoi(value)
+			if err != nil {
+				l.Fatal("invalid MAIL_PORT", "err", err)
+			}
+		}
+		api, err := emails.New(emails.Config{
+			Host:     os.Getenv("MAIL_HOST"),
+			Port:     port,
+			TLS:      os.Getenv("MAIL_TLS"),
+			Username: os.Getenv("MAIL_USERNAME"),
+			Password: os.Getenv("MAIL_APP_PASSWORD"),
+			From:     from,
+		})
+		if err != nil {
+			l.Fatal("invalid MAIL_TLS", "err", err)
+		}
+		return api
+	case "file":
+		path := os.Getenv("MAIL_FILE")
+		if path == "" {
+			path = "mail.mbox"
+		}
+		l.Info("emails are written to file", "path", path)
+		return emails.NewFile(path, from)
+	case "memory":
+		l.Info("emails are captured in memory")
+		return emails.NewMemory()
+	default:
+		l.Fatal("unknown MAIL_TRANSPORT", "transport", transport)
+		return nil
+	}
+}
+
+// newServices - creates all services.
+func newServices(repositories service.Repositories, apis service.APIs) service.Services {
+	return service.Services{
 		Users: service.NewUserService(
 			repositories,
 			apis,
 		),
 		BankAccounts: service.NewBankAccountService(
 			repositories,
+			apis,
 		),
 		Payments: service.NewPaymentService(
 			repositories,
+			apis,
 		),
 		MessageLogs: service.NewMessageLogsService(
 			repositories,
 		),
+		Deposits: service.NewDepositService(
+			repositories,
+		),
+		Loans: service.NewLoanService(
+			repositories,
+		),
+		Reconciliation: service.NewReconciliationService(
+			repositories,
+			apis,
+		),
+		BusinessDays: service.NewBusinessDaysService(
+			repositories,
+		),
+		Webhooks: service.NewWebhooksService(
+			repositories,
+			apis,
+		),
+		Merchants: service.NewMerchantsService(
+			repositories,
+		),
+		PaymentLinks: service.NewPaymentLinksService(
+			repositories,
+			apis,
+		),
+		Splits: service.NewSplitsService(
+			repositories,
+			apis,
+		),
+		Spending: service.NewSpendingService(
+			repositories,
+		),
+		Budgets: service.NewBudgetsService(
+			repositories,
+			apis,
+		),
+		Notifications: service.NewNotificationsService(
+			repositories,
+			apis,
+		),
+		Emails: service.NewEmailsService(
+			repositories,
+			apis,
+		),
+		Roles: service.NewRolesService(
+			repositories,
+		),
 	}
+}
 
-	// init framework of choice
-	handler := gin.New()
-
-	// init router
-	controller.NewRouter(handler, services, l, repositories)
-
-	// init and run http server
-	httpServer := httpserver.New(handler, httpserver.Port(os.Getenv("HTTP_PORT")), httpserver.ReadTimeout(60*time.Second), httpserver.WriteTimeout(60*time.Second))
-
-	// waiting signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
-	select {
-	case s := <-interrupt:
-		l.Info("app - Run - signal: " + s.String())
-
-	case err = <-httpServer.Notify():
-		l.Error("app - Run - httpServer.Notify", "err", err)
+// reconcileInputFromEnv - returns scheduled reconciliation settings.
+// Accounts are frozen only when RECONCILIATION_FREEZE_THRESHOLD is set.
+func reconcileInputFromEnv(l logger.Interface) *service.ReconcileInput {
+	value := os.Getenv("RECONCILIATION_FREEZE_THRESHOLD")
+	if value == "" {
+		return &service.ReconcileInput{}
 	}
 
-	// shutdown http server
-	err = httpServer.Shutdown()
+	threshold, err := strconv.ParseFloat(value, 64)
 	if err != nil {
-		l.Error("app - Run - httpServer.Shutdown", "err", err)
+		l.Fatal("invalid RECONCILIATION_FREEZE_THRESHOLD", "err", err)
 	}
+
+	return &service.ReconcileInput{Threshold: threshold, Freeze: true}
 }
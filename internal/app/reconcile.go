@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// Reconcile - runs balance reconciliation once and writes report to stdout.
+func Reconcile(args []string) {
+
+	// parse flags
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	format := flags.String("format", "json", "report format: json or csv")
+	threshold := flags.Float64("threshold", 0, "freeze accounts with mismatch above threshold")
+	freeze := flags.Bool("freeze", false, "lock accounts with mismatch above threshold")
+	_ = flags.Parse(args)
+
+	// init logger
+	l := logger.New(os.Getenv("LOG_LEVEL"))
+
+	// init repositories and services
+	services := newServices(newRepositories(newMySQL(l)), newAPIs(l))
+
+	report, err := services.Reconcile(context.Background(), &service.ReconcileInput{
+		Threshold: *threshold,
+		Freeze:    *freeze,
+	})
+	if err != nil {
+		l.Fatal("app - Reconcile - services.Reconcile", "err", err)
+	}
+
+	if *format == "csv" {
+		err = report.WriteCSV(os.Stdout)
+	} else {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	}
+	if err != nil {
+		l.Fatal("app - Reconcile - write report", "err", err)
+	}
+}
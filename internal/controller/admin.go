@@ -11,6 +11,7 @@ import (
 	"github.com/Shevchenkko/payment_system/pkg/logger"
 
 	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
 	"github.com/Shevchenkko/payment_system/internal/service"
 )
 
@@ -27,8 +28,16 @@ func newAdminRoutes(handler *gin.RouterGroup, s service.Services, l logger.Inter
 	h := handler.Group("/admin")
 	{
 		// routes
-		h.PATCH("/lock_user", newAuthMiddleware(s, l), r.lockUser)
-		h.PATCH("/unlock_user", newAuthMiddleware(s, l), r.unlockUser)
+		h.PATCH("/lock_user", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionUsersLock), r.lockUser)
+		h.PATCH("/unlock_user", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionUsersLock), r.unlockUser)
+		h.GET("/reconciliation", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionReconciliationRead), r.getReconciliationReport)
+		h.POST("/reconciliation", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionReconciliationRun), r.reconcile)
+		h.GET("/email_preview", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionEmailsManage), r.previewEmail)
+		h.GET("/email_queue", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionEmailsManage), r.searchEmailQueue)
+		h.PATCH("/email_queue/retry", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionEmailsManage), r.retryEmail)
+		h.GET("/roles", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionRolesAssign), r.searchRoles)
+		h.GET("/user_roles", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionRolesAssign), r.getUserRoles)
+		h.PATCH("/user_roles", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionRolesAssign), r.assignUserRoles)
 	}
 }
 
@@ -57,28 +66,15 @@ func (r *adminRoutes) lockUser(c *gin.Context) {
 	}
 	logger = logger.With("body", body)
 
-	// check role
-	var status string
-	if c.GetString("userRole") == "admin" {
-		status, err = r.service.LockUser(c.Request.Context(), body.UserID, c.GetString("userRole"))
-		if err != nil {
-			logger.Error("failed to block user", "err", err)
-			err, ok := err.(*service.Error)
-			if ok {
-				c.AbortWithStatusJSON(http.StatusBadRequest, lockUserResponse{Error: err})
-				return
-			}
-			errorResponse(c, http.StatusInternalServerError, "failed to block user")
-			return
-		}
-	} else {
+	status, err := r.service.LockUser(c.Request.Context(), body.UserID)
+	if err != nil {
 		logger.Error("failed to block user", "err", err)
 		err, ok := err.(*service.Error)
 		if ok {
 			c.AbortWithStatusJSON(http.StatusBadRequest, lockUserResponse{Error: err})
 			return
 		}
-		errorResponse(c, http.StatusForbidden, "you need admin rights!")
+		errorResponse(c, http.StatusInternalServerError, "failed to block user")
 		return
 	}
 
@@ -131,28 +127,15 @@ func (r *adminRoutes) unlockUser(c *gin.Context) {
 	}
 	logger = logger.With("body", body)
 
-	// check role
-	var status string
-	if c.GetString("userRole") == "admin" {
-		status, err = r.service.UnlockUser(c.Request.Context(), body.UserID, c.GetString("userRole"))
-		if err != nil {
-			logger.Error("failed to unlock user", "err", err)
-			err, ok := err.(*service.Error)
-			if ok {
-				c.AbortWithStatusJSON(http.StatusBadRequest, unlockUserResponse{Error: err})
-				return
-			}
-			errorResponse(c, http.StatusInternalServerError, "failed to unlock user")
-			return
-		}
-	} else {
+	status, err := r.service.UnlockUser(c.Request.Context(), body.UserID)
+	if err != nil {
 		logger.Error("failed to unlock user", "err", err)
 		err, ok := err.(*service.Error)
 		if ok {
 			c.AbortWithStatusJSON(http.StatusBadRequest, unlockUserResponse{Error: err})
 			return
 		}
-		errorResponse(c, http.StatusForbidden, "you need admin rights!")
+		errorResponse(c, http.StatusInternalServerError, "failed to unlock user")
 		return
 	}
 
@@ -3,6 +3,8 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	// third party
 	"github.com/gin-gonic/gin"
@@ -28,11 +30,16 @@ func newBankAccountRoutes(handler *gin.RouterGroup, s service.Services, l logger
 	h := handler.Group("/bank_account")
 	{
 		// routes
-		h.GET("/search", newAuthMiddleware(s, l), r.searchBankAccount)
+		h.GET("/search", newAuthMiddleware(s, l, "accounts:read"), r.searchBankAccount)
 		h.POST("/create", newAuthMiddleware(s, l), r.createBankAccount)
 		h.PATCH("/top_up", newAuthMiddleware(s, l), r.topUpBankAccount)
 		h.PATCH("/lock", newAuthMiddleware(s, l), r.lockBankAccount)
 		h.PATCH("/unlock", newAuthMiddleware(s, l), r.unlockBankAccount)
+		h.GET("/members", newAuthMiddleware(s, l), r.searchAccountMembers)
+		h.POST("/members", newAuthMiddleware(s, l), r.addAccountMember)
+		h.PATCH("/remove_member", newAuthMiddleware(s, l), r.removeAccountMember)
+		h.PATCH("/close", newAuthMiddleware(s, l), r.closeBankAccount)
+		h.GET("/balance_history", newAuthMiddleware(s, l, "accounts:read"), r.getBalanceHistory)
 	}
 }
 
@@ -52,10 +59,10 @@ type searchBankAccountResponse struct {
 func (r *bankAccountRoutes) searchBankAccount(c *gin.Context) {
 	logger := r.logger.Named("searchBankAccount")
 
-	filter, err := getFilterFromQuery(c.Request)
+	filter, err := getFilterFromQuery(c.Request, domain.BankAccountSortFields)
 	if err != nil {
 		logger.Error("failed to parse query params", "err", err)
-		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -78,7 +85,7 @@ func (r *bankAccountRoutes) searchBankAccount(c *gin.Context) {
 		return
 	}
 
-	response, err := r.service.BankAccounts.SearchBankAccounts(c.Request.Context(), filter, client.FullName, c.GetString("userRole"))
+	response, err := r.service.BankAccounts.SearchBankAccounts(c.Request.Context(), filter, client.ID, getPermissions(c))
 	if err != nil {
 		logger.Error("failed to search bank accounts", "err", err)
 		// get service error
@@ -234,6 +241,14 @@ func (r *bankAccountRoutes) topUpBankAccount(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, "failed to top up the bank account, please unlock your bank account")
 		return
 	}
+	if status.Status == "FROZEN" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, "failed to top up the bank account, bank account is frozen")
+		return
+	}
+	if status.Type == "DEPOSIT" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, "failed to top up the bank account, deposit account can not be topped up")
+		return
+	}
 
 	// top up bank account for client
 	logger.Debug("top up bank account for client")
@@ -309,7 +324,7 @@ func (r *bankAccountRoutes) lockBankAccount(c *gin.Context) {
 
 	// lock bank account
 	logger.Debug("bank account blocking")
-	status, err := r.service.BlockBankAccount(c.Request.Context(), client.FullName, c.GetString("userRole"),
+	status, err := r.service.BlockBankAccount(c.Request.Context(), client.ID, getPermissions(c),
 		&service.ChangeBankAccountInput{
 			CardNumber:  body.CardNumber,
 			SecretValue: body.SecretValue,
@@ -381,7 +396,7 @@ func (r *bankAccountRoutes) unlockBankAccount(c *gin.Context) {
 
 	// unlock bank account
 	logger.Debug("bank account unlocking")
-	status, err := r.service.UnlockBankAccount(c.Request.Context(), client.FullName, c.GetString("userRole"),
+	status, err := r.service.UnlockBankAccount(c.Request.Context(), client.ID, getPermissions(c),
 		&service.ChangeBankAccountInput{
 			CardNumber:  body.CardNumber,
 			SecretValue: body.SecretValue,
@@ -415,9 +430,341 @@ func (r *bankAccountRoutes) unlockBankAccount(c *gin.Context) {
 	}
 }
 
+// searchAccountMembersResponse - represents search account members response.
+type searchAccountMembersResponse struct {
+	Data  []service.AccountMemberOutput `json:"data"`
+	Error *service.Error                `json:"error,omitempty"`
+}
+
+func (r *bankAccountRoutes) searchAccountMembers(c *gin.Context) {
+	logger := r.logger.Named("searchAccountMembers")
+
+	cardNumber, err := strconv.ParseInt(c.Query("cardNumber"), 10, 64)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	members, err := r.service.SearchAccountMembers(c.Request.Context(), client.ID, getPermissions(c), cardNumber)
+	if err != nil {
+		logger.Error("failed to search account members", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchAccountMembersResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search account members")
+		return
+	}
+
+	logger.Info("successfully search account members")
+	c.JSON(http.StatusOK, searchAccountMembersResponse{Data: members})
+}
+
+// accountMemberRequestBody - represents addAccountMember and removeAccountMember request body.
+type accountMemberRequestBody struct {
+	CardNumber  int64  `json:"cardNumber" binding:"required"`
+	SecretValue string `json:"secretValue" binding:"required"`
+	UserID      int    `json:"userId" binding:"required"`
+	Role        string `json:"role"`
+}
+
+// accountMemberResponse - represents addAccountMember and removeAccountMember response.
+type accountMemberResponse struct {
+	Member *domain.AccountMember `json:"member,omitempty"`
+	Error  *service.Error        `json:"error,omitempty"`
+}
+
+func (r *bankAccountRoutes) addAccountMember(c *gin.Context) {
+	logger := r.logger.Named("addAccountMember")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body accountMemberRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("cardNumber", body.CardNumber, "userId", body.UserID, "role", body.Role)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// add member
+	logger.Debug("adding bank account member")
+	member, err := r.service.AddAccountMember(c.Request.Context(), client.ID,
+		&service.AccountMemberInput{
+			CardNumber:  body.CardNumber,
+			SecretValue: body.SecretValue,
+			UserID:      body.UserID,
+			Role:        body.Role,
+		})
+	if err != nil {
+		logger.Error("failed to add bank account member", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, accountMemberResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to add bank account member")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully added user #%d as %s to bank account %d", body.UserID, body.Role, body.CardNumber),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully added bank account member")
+	c.JSON(http.StatusOK, accountMemberResponse{Member: member})
+}
+
+func (r *bankAccountRoutes) removeAccountMember(c *gin.Context) {
+	logger := r.logger.Named("removeAccountMember")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body accountMemberRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("cardNumber", body.CardNumber, "userId", body.UserID)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// remove member
+	logger.Debug("removing bank account member")
+	err = r.service.RemoveAccountMember(c.Request.Context(), client.ID,
+		&service.AccountMemberInput{
+			CardNumber:  body.CardNumber,
+			SecretValue: body.SecretValue,
+			UserID:      body.UserID,
+		})
+	if err != nil {
+		logger.Error("failed to remove bank account member", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, accountMemberResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to remove bank account member")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully removed user #%d from bank account %d", body.UserID, body.CardNumber),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully removed bank account member")
+	c.JSON(http.StatusOK, accountMemberResponse{})
+}
+
+// closeBankAccountRequestBody - represents closeBankAccount request body.
+type closeBankAccountRequestBody struct {
+	CardNumber       int64  `json:"cardNumber" binding:"required"`
+	SecretValue      string `json:"secretValue"`
+	TargetCardNumber int64  `json:"targetCardNumber"`
+	PayoutIBAN       string `json:"payoutIban"`
+	PayoutName       string `json:"payoutName"`
+}
+
+// closeBankAccountResponse - represents closeBankAccount response.
+type closeBankAccountResponse struct {
+	Account *service.BankAccountOutput `json:"account,omitempty"`
+	Error   *service.Error             `json:"error,omitempty"`
+}
+
+func (r *bankAccountRoutes) closeBankAccount(c *gin.Context) {
+	logger := r.logger.Named("closeBankAccount")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body closeBankAccountRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("cardNumber", body.CardNumber, "targetCardNumber", body.TargetCardNumber, "payoutIban", body.PayoutIBAN)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// close bank account
+	logger.Debug("closing bank account")
+	account, err := r.service.CloseBankAccount(c.Request.Context(), client.ID, getPermissions(c),
+		&service.CloseBankAccountInput{
+			CardNumber:       body.CardNumber,
+			SecretValue:      body.SecretValue,
+			TargetCardNumber: body.TargetCardNumber,
+			PayoutIBAN:       body.PayoutIBAN,
+			PayoutName:       body.PayoutName,
+		})
+	if err != nil {
+		logger.Error("failed to close bank account", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, closeBankAccountResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to close bank account")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully closed bank account %d", body.CardNumber),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully closed bank account")
+	c.JSON(http.StatusOK, closeBankAccountResponse{
+		Account: &service.BankAccountOutput{
+			ID:         account.ID,
+			Status:     account.Status,
+			Type:       account.Type,
+			Client:     account.Client,
+			CardNumber: account.CardNumber,
+			IBAN:       account.IBAN,
+			Balance:    account.Balance,
+			ClosedAt:   account.ClosedAt,
+		},
+	})
+}
+
+// balanceHistoryResponse - represents balance history response.
+type balanceHistoryResponse struct {
+	History *service.BalanceHistory `json:"history,omitempty"`
+	Error   *service.Error          `json:"error,omitempty"`
+}
+
+func (r *bankAccountRoutes) getBalanceHistory(c *gin.Context) {
+	logger := r.logger.Named("getBalanceHistory")
+
+	inp, err := getBalanceHistoryFromQuery(c.Request)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+	logger = logger.With("cardNumber", inp.CardNumber)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	history, err := r.service.GetBalanceHistory(c.Request.Context(), client.ID, getPermissions(c), inp)
+	if err != nil {
+		logger.Error("failed to get balance history", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, balanceHistoryResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to get balance history")
+		return
+	}
+
+	logger.Info("successfully get balance history")
+	c.JSON(http.StatusOK, balanceHistoryResponse{History: history})
+}
+
+// getBalanceHistoryFromQuery - returns balance history input from query.
+// Series defaults to last 30 days, date is optional.
+func getBalanceHistoryFromQuery(r *http.Request) (*service.BalanceHistoryInput, error) {
+	q := r.URL.Query()
+
+	cardNumber, err := strconv.ParseInt(q.Get("cardNumber"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inp := &service.BalanceHistoryInput{
+		CardNumber: cardNumber,
+		To:         time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.Local),
+	}
+	if q.Get("to") != "" {
+		inp.To, err = time.ParseInLocation("2006-01-02", q.Get("to"), time.Local)
+		if err != nil {
+			return nil, err
+		}
+	}
+	inp.From = inp.To.AddDate(0, 0, -29)
+	if q.Get("from") != "" {
+		inp.From, err = time.ParseInLocation("2006-01-02", q.Get("from"), time.Local)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if q.Get("date") != "" {
+		asOf, err := time.ParseInLocation("2006-01-02", q.Get("date"), time.Local)
+		if err != nil {
+			return nil, err
+		}
+		inp.AsOf = &asOf
+	}
+
+	return inp, nil
+}
+
 // getFilterFromQuery - returns filter from query.
-func getFilterFromQuery(r *http.Request) (*domain.Filter, error) {
-	filter, err := domain.GetFilterFromQuery(r)
+func getFilterFromQuery(r *http.Request, fields domain.SortFields) (*domain.Filter, error) {
+	filter, err := domain.GetFilterFromQuery(r, fields)
 	if err != nil {
 		return nil, err
 	}
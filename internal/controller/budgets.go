@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// budgetRoutes - represents budgets service router.
+type budgetRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newBudgetRoutes - implements new budgets service routes.
+func newBudgetRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &budgetRoutes{s, repo, l}
+	h := handler.Group("/budget")
+	{
+		// routes
+		h.POST("/create", newAuthMiddleware(s, l), r.createBudget)
+		h.GET("/search", newAuthMiddleware(s, l), r.searchBudgets)
+		h.PATCH("/update", newAuthMiddleware(s, l), r.updateBudget)
+		h.DELETE("/delete", newAuthMiddleware(s, l), r.deleteBudget)
+	}
+}
+
+// createBudgetRequestBody - represents createBudget request body.
+// Empty category sets overall budget.
+type createBudgetRequestBody struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount" binding:"required"`
+}
+
+// budgetResponse - represents budget response.
+type budgetResponse struct {
+	Budget *service.BudgetProgress `json:"budget,omitempty"`
+	Error  *service.Error          `json:"error,omitempty"`
+}
+
+func (r *budgetRoutes) createBudget(c *gin.Context) {
+	logger := r.logger.Named("createBudget")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createBudgetRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	budget, err := r.service.CreateBudget(c.Request.Context(), client.ID,
+		&service.BudgetInput{
+			Category: body.Category,
+			Amount:   body.Amount,
+		})
+	if err != nil {
+		logger.Error("failed to create budget", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, budgetResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create budget")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created budget #%d of %.2f", budget.ID, budget.Amount),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created budget")
+	c.JSON(http.StatusOK, budgetResponse{Budget: budget})
+}
+
+// searchBudgetsResponse - represents search budgets response.
+type searchBudgetsResponse struct {
+	Data  []service.BudgetProgress `json:"data"`
+	Error *service.Error           `json:"error,omitempty"`
+}
+
+func (r *budgetRoutes) searchBudgets(c *gin.Context) {
+	logger := r.logger.Named("searchBudgets")
+
+	budgets, err := r.service.SearchBudgets(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to search budgets", "err", err)
+		errorResponse(c, http.StatusInternalServerError, "failed to search budgets")
+		return
+	}
+
+	logger.Info("successfully searched budgets")
+	c.JSON(http.StatusOK, searchBudgetsResponse{Data: budgets})
+}
+
+// updateBudgetRequestBody - represents updateBudget request body.
+type updateBudgetRequestBody struct {
+	BudgetID int     `json:"budgetId" binding:"required"`
+	Amount   float64 `json:"amount" binding:"required"`
+}
+
+func (r *budgetRoutes) updateBudget(c *gin.Context) {
+	logger := r.logger.Named("updateBudget")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body updateBudgetRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	budget, err := r.service.UpdateBudget(c.Request.Context(), client.ID,
+		&service.UpdateBudgetInput{
+			BudgetID: body.BudgetID,
+			Amount:   body.Amount,
+		})
+	if err != nil {
+		logger.Error("failed to update budget", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, budgetResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to update budget")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully changed budget #%d to %.2f", budget.ID, budget.Amount),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully updated budget")
+	c.JSON(http.StatusOK, budgetResponse{Budget: budget})
+}
+
+func (r *budgetRoutes) deleteBudget(c *gin.Context) {
+	logger := r.logger.Named("deleteBudget")
+
+	budgetId, err := strconv.Atoi(c.Query("budgetId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+	logger = logger.With("budgetId", budgetId)
+
+	err = r.service.DeleteBudget(c.Request.Context(), c.GetInt("clientID"), budgetId)
+	if err != nil {
+		logger.Error("failed to delete budget", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, budgetResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to delete budget")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully deleted budget #%d", budgetId),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully deleted budget")
+	c.JSON(http.StatusOK, budgetResponse{})
+}
@@ -0,0 +1,315 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// depositRoutes - represents deposit service router.
+type depositRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newDepositRoutes - implements new deposit service routes.
+func newDepositRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &depositRoutes{s, repo, l}
+	h := handler.Group("/deposit")
+	{
+		// routes
+		h.GET("/products", newAuthMiddleware(s, l), r.searchDepositProducts)
+		h.POST("/products", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionDepositsManage), r.createDepositProduct)
+		h.GET("/search", newAuthMiddleware(s, l), r.searchDeposits)
+		h.POST("/open", newAuthMiddleware(s, l), r.openDeposit)
+		h.PATCH("/withdraw", newAuthMiddleware(s, l), r.withdrawDeposit)
+	}
+}
+
+// searchDepositProductsResponse - represents search deposit products response.
+type searchDepositProductsResponse struct {
+	Data       []domain.DepositProduct `json:"data"`
+	Pagination *domain.Pagination      `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *depositRoutes) searchDepositProducts(c *gin.Context) {
+	logger := r.logger.Named("searchDepositProducts")
+
+	filter, err := getFilterFromQuery(c.Request, domain.DepositProductSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := r.service.Deposits.SearchDepositProducts(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("failed to search deposit products", "err", err)
+		// get service error
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchDepositProductsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search deposit products")
+		return
+	}
+
+	logger.Info("successfully search deposit products")
+	c.JSON(http.StatusOK, searchDepositProductsResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// createDepositProductRequestBody - represents createDepositProduct request body.
+type createDepositProductRequestBody struct {
+	Name        string  `json:"name" binding:"required"`
+	TermMonths  int     `json:"termMonths" binding:"required"`
+	Rate        float64 `json:"rate" binding:"required"`
+	PenaltyRate float64 `json:"penaltyRate"`
+	MinAmount   float64 `json:"minAmount"`
+}
+
+// createDepositProductResponse - represents createDepositProduct response.
+type createDepositProductResponse struct {
+	Product *domain.DepositProduct `json:"product,omitempty"`
+	Error   *service.Error         `json:"error,omitempty"`
+}
+
+func (r *depositRoutes) createDepositProduct(c *gin.Context) {
+	logger := r.logger.Named("createDepositProduct")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createDepositProductRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	product, err := r.service.Deposits.CreateDepositProduct(c.Request.Context(),
+		&service.DepositProductInput{
+			Name:        body.Name,
+			TermMonths:  body.TermMonths,
+			Rate:        body.Rate,
+			PenaltyRate: body.PenaltyRate,
+			MinAmount:   body.MinAmount,
+		})
+	if err != nil {
+		logger.Error("failed to create deposit product", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, createDepositProductResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create deposit product")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created deposit product %s", product.Name),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created deposit product")
+	c.JSON(http.StatusOK, createDepositProductResponse{Product: product})
+}
+
+// searchDepositsResponse - represents search deposits response.
+type searchDepositsResponse struct {
+	Data       []domain.Deposit   `json:"data"`
+	Pagination *domain.Pagination `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *depositRoutes) searchDeposits(c *gin.Context) {
+	logger := r.logger.Named("searchDeposits")
+
+	filter, err := getFilterFromQuery(c.Request, domain.DepositSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	response, err := r.service.Deposits.SearchDeposits(c.Request.Context(), filter, client.ID)
+	if err != nil {
+		logger.Error("failed to search deposits", "err", err)
+		// get service error
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchDepositsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search deposits")
+		return
+	}
+
+	logger.Info("successfully search deposits")
+	c.JSON(http.StatusOK, searchDepositsResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// openDepositRequestBody - represents openDeposit request body.
+type openDepositRequestBody struct {
+	ProductID   int     `json:"productId" binding:"required"`
+	CardNumber  int64   `json:"cardNumber" binding:"required"`
+	SecretValue string  `json:"secretValue" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required"`
+	Rollover    bool    `json:"rollover"`
+}
+
+// depositResponse - represents deposit response.
+type depositResponse struct {
+	Deposit *domain.Deposit `json:"deposit,omitempty"`
+	Error   *service.Error  `json:"error,omitempty"`
+}
+
+func (r *depositRoutes) openDeposit(c *gin.Context) {
+	logger := r.logger.Named("openDeposit")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body openDepositRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("productId", body.ProductID, "cardNumber", body.CardNumber)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// open deposit
+	logger.Debug("opening deposit for client")
+	deposit, err := r.service.OpenDeposit(c.Request.Context(), client.ID,
+		&service.OpenDepositInput{
+			ProductID:   body.ProductID,
+			CardNumber:  body.CardNumber,
+			SecretValue: body.SecretValue,
+			Amount:      body.Amount,
+			Rollover:    body.Rollover,
+		})
+	if err != nil {
+		logger.Error("failed to open deposit", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, depositResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to open deposit")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully opened deposit #%d on amount %0.2f from bank account %d", deposit.ID, deposit.Principal, body.CardNumber),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully opened deposit")
+	c.JSON(http.StatusOK, depositResponse{Deposit: deposit})
+}
+
+// withdrawDepositRequestBody - represents withdrawDeposit request body.
+type withdrawDepositRequestBody struct {
+	DepositID   int    `json:"depositId" binding:"required"`
+	SecretValue string `json:"secretValue" binding:"required"`
+}
+
+func (r *depositRoutes) withdrawDeposit(c *gin.Context) {
+	logger := r.logger.Named("withdrawDeposit")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body withdrawDepositRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("depositId", body.DepositID)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// withdraw deposit
+	logger.Debug("withdrawing deposit")
+	deposit, err := r.service.WithdrawDeposit(c.Request.Context(), client.ID,
+		&service.WithdrawDepositInput{
+			DepositID:   body.DepositID,
+			SecretValue: body.SecretValue,
+		})
+	if err != nil {
+		logger.Error("failed to withdraw deposit", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, depositResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to withdraw deposit")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully withdrew deposit #%d before maturity", deposit.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully withdrew deposit")
+	c.JSON(http.StatusOK, depositResponse{Deposit: deposit})
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"net/http"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// devRoutes - represents local development router.
+// Routes answer only when emails are captured in memory (MAIL_TRANSPORT=memory), which is never done in production.
+type devRoutes struct {
+	service service.Services
+	logger  logger.Interface
+}
+
+// newDevRoutes - implements new local development routes.
+func newDevRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface) {
+	r := &devRoutes{s, l}
+	h := handler.Group("/dev")
+	{
+		// routes
+		h.GET("/emails", r.searchCapturedEmails)
+		h.DELETE("/emails", r.clearCapturedEmails)
+	}
+}
+
+// searchCapturedEmailsResponse - represents search captured emails response.
+type searchCapturedEmailsResponse struct {
+	Data  []service.CapturedEmail `json:"data"`
+	Error *service.Error          `json:"error,omitempty"`
+}
+
+func (r *devRoutes) searchCapturedEmails(c *gin.Context) {
+	logger := r.logger.Named("searchCapturedEmails")
+
+	emails, err := r.service.SearchCapturedEmails(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to search captured emails", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, searchCapturedEmailsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search captured emails")
+		return
+	}
+
+	logger.Info("successfully search captured emails")
+	c.JSON(http.StatusOK, searchCapturedEmailsResponse{Data: emails})
+}
+
+func (r *devRoutes) clearCapturedEmails(c *gin.Context) {
+	logger := r.logger.Named("clearCapturedEmails")
+
+	err := r.service.ClearCapturedEmails(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to clear captured emails", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, searchCapturedEmailsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to clear captured emails")
+		return
+	}
+
+	logger.Info("successfully cleared captured emails")
+	c.JSON(http.StatusOK, searchCapturedEmailsResponse{Data: []service.CapturedEmail{}})
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+	"github.com/Shevchenkko/payment_system/internal/templates"
+)
+
+// previewEmailRequestQuery - represents previewEmail request query.
+// Format is json by default, html and text return email body as is.
+type previewEmailRequestQuery struct {
+	Template string `form:"template" binding:"required"`
+	Language string `form:"language"`
+	Format   string `form:"format"`
+}
+
+// previewEmailResponse - represents previewEmail response.
+type previewEmailResponse struct {
+	Email *templates.Email `json:"email,omitempty"`
+	Error *service.Error   `json:"error,omitempty"`
+}
+
+func (r *adminRoutes) previewEmail(c *gin.Context) {
+	logger := r.logger.Named("previewEmail")
+
+	// parse request query
+	var query previewEmailRequestQuery
+	logger.Info("parsing request query")
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logger.Error("failed to parse request query", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+		return
+	}
+	logger = logger.With("query", query)
+	if query.Language == "" {
+		query.Language = templates.LanguageUK
+	}
+
+	email, err := r.service.PreviewEmail(c.Request.Context(), query.Template, query.Language)
+	if err != nil {
+		logger.Error("failed to preview email", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, previewEmailResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to preview email")
+		return
+	}
+
+	logger.Info("successfully previewed email")
+	switch query.Format {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(email.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(email.Text))
+	default:
+		c.JSON(http.StatusOK, previewEmailResponse{Email: email})
+	}
+}
+
+// searchEmailQueueResponse - represents search email queue response.
+type searchEmailQueueResponse struct {
+	Data       []domain.OutboxEmail `json:"data"`
+	Counts     map[string]int64     `json:"counts"`
+	Pagination *domain.Pagination   `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *adminRoutes) searchEmailQueue(c *gin.Context) {
+	logger := r.logger.Named("searchEmailQueue")
+
+	filter, err := getFilterFromQuery(c.Request, domain.OutboxEmailSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := r.service.SearchOutboxEmails(c.Request.Context(), filter, c.Query("status"))
+	if err != nil {
+		logger.Error("failed to search email queue", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchEmailQueueResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search email queue")
+		return
+	}
+
+	logger.Info("successfully search email queue")
+	c.JSON(http.StatusOK, searchEmailQueueResponse{
+		Data:       response.Data,
+		Counts:     response.Counts,
+		Pagination: response.Pagination,
+	})
+}
+
+// retryEmailRequestBody - represents retryEmail request body.
+type retryEmailRequestBody struct {
+	EmailID int `json:"emailId" binding:"required"`
+}
+
+// outboxEmailResponse - represents outbox email response.
+type outboxEmailResponse struct {
+	Email *domain.OutboxEmail `json:"email,omitempty"`
+	Error *service.Error      `json:"error,omitempty"`
+}
+
+func (r *adminRoutes) retryEmail(c *gin.Context) {
+	logger := r.logger.Named("retryEmail")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body retryEmailRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("emailId", body.EmailID)
+
+	email, err := r.service.RetryOutboxEmail(c.Request.Context(), body.EmailID)
+	if err != nil {
+		logger.Error("failed to retry email", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, outboxEmailResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to retry email")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully queued email #%d again", email.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully retried email")
+	c.JSON(http.StatusOK, outboxEmailResponse{Email: email})
+}
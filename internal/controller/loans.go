@@ -0,0 +1,444 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// loanRoutes - represents loan service router.
+type loanRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newLoanRoutes - implements new loan service routes.
+func newLoanRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &loanRoutes{s, repo, l}
+	h := handler.Group("/loan")
+	{
+		// routes
+		h.GET("/products", newAuthMiddleware(s, l), r.searchLoanProducts)
+		h.POST("/products", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionLoansManage), r.createLoanProduct)
+		h.GET("/search", newAuthMiddleware(s, l), r.searchLoans)
+		h.POST("/apply", newAuthMiddleware(s, l), r.applyLoan)
+		h.PATCH("/approve", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionLoansManage), r.approveLoan)
+		h.PATCH("/reject", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionLoansManage), r.rejectLoan)
+		h.GET("/schedule", newAuthMiddleware(s, l), r.loanSchedule)
+		h.PATCH("/prepay", newAuthMiddleware(s, l), r.prepayLoan)
+	}
+}
+
+// searchLoanProductsResponse - represents search loan products response.
+type searchLoanProductsResponse struct {
+	Data       []domain.LoanProduct `json:"data"`
+	Pagination *domain.Pagination   `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *loanRoutes) searchLoanProducts(c *gin.Context) {
+	logger := r.logger.Named("searchLoanProducts")
+
+	filter, err := getFilterFromQuery(c.Request, domain.LoanProductSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := r.service.Loans.SearchLoanProducts(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("failed to search loan products", "err", err)
+		// get service error
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchLoanProductsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search loan products")
+		return
+	}
+
+	logger.Info("successfully search loan products")
+	c.JSON(http.StatusOK, searchLoanProductsResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// createLoanProductRequestBody - represents createLoanProduct request body.
+type createLoanProductRequestBody struct {
+	Name          string  `json:"name" binding:"required"`
+	Rate          float64 `json:"rate"`
+	TermMonths    int     `json:"termMonths" binding:"required"`
+	RepaymentType string  `json:"repaymentType"`
+	PenaltyFee    float64 `json:"penaltyFee"`
+	MinAmount     float64 `json:"minAmount"`
+	MaxAmount     float64 `json:"maxAmount"`
+}
+
+// createLoanProductResponse - represents createLoanProduct response.
+type createLoanProductResponse struct {
+	Product *domain.LoanProduct `json:"product,omitempty"`
+	Error   *service.Error      `json:"error,omitempty"`
+}
+
+func (r *loanRoutes) createLoanProduct(c *gin.Context) {
+	logger := r.logger.Named("createLoanProduct")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createLoanProductRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	product, err := r.service.Loans.CreateLoanProduct(c.Request.Context(),
+		&service.LoanProductInput{
+			Name:          body.Name,
+			Rate:          body.Rate,
+			TermMonths:    body.TermMonths,
+			RepaymentType: body.RepaymentType,
+			PenaltyFee:    body.PenaltyFee,
+			MinAmount:     body.MinAmount,
+			MaxAmount:     body.MaxAmount,
+		})
+	if err != nil {
+		logger.Error("failed to create loan product", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, createLoanProductResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create loan product")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created loan product %s", product.Name),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created loan product")
+	c.JSON(http.StatusOK, createLoanProductResponse{Product: product})
+}
+
+// searchLoansResponse - represents search loans response.
+type searchLoansResponse struct {
+	Data       []domain.Loan      `json:"data"`
+	Pagination *domain.Pagination `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *loanRoutes) searchLoans(c *gin.Context) {
+	logger := r.logger.Named("searchLoans")
+
+	filter, err := getFilterFromQuery(c.Request, domain.LoanSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	response, err := r.service.Loans.SearchLoans(c.Request.Context(), filter, client.ID, getPermissions(c))
+	if err != nil {
+		logger.Error("failed to search loans", "err", err)
+		// get service error
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchLoansResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search loans")
+		return
+	}
+
+	logger.Info("successfully search loans")
+	c.JSON(http.StatusOK, searchLoansResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// applyLoanRequestBody - represents applyLoan request body.
+type applyLoanRequestBody struct {
+	ProductID  int     `json:"productId" binding:"required"`
+	CardNumber int64   `json:"cardNumber" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required"`
+}
+
+// loanResponse - represents loan response.
+type loanResponse struct {
+	Loan  *domain.Loan   `json:"loan,omitempty"`
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *loanRoutes) applyLoan(c *gin.Context) {
+	logger := r.logger.Named("applyLoan")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body applyLoanRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// apply for loan
+	logger.Debug("applying for loan")
+	loan, err := r.service.ApplyLoan(c.Request.Context(), client.ID,
+		&service.ApplyLoanInput{
+			ProductID:  body.ProductID,
+			CardNumber: body.CardNumber,
+			Amount:     body.Amount,
+		})
+	if err != nil {
+		logger.Error("failed to apply for loan", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, loanResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to apply for loan")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully applied for loan #%d on amount %0.2f", loan.ID, loan.Principal),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully applied for loan")
+	c.JSON(http.StatusOK, loanResponse{Loan: loan})
+}
+
+// changeLoanRequestBody - represents approveLoan and rejectLoan request body.
+type changeLoanRequestBody struct {
+	LoanID int `json:"loanId" binding:"required"`
+}
+
+func (r *loanRoutes) approveLoan(c *gin.Context) {
+	logger := r.logger.Named("approveLoan")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body changeLoanRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	loan, err := r.service.ApproveLoan(c.Request.Context(), body.LoanID)
+	if err != nil {
+		logger.Error("failed to approve loan", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, loanResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to approve loan")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully approved and disbursed loan #%d", loan.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully approved loan")
+	c.JSON(http.StatusOK, loanResponse{Loan: loan})
+}
+
+func (r *loanRoutes) rejectLoan(c *gin.Context) {
+	logger := r.logger.Named("rejectLoan")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body changeLoanRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	loan, err := r.service.RejectLoan(c.Request.Context(), body.LoanID)
+	if err != nil {
+		logger.Error("failed to reject loan", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, loanResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to reject loan")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully rejected loan #%d", loan.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully rejected loan")
+	c.JSON(http.StatusOK, loanResponse{Loan: loan})
+}
+
+// loanScheduleResponse - represents loan schedule response.
+type loanScheduleResponse struct {
+	Data  []domain.LoanInstallment `json:"data"`
+	Error *service.Error           `json:"error,omitempty"`
+}
+
+func (r *loanRoutes) loanSchedule(c *gin.Context) {
+	logger := r.logger.Named("loanSchedule")
+
+	loanId, err := strconv.Atoi(c.Query("loanId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	schedule, err := r.service.GetLoanSchedule(c.Request.Context(), client.ID, getPermissions(c), loanId)
+	if err != nil {
+		logger.Error("failed to get loan schedule", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, loanScheduleResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to get loan schedule")
+		return
+	}
+
+	logger.Info("successfully got loan schedule")
+	c.JSON(http.StatusOK, loanScheduleResponse{Data: schedule})
+}
+
+// prepayLoanRequestBody - represents prepayLoan request body.
+type prepayLoanRequestBody struct {
+	LoanID      int     `json:"loanId" binding:"required"`
+	SecretValue string  `json:"secretValue" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required"`
+}
+
+func (r *loanRoutes) prepayLoan(c *gin.Context) {
+	logger := r.logger.Named("prepayLoan")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body prepayLoanRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("loanId", body.LoanID, "amount", body.Amount)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	// prepay loan
+	logger.Debug("prepaying loan")
+	loan, err := r.service.PrepayLoan(c.Request.Context(), client.ID,
+		&service.PrepayLoanInput{
+			LoanID:      body.LoanID,
+			SecretValue: body.SecretValue,
+			Amount:      body.Amount,
+		})
+	if err != nil {
+		logger.Error("failed to prepay loan", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, loanResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to prepay loan")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully prepaid loan #%d on amount %0.2f", loan.ID, body.Amount),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully prepaid loan")
+	c.JSON(http.StatusOK, loanResponse{Loan: loan})
+}
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// merchantRoutes - represents merchant service router.
+type merchantRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newMerchantRoutes - implements new merchant service routes.
+func newMerchantRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &merchantRoutes{s, repo, l}
+	h := handler.Group("/merchant")
+	{
+		// routes
+		h.POST("/create", newAuthMiddleware(s, l), r.createMerchant)
+		h.GET("/search", newAuthMiddleware(s, l), r.searchMerchants)
+		h.POST("/api_keys", newAuthMiddleware(s, l), r.createAPIKey)
+		h.GET("/api_keys", newAuthMiddleware(s, l), r.searchAPIKeys)
+		h.PATCH("/api_keys/rotate", newAuthMiddleware(s, l), r.rotateAPIKey)
+		h.PATCH("/api_keys/revoke", newAuthMiddleware(s, l), r.revokeAPIKey)
+	}
+}
+
+// createMerchantRequestBody - represents createMerchant request body.
+type createMerchantRequestBody struct {
+	Name        string `json:"name" binding:"required"`
+	CardNumber  int64  `json:"cardNumber" binding:"required"`
+	SecretValue string `json:"secretValue" binding:"required"`
+	Category    string `json:"category"`
+}
+
+// merchantResponse - represents merchant response.
+type merchantResponse struct {
+	Merchant *domain.Merchant `json:"merchant,omitempty"`
+	Error    *service.Error   `json:"error,omitempty"`
+}
+
+func (r *merchantRoutes) createMerchant(c *gin.Context) {
+	logger := r.logger.Named("createMerchant")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createMerchantRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("name", body.Name, "cardNumber", body.CardNumber)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	merchant, err := r.service.CreateMerchant(c.Request.Context(), client.ID,
+		&service.MerchantInput{
+			Name:        body.Name,
+			CardNumber:  body.CardNumber,
+			SecretValue: body.SecretValue,
+			Category:    body.Category,
+		})
+	if err != nil {
+		logger.Error("failed to create merchant", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, merchantResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create merchant")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created merchant #%d %s", merchant.ID, merchant.Name),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created merchant")
+	c.JSON(http.StatusOK, merchantResponse{Merchant: merchant})
+}
+
+// searchMerchantsResponse - represents search merchants response.
+type searchMerchantsResponse struct {
+	Data  []domain.Merchant `json:"data"`
+	Error *service.Error    `json:"error,omitempty"`
+}
+
+func (r *merchantRoutes) searchMerchants(c *gin.Context) {
+	logger := r.logger.Named("searchMerchants")
+
+	merchants, err := r.service.SearchMerchants(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to search merchants", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchMerchantsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search merchants")
+		return
+	}
+
+	logger.Info("successfully search merchants")
+	c.JSON(http.StatusOK, searchMerchantsResponse{Data: merchants})
+}
+
+// createAPIKeyRequestBody - represents createAPIKey request body.
+type createAPIKeyRequestBody struct {
+	MerchantID int      `json:"merchantId" binding:"required"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes" binding:"required"`
+}
+
+// apiKeyResponse - represents api key response, plain key is set only when key is issued.
+type apiKeyResponse struct {
+	Key    string                 `json:"key,omitempty"`
+	APIKey *domain.MerchantAPIKey `json:"apiKey,omitempty"`
+	Error  *service.Error         `json:"error,omitempty"`
+}
+
+func (r *merchantRoutes) createAPIKey(c *gin.Context) {
+	logger := r.logger.Named("createAPIKey")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createAPIKeyRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("merchantId", body.MerchantID, "scopes", body.Scopes)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	output, err := r.service.CreateAPIKey(c.Request.Context(), client.ID,
+		&service.APIKeyInput{
+			MerchantID: body.MerchantID,
+			Name:       body.Name,
+			Scopes:     body.Scopes,
+		})
+	if err != nil {
+		logger.Error("failed to create api key", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, apiKeyResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create api key")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created api key %s for merchant #%d", output.APIKey.Prefix, body.MerchantID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created api key")
+	c.JSON(http.StatusOK, apiKeyResponse{Key: output.Key, APIKey: output.APIKey})
+}
+
+// searchAPIKeysResponse - represents search api keys response.
+type searchAPIKeysResponse struct {
+	Data  []domain.MerchantAPIKey `json:"data"`
+	Error *service.Error          `json:"error,omitempty"`
+}
+
+func (r *merchantRoutes) searchAPIKeys(c *gin.Context) {
+	logger := r.logger.Named("searchAPIKeys")
+
+	merchantId, err := strconv.Atoi(c.Query("merchantId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+
+	keys, err := r.service.SearchAPIKeys(c.Request.Context(), c.GetInt("clientID"), merchantId)
+	if err != nil {
+		logger.Error("failed to search api keys", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchAPIKeysResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search api keys")
+		return
+	}
+
+	logger.Info("successfully search api keys")
+	c.JSON(http.StatusOK, searchAPIKeysResponse{Data: keys})
+}
+
+// changeAPIKeyRequestBody - represents rotateAPIKey and revokeAPIKey request body.
+type changeAPIKeyRequestBody struct {
+	KeyID int `json:"keyId" binding:"required"`
+}
+
+func (r *merchantRoutes) rotateAPIKey(c *gin.Context) {
+	logger := r.logger.Named("rotateAPIKey")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body changeAPIKeyRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("keyId", body.KeyID)
+
+	output, err := r.service.RotateAPIKey(c.Request.Context(), c.GetInt("clientID"), body.KeyID)
+	if err != nil {
+		logger.Error("failed to rotate api key", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, apiKeyResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to rotate api key")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully rotated api key #%d to %s", body.KeyID, output.APIKey.Prefix),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully rotated api key")
+	c.JSON(http.StatusOK, apiKeyResponse{Key: output.Key, APIKey: output.APIKey})
+}
+
+func (r *merchantRoutes) revokeAPIKey(c *gin.Context) {
+	logger := r.logger.Named("revokeAPIKey")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body changeAPIKeyRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("keyId", body.KeyID)
+
+	err = r.service.RevokeAPIKey(c.Request.Context(), c.GetInt("clientID"), body.KeyID)
+	if err != nil {
+		logger.Error("failed to revoke api key", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, apiKeyResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to revoke api key")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully revoked api key #%d", body.KeyID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully revoked api key")
+	c.JSON(http.StatusOK, apiKeyResponse{})
+}
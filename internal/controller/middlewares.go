@@ -11,6 +11,7 @@ import (
 	"github.com/Shevchenkko/payment_system/pkg/logger"
 
 	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
 	"github.com/Shevchenkko/payment_system/internal/service"
 )
 
@@ -27,40 +28,111 @@ func corsMiddleware(c *gin.Context) {
 	}
 }
 
-// newAuthMiddleware is used to get auth token from request headers and validate it.
-func newAuthMiddleware(services service.Services, l logger.Interface) gin.HandlerFunc {
+// newAuthMiddleware is used to get auth token or merchant api key from request headers and validate it.
+// Api key ("Authorization: ApiKey <key>" or "X-API-Key: <key>") is accepted only by routes
+// that list scopes, and the key must be granted all of them.
+func newAuthMiddleware(services service.Services, l logger.Interface, scopes ...string) gin.HandlerFunc {
 	logger := l.Named("authMiddleware")
 
 	return func(c *gin.Context) {
+		// get api key
+		apiKey := c.GetHeader("X-API-Key")
+
 		// get token and check if empty ("Bearer token")
 		tokenStringRaw := c.GetHeader("Authorization")
-		if tokenStringRaw == "" {
+		if tokenStringRaw == "" && apiKey == "" {
 			logger.Debug("empty Authorization header", "tokenStringRaw", tokenStringRaw)
 			errorResponse(c, http.StatusUnauthorized, "empty auth token")
 			return
 		}
 
-		// split Bearer and token
-		tokenStringArr := strings.Split(tokenStringRaw, " ")
-		if len(tokenStringArr) != 2 {
-			logger.Debug("malformed auth token", "tokenStringArr", tokenStringArr)
-			errorResponse(c, http.StatusUnauthorized, "malformed auth token")
-			return
+		if apiKey == "" {
+			// split Bearer and token
+			tokenStringArr := strings.Split(tokenStringRaw, " ")
+			if len(tokenStringArr) != 2 {
+				logger.Debug("malformed auth token", "tokenStringArr", tokenStringArr)
+				errorResponse(c, http.StatusUnauthorized, "malformed auth token")
+				return
+			}
+
+			if tokenStringArr[0] == "ApiKey" {
+				apiKey = tokenStringArr[1]
+			} else {
+				// get token
+				tokenString := tokenStringArr[1]
+				token, valid := services.Users.VerifyAccessToken(c.Request.Context(), tokenString)
+				if !valid {
+					logger.Debug("invalid auth token", "tokenStringArr", tokenStringArr)
+					errorResponse(c, http.StatusUnauthorized, "invalid auth token")
+					return
+				}
+
+				// get permissions of user roles
+				permissions, err := services.Roles.GetUserPermissions(c.Request.Context(), token.UserID)
+				if err != nil {
+					logger.Error("failed to get user permissions", "err", err)
+					errorResponse(c, http.StatusInternalServerError, "failed to get user permissions")
+					return
+				}
+
+				// set user id to context
+				c.Set("clientID", token.UserID)
+
+				// set user permissions to context
+				c.Set("permissions", permissions)
+
+				// set session id to context
+				c.Set("sessionID", token.SessionID)
+				return
+			}
 		}
 
-		// get token
-		tokenString := tokenStringArr[1]
-		valid, client, userRole := services.Users.VerifyAccessToken(c.Request.Context(), tokenString)
+		// verify api key
+		key, valid := services.Merchants.VerifyAPIKey(c.Request.Context(), apiKey)
 		if !valid {
-			logger.Debug("invalid auth token", "tokenStringArr", tokenStringArr)
-			errorResponse(c, http.StatusUnauthorized, "invalid auth token")
+			logger.Debug("invalid api key")
+			errorResponse(c, http.StatusUnauthorized, "invalid api key")
 			return
 		}
+		if len(scopes) == 0 {
+			logger.Debug("api key used for user only route", "keyId", key.ID)
+			errorResponse(c, http.StatusForbidden, "route is not available with api key")
+			return
+		}
+		for _, scope := range scopes {
+			if !key.HasScope(scope) {
+				logger.Debug("api key scope missing", "keyId", key.ID, "scope", scope)
+				errorResponse(c, http.StatusForbidden, "api key has no scope "+scope)
+				return
+			}
+		}
 
-		// set user id to context
-		c.Set("clientID", client)
+		// merchant owner acts as user without staff permissions
+		c.Set("clientID", key.Merchant.UserID)
+		c.Set("permissions", domain.Permissions{})
 
-		// set user role to context
-		c.Set("userRole", userRole)
+		// set merchant to request context, services restrict request to its settlement account
+		c.Request = c.Request.WithContext(service.WithMerchant(c.Request.Context(), key.Merchant))
+		c.Set("apiKeyID", key.ID)
 	}
 }
+
+// requirePermission is used to allow route only for users whose roles grant permission.
+// Must follow auth middleware, which sets user permissions to context.
+func requirePermission(l logger.Interface, permission string) gin.HandlerFunc {
+	logger := l.Named("permissionMiddleware")
+
+	return func(c *gin.Context) {
+		if !getPermissions(c).Has(permission) {
+			logger.Debug("permission missing", "clientID", c.GetInt("clientID"), "permission", permission)
+			errorResponse(c, http.StatusForbidden, "you need "+permission+" permission")
+			return
+		}
+	}
+}
+
+// getPermissions - returns permissions of authenticated user set to context by auth middleware.
+func getPermissions(c *gin.Context) domain.Permissions {
+	permissions, _ := c.Value("permissions").(domain.Permissions)
+	return permissions
+}
@@ -0,0 +1,381 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// notificationRoutes - represents notifications service router.
+type notificationRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newNotificationRoutes - implements new notifications service routes.
+func newNotificationRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &notificationRoutes{s, repo, l}
+	h := handler.Group("/notification")
+	{
+		// routes
+		h.GET("/search", newAuthMiddleware(s, l), r.searchNotifications)
+		h.PATCH("/read", newAuthMiddleware(s, l), r.markNotificationsRead)
+		h.GET("/preferences", newAuthMiddleware(s, l), r.getNotificationPreferences)
+		h.PATCH("/preferences", newAuthMiddleware(s, l), r.updateNotificationPreferences)
+		h.POST("/devices", newAuthMiddleware(s, l), r.registerDevice)
+		h.GET("/devices", newAuthMiddleware(s, l), r.searchDevices)
+		h.DELETE("/devices", newAuthMiddleware(s, l), r.deleteDevice)
+		h.GET("/deliveries", newAuthMiddleware(s, l), r.searchNotificationDeliveries)
+	}
+}
+
+// searchNotificationsRequestQuery - represents search notifications request query.
+type searchNotificationsRequestQuery struct {
+	Unread bool `form:"unread"`
+}
+
+// searchNotificationsResponse - represents search notifications response.
+type searchNotificationsResponse struct {
+	Data       []domain.Notification `json:"data"`
+	Unread     int64                 `json:"unread"`
+	Pagination *domain.Pagination    `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *notificationRoutes) searchNotifications(c *gin.Context) {
+	logger := r.logger.Named("searchNotifications")
+
+	filter, err := getFilterFromQuery(c.Request, domain.NotificationSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// parse request query
+	var query searchNotificationsRequestQuery
+	logger.Info("parsing request query")
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logger.Error("failed to parse request query", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+		return
+	}
+
+	response, err := r.service.SearchNotifications(c.Request.Context(), filter, c.GetInt("clientID"), query.Unread)
+	if err != nil {
+		logger.Error("failed to search notifications", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchNotificationsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search notifications")
+		return
+	}
+
+	logger.Info("successfully searched notifications")
+	c.JSON(http.StatusOK, searchNotificationsResponse{
+		Data:       response.Data,
+		Unread:     response.Unread,
+		Pagination: response.Pagination,
+	})
+}
+
+// markNotificationsReadRequestBody - represents markNotificationsRead request body.
+// Empty ids mark every notification read.
+type markNotificationsReadRequestBody struct {
+	IDs []int `json:"ids"`
+}
+
+// markNotificationsReadResponse - represents markNotificationsRead response.
+type markNotificationsReadResponse struct {
+	Marked int64          `json:"marked"`
+	Error  *service.Error `json:"error,omitempty"`
+}
+
+func (r *notificationRoutes) markNotificationsRead(c *gin.Context) {
+	logger := r.logger.Named("markNotificationsRead")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body markNotificationsReadRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	marked, err := r.service.MarkNotificationsRead(c.Request.Context(), c.GetInt("clientID"), body.IDs)
+	if err != nil {
+		logger.Error("failed to mark notifications read", "err", err)
+		errorResponse(c, http.StatusInternalServerError, "failed to mark notifications read")
+		return
+	}
+
+	logger.Info("successfully marked notifications read")
+	c.JSON(http.StatusOK, markNotificationsReadResponse{Marked: marked})
+}
+
+// notificationPreferencesResponse - represents notification preferences response.
+type notificationPreferencesResponse struct {
+	Data  []domain.NotificationPreference `json:"data"`
+	Error *service.Error                  `json:"error,omitempty"`
+}
+
+func (r *notificationRoutes) getNotificationPreferences(c *gin.Context) {
+	logger := r.logger.Named("getNotificationPreferences")
+
+	preferences, err := r.service.GetNotificationPreferences(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to get notification preferences", "err", err)
+		errorResponse(c, http.StatusInternalServerError, "failed to get notification preferences")
+		return
+	}
+
+	logger.Info("successfully got notification preferences")
+	c.JSON(http.StatusOK, notificationPreferencesResponse{Data: preferences})
+}
+
+// notificationPreferenceRequestBody - represents channels of event in updateNotificationPreferences request body.
+// Omitted sms and push keep their current value.
+type notificationPreferenceRequestBody struct {
+	Event string `json:"event" binding:"required"`
+	Email *bool  `json:"email" binding:"required"`
+	Inbox *bool  `json:"inbox" binding:"required"`
+	SMS   *bool  `json:"sms"`
+	Push  *bool  `json:"push"`
+}
+
+// updateNotificationPreferencesRequestBody - represents updateNotificationPreferences request body.
+type updateNotificationPreferencesRequestBody struct {
+	Preferences []notificationPreferenceRequestBody `json:"preferences" binding:"required,dive"`
+}
+
+func (r *notificationRoutes) updateNotificationPreferences(c *gin.Context) {
+	logger := r.logger.Named("updateNotificationPreferences")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body updateNotificationPreferencesRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	inp := make([]service.NotificationPreferenceInput, 0, len(body.Preferences))
+	for _, p := range body.Preferences {
+		inp = append(inp, service.NotificationPreferenceInput{
+			Event: p.Event,
+			Email: *p.Email,
+			Inbox: *p.Inbox,
+			SMS:   p.SMS,
+			Push:  p.Push,
+		})
+	}
+
+	preferences, err := r.service.UpdateNotificationPreferences(c.Request.Context(), client.ID, inp)
+	if err != nil {
+		logger.Error("failed to update notification preferences", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, notificationPreferencesResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to update notification preferences")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully updated %d notification preferences", len(inp)),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully updated notification preferences")
+	c.JSON(http.StatusOK, notificationPreferencesResponse{Data: preferences})
+}
+
+// registerDeviceRequestBody - represents registerDevice request body.
+type registerDeviceRequestBody struct {
+	Token    string `json:"token" binding:"required"`
+	Platform string `json:"platform" binding:"required"`
+}
+
+// deviceResponse - represents device response.
+type deviceResponse struct {
+	Device *domain.DeviceToken `json:"device,omitempty"`
+	Error  *service.Error      `json:"error,omitempty"`
+}
+
+func (r *notificationRoutes) registerDevice(c *gin.Context) {
+	logger := r.logger.Named("registerDevice")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body registerDeviceRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("platform", body.Platform)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	device, err := r.service.RegisterDevice(c.Request.Context(), client.ID,
+		&service.DeviceInput{
+			Token:    body.Token,
+			Platform: body.Platform,
+		})
+	if err != nil {
+		logger.Error("failed to register device", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, deviceResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to register device")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully registered %s device #%d", device.Platform, device.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully registered device")
+	c.JSON(http.StatusOK, deviceResponse{Device: device})
+}
+
+// searchDevicesResponse - represents search devices response.
+type searchDevicesResponse struct {
+	Data  []domain.DeviceToken `json:"data"`
+	Error *service.Error       `json:"error,omitempty"`
+}
+
+func (r *notificationRoutes) searchDevices(c *gin.Context) {
+	logger := r.logger.Named("searchDevices")
+
+	devices, err := r.service.SearchDevices(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to search devices", "err", err)
+		errorResponse(c, http.StatusInternalServerError, "failed to search devices")
+		return
+	}
+
+	logger.Info("successfully searched devices")
+	c.JSON(http.StatusOK, searchDevicesResponse{Data: devices})
+}
+
+func (r *notificationRoutes) deleteDevice(c *gin.Context) {
+	logger := r.logger.Named("deleteDevice")
+
+	deviceId, err := strconv.Atoi(c.Query("deviceId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+	logger = logger.With("deviceId", deviceId)
+
+	err = r.service.DeleteDevice(c.Request.Context(), c.GetInt("clientID"), deviceId)
+	if err != nil {
+		logger.Error("failed to delete device", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, deviceResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to delete device")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully deleted device #%d", deviceId),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully deleted device")
+	c.JSON(http.StatusOK, deviceResponse{})
+}
+
+// searchNotificationDeliveriesResponse - represents search notification deliveries response.
+type searchNotificationDeliveriesResponse struct {
+	Data       []domain.NotificationDelivery `json:"data"`
+	Pagination *domain.Pagination            `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *notificationRoutes) searchNotificationDeliveries(c *gin.Context) {
+	logger := r.logger.Named("searchNotificationDeliveries")
+
+	filter, err := getFilterFromQuery(c.Request, domain.NotificationDeliverySortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := r.service.SearchNotificationDeliveries(c.Request.Context(), filter, c.GetInt("clientID"), c.Query("channel"))
+	if err != nil {
+		logger.Error("failed to search notification deliveries", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchNotificationDeliveriesResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search notification deliveries")
+		return
+	}
+
+	logger.Info("successfully searched notification deliveries")
+	c.JSON(http.StatusOK, searchNotificationDeliveriesResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
@@ -0,0 +1,293 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// paymentLinkRoutes - represents payment link service router.
+type paymentLinkRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newPaymentLinkRoutes - implements new payment link service routes.
+func newPaymentLinkRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &paymentLinkRoutes{s, repo, l}
+	h := handler.Group("/payment_link")
+	{
+		// routes
+		h.POST("/create", newAuthMiddleware(s, l, "payments:write"), r.createPaymentLink)
+		h.GET("/search", newAuthMiddleware(s, l, "payments:read"), r.searchPaymentLinks)
+		h.PATCH("/disable", newAuthMiddleware(s, l, "payments:write"), r.disablePaymentLink)
+		h.GET("/open", r.openPaymentLink)
+		h.POST("/pay", newAuthMiddleware(s, l), r.payPaymentLink)
+	}
+}
+
+// createPaymentLinkRequestBody - represents createPaymentLink request body.
+type createPaymentLinkRequestBody struct {
+	MerchantID  int        `json:"merchantId" binding:"required"`
+	Amount      float64    `json:"amount" binding:"required"`
+	Description string     `json:"description" binding:"required"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	MultiUse    bool       `json:"multiUse"`
+}
+
+// paymentLinkResponse - represents payment link response.
+type paymentLinkResponse struct {
+	PaymentLink *domain.PaymentLink `json:"paymentLink,omitempty"`
+	Error       *service.Error      `json:"error,omitempty"`
+}
+
+func (r *paymentLinkRoutes) createPaymentLink(c *gin.Context) {
+	logger := r.logger.Named("createPaymentLink")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createPaymentLinkRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	link, err := r.service.CreatePaymentLink(c.Request.Context(), client.ID,
+		&service.PaymentLinkInput{
+			MerchantID:  body.MerchantID,
+			Amount:      body.Amount,
+			Description: body.Description,
+			ExpiresAt:   body.ExpiresAt,
+			MultiUse:    body.MultiUse,
+		})
+	if err != nil {
+		logger.Error("failed to create payment link", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, paymentLinkResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create payment link")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created payment link #%d for merchant #%d", link.ID, link.MerchantID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created payment link")
+	c.JSON(http.StatusOK, paymentLinkResponse{PaymentLink: link})
+}
+
+// searchPaymentLinksResponse - represents search payment links response.
+type searchPaymentLinksResponse struct {
+	Data       []domain.PaymentLink `json:"data"`
+	Pagination *domain.Pagination   `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *paymentLinkRoutes) searchPaymentLinks(c *gin.Context) {
+	logger := r.logger.Named("searchPaymentLinks")
+
+	filter, err := getFilterFromQuery(c.Request, domain.PaymentLinkSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	merchantId, err := strconv.Atoi(c.Query("merchantId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+
+	response, err := r.service.SearchPaymentLinks(c.Request.Context(), filter, c.GetInt("clientID"), merchantId)
+	if err != nil {
+		logger.Error("failed to search payment links", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchPaymentLinksResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search payment links")
+		return
+	}
+
+	logger.Info("successfully search payment links")
+	c.JSON(http.StatusOK, searchPaymentLinksResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// disablePaymentLinkRequestBody - represents disablePaymentLink request body.
+type disablePaymentLinkRequestBody struct {
+	LinkID int `json:"linkId" binding:"required"`
+}
+
+func (r *paymentLinkRoutes) disablePaymentLink(c *gin.Context) {
+	logger := r.logger.Named("disablePaymentLink")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body disablePaymentLinkRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("linkId", body.LinkID)
+
+	err = r.service.DisablePaymentLink(c.Request.Context(), c.GetInt("clientID"), body.LinkID)
+	if err != nil {
+		logger.Error("failed to disable payment link", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, paymentLinkResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to disable payment link")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully disabled payment link #%d", body.LinkID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully disabled payment link")
+	c.JSON(http.StatusOK, paymentLinkResponse{})
+}
+
+// openPaymentLinkResponse - represents openPaymentLink response.
+type openPaymentLinkResponse struct {
+	PaymentLink *service.PaymentLinkOutput `json:"paymentLink,omitempty"`
+	Error       *service.Error             `json:"error,omitempty"`
+}
+
+func (r *paymentLinkRoutes) openPaymentLink(c *gin.Context) {
+	logger := r.logger.Named("openPaymentLink")
+
+	code := c.Query("code")
+	if code == "" {
+		logger.Error("empty payment link code")
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+
+	link, err := r.service.GetPaymentLink(c.Request.Context(), code)
+	if err != nil {
+		logger.Error("failed to open payment link", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, openPaymentLinkResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to open payment link")
+		return
+	}
+
+	logger.Info("successfully opened payment link")
+	c.JSON(http.StatusOK, openPaymentLinkResponse{PaymentLink: link})
+}
+
+// payPaymentLinkRequestBody - represents payPaymentLink request body.
+type payPaymentLinkRequestBody struct {
+	Code        string `json:"code" binding:"required"`
+	CardNumber  int64  `json:"cardNumber" binding:"required"`
+	SecretValue string `json:"secretValue" binding:"required"`
+}
+
+// payPaymentLinkResponse - represents payPaymentLink response.
+type payPaymentLinkResponse struct {
+	Payment *service.PaymentOutput `json:"payment,omitempty"`
+	Error   *service.Error         `json:"error,omitempty"`
+}
+
+func (r *paymentLinkRoutes) payPaymentLink(c *gin.Context) {
+	logger := r.logger.Named("payPaymentLink")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body payPaymentLinkRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("code", body.Code, "cardNumber", body.CardNumber)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	payment, err := r.service.PayPaymentLink(c.Request.Context(), client.ID,
+		&service.PayPaymentLinkInput{
+			Code:        body.Code,
+			CardNumber:  body.CardNumber,
+			SecretValue: body.SecretValue,
+		})
+	if err != nil {
+		logger.Error("failed to pay payment link", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, payPaymentLinkResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to pay payment link")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully paid %.2f to %s by payment link", payment.OperationAmount, payment.ToClient),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully paid payment link")
+	c.JSON(http.StatusOK, payPaymentLinkResponse{Payment: payment})
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	// third party
 	"github.com/gin-gonic/gin"
@@ -28,15 +29,27 @@ func newPaymentRoutes(handler *gin.RouterGroup, s service.Services, l logger.Int
 	h := handler.Group("/payment")
 	{
 		// routes
-		h.GET("/search", newAuthMiddleware(s, l), r.searchPayment)
-		h.POST("/create", newAuthMiddleware(s, l), r.createPayment)
-		h.PATCH("/sent", newAuthMiddleware(s, l), r.sentPayment)
+		h.GET("/search", newAuthMiddleware(s, l, "payments:read"), r.searchPayment)
+		h.POST("/create", newAuthMiddleware(s, l, "payments:write"), r.createPayment)
+		h.PATCH("/sent", newAuthMiddleware(s, l, "payments:write"), r.sentPayment)
+		h.GET("/qr", newAuthMiddleware(s, l, "payments:read"), r.generatePaymentQR)
+		h.POST("/qr/parse", newAuthMiddleware(s, l, "payments:read"), r.parsePaymentQR)
 	}
 }
 
 // searchPaymentRequestQuery - represents search payments request query.
+// Dates are YYYY-MM-DD, both ends are inclusive.
 type searchPaymentRequestQuery struct {
-	Filter domain.Filter `form:"filter"`
+	Direction    string   `form:"direction"`
+	From         string   `form:"from"`
+	To           string   `form:"to"`
+	MinAmount    *float64 `form:"minAmount"`
+	MaxAmount    *float64 `form:"maxAmount"`
+	Status       string   `form:"status"`
+	Counterparty string   `form:"counterparty"`
+	Account      string   `form:"account"`
+	Description  string   `form:"description"`
+	Category     string   `form:"category"`
 }
 
 // searchPaymentResponse - represents search payments response.
@@ -50,10 +63,10 @@ type searchPaymentResponse struct {
 func (r *paymentRoutes) searchPayment(c *gin.Context) {
 	logger := r.logger.Named("searchPayment")
 
-	filter, err := getFilterFromQuery(c.Request)
+	filter, err := getFilterFromQuery(c.Request, domain.PaymentSortFields)
 	if err != nil {
 		logger.Error("failed to parse query params", "err", err)
-		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -65,6 +78,35 @@ func (r *paymentRoutes) searchPayment(c *gin.Context) {
 		errorResponse(c, http.StatusBadRequest, "failed to parse request query")
 		return
 	}
+	inp := &service.PaymentSearchInput{
+		Direction:    query.Direction,
+		MinAmount:    query.MinAmount,
+		MaxAmount:    query.MaxAmount,
+		Status:       query.Status,
+		Counterparty: query.Counterparty,
+		AccountIBAN:  query.Account,
+		Description:  query.Description,
+		Category:     query.Category,
+	}
+	if query.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", query.From, time.Local)
+		if err != nil {
+			logger.Error("failed to parse request query", "err", err)
+			errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+			return
+		}
+		inp.From = &from
+	}
+	if query.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", query.To, time.Local)
+		if err != nil {
+			logger.Error("failed to parse request query", "err", err)
+			errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		inp.To = &to
+	}
 
 	// get client
 	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
@@ -76,7 +118,7 @@ func (r *paymentRoutes) searchPayment(c *gin.Context) {
 		return
 	}
 
-	response, err := r.service.Payments.SearchPayments(c.Request.Context(), filter, client.FullName)
+	response, err := r.service.Payments.SearchPayments(c.Request.Context(), filter, client.ID, inp)
 	if err != nil {
 		logger.Error("failed to search payments", "err", err)
 		// get service error
@@ -111,6 +153,7 @@ type createPaymentRequestBody struct {
 type createPaymentResponse struct {
 	PaymentID            int64          `json:"paymentId"`
 	PaymentStatus        string         `json:"paymentStatus"`
+	FromClientID         int            `json:"fromClientId"`
 	FromClient           string         `json:"fromClient"`
 	FromClientITN        int64          `json:"fromClientItn"`
 	FromClientIBAN       string         `json:"fromClientIban"`
@@ -189,6 +232,7 @@ func (r *paymentRoutes) createPayment(c *gin.Context) {
 	c.JSON(http.StatusOK, createPaymentResponse{
 		PaymentID:            data.ID,
 		PaymentStatus:        data.PaymentStatus,
+		FromClientID:         data.FromClientID,
 		FromClient:           data.FromClient,
 		FromClientITN:        data.FromClientITN,
 		FromClientIBAN:       data.FromClientIBAN,
@@ -253,18 +297,19 @@ func (r *paymentRoutes) sentPayment(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, "failed to sent payment, please unlock your bank account")
 		return
 	}
+	if bank.Status == "FROZEN" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, "failed to sent payment, bank account is frozen")
+		return
+	}
 
-	var cardBalance float64
-	if bank.Balance-payment.OperationAmount > 0 {
-		cardBalance = bank.Balance - payment.OperationAmount
-	} else {
+	if bank.Balance < payment.OperationAmount {
 		c.AbortWithStatusJSON(http.StatusBadRequest, "failed to sent payment, please top up your balance")
 		return
 	}
 
 	// sent payment for client
 	logger.Debug("senting payment for client")
-	data, err := r.service.SentPayment(c.Request.Context(), body.PaymentID, body.SecretValue, cardBalance)
+	data, err := r.service.SentPayment(c.Request.Context(), c.GetInt("clientID"), body.PaymentID, body.SecretValue)
 	if err != nil {
 		logger.Error("failed to create payment", "err", err)
 		err, ok := err.(*service.Error)
@@ -288,3 +333,103 @@ func (r *paymentRoutes) sentPayment(c *gin.Context) {
 	logger.Info("successfully send payment")
 	c.JSON(http.StatusOK, sentPaymentResponse{Status: data})
 }
+
+// generatePaymentQRRequestQuery - represents generatePaymentQR request query.
+type generatePaymentQRRequestQuery struct {
+	IBAN    string  `form:"iban" binding:"required"`
+	Amount  float64 `form:"amount"`
+	Purpose string  `form:"purpose"`
+	Format  string  `form:"format"`
+	Image   string  `form:"image"`
+}
+
+// generatePaymentQRResponse - represents generatePaymentQR response, png is base64 encoded.
+type generatePaymentQRResponse struct {
+	Format  string         `json:"format,omitempty"`
+	Payload string         `json:"payload,omitempty"`
+	PNG     []byte         `json:"png,omitempty"`
+	Error   *service.Error `json:"error,omitempty"`
+}
+
+func (r *paymentRoutes) generatePaymentQR(c *gin.Context) {
+	logger := r.logger.Named("generatePaymentQR")
+
+	// parse request query
+	var query generatePaymentQRRequestQuery
+	logger.Info("parsing request query")
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logger.Error("failed to parse request query", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+		return
+	}
+	logger = logger.With("query", query)
+
+	qr, err := r.service.GeneratePaymentQR(c.Request.Context(), c.GetInt("clientID"), getPermissions(c),
+		&service.PaymentQRInput{
+			IBAN:    query.IBAN,
+			Amount:  query.Amount,
+			Purpose: query.Purpose,
+			Format:  query.Format,
+		})
+	if err != nil {
+		logger.Error("failed to generate payment qr", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, generatePaymentQRResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to generate payment qr")
+		return
+	}
+
+	logger.Info("successfully generated payment qr")
+	if query.Image == "png" {
+		c.Data(http.StatusOK, "image/png", qr.PNG)
+		return
+	}
+	c.JSON(http.StatusOK, generatePaymentQRResponse{
+		Format:  qr.Format,
+		Payload: qr.Payload,
+		PNG:     qr.PNG,
+	})
+}
+
+// parsePaymentQRRequestBody - represents parsePaymentQR request body.
+type parsePaymentQRRequestBody struct {
+	Payload string `json:"payload" binding:"required"`
+}
+
+// parsePaymentQRResponse - represents parsePaymentQR response.
+type parsePaymentQRResponse struct {
+	Payment *service.PaymentInput `json:"payment,omitempty"`
+	Error   *service.Error        `json:"error,omitempty"`
+}
+
+func (r *paymentRoutes) parsePaymentQR(c *gin.Context) {
+	logger := r.logger.Named("parsePaymentQR")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body parsePaymentQRRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	payment, err := r.service.ParsePaymentQR(c.Request.Context(), body.Payload)
+	if err != nil {
+		logger.Error("failed to parse payment qr", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, parsePaymentQRResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to parse payment qr")
+		return
+	}
+
+	logger.Info("successfully parsed payment qr")
+	c.JSON(http.StatusOK, parsePaymentQRResponse{Payment: payment})
+}
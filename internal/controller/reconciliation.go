@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// reconciliationResponse - represents reconciliation report response.
+type reconciliationResponse struct {
+	Report *service.ReconciliationReport `json:"report,omitempty"`
+	Error  *service.Error                `json:"error,omitempty"`
+}
+
+func (r *adminRoutes) getReconciliationReport(c *gin.Context) {
+	logger := r.logger.Named("getReconciliationReport")
+
+	var runId int
+	var err error
+	if c.Query("runId") != "" {
+		runId, err = strconv.Atoi(c.Query("runId"))
+		if err != nil {
+			logger.Error("failed to parse query params", "err", err)
+			errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+			return
+		}
+	}
+
+	report, err := r.service.GetReconciliationReport(c.Request.Context(), runId)
+	if err != nil {
+		logger.Error("failed to get reconciliation report", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, reconciliationResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to get reconciliation report")
+		return
+	}
+
+	logger.Info("successfully get reconciliation report")
+	writeReconciliationReport(c, report)
+}
+
+// reconcileRequestBody - represents reconcile request body.
+type reconcileRequestBody struct {
+	Threshold float64 `json:"threshold"`
+	Freeze    bool    `json:"freeze"`
+}
+
+func (r *adminRoutes) reconcile(c *gin.Context) {
+	logger := r.logger.Named("reconcile")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body reconcileRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	report, err := r.service.Reconcile(c.Request.Context(), &service.ReconcileInput{
+		Threshold: body.Threshold,
+		Freeze:    body.Freeze,
+	})
+	if err != nil {
+		logger.Error("failed to reconcile balances", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, reconciliationResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to reconcile balances")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully reconciled balances: %d discrepancies, %d accounts frozen", report.Run.Discrepancies, report.Run.Frozen),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully reconciled balances")
+	writeReconciliationReport(c, report)
+}
+
+// writeReconciliationReport - writes report as CSV when format=csv is requested, otherwise as JSON.
+func writeReconciliationReport(c *gin.Context, report *service.ReconciliationReport) {
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, reconciliationResponse{Report: report})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=reconciliation-%d.csv", report.Run.ID))
+	c.Status(http.StatusOK)
+	_ = report.WriteCSV(c.Writer)
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// rolesResponse - represents roles response.
+type rolesResponse struct {
+	Data  []domain.Role  `json:"data"`
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *adminRoutes) searchRoles(c *gin.Context) {
+	logger := r.logger.Named("searchRoles")
+
+	roles, err := r.service.SearchRoles(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to search roles", "err", err)
+		errorResponse(c, http.StatusInternalServerError, "failed to search roles")
+		return
+	}
+
+	logger.Info("successfully search roles")
+	c.JSON(http.StatusOK, rolesResponse{Data: roles})
+}
+
+func (r *adminRoutes) getUserRoles(c *gin.Context) {
+	logger := r.logger.Named("getUserRoles")
+
+	// parse request query
+	userId, err := strconv.Atoi(c.Query("userId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+	logger = logger.With("userId", userId)
+
+	roles, err := r.service.GetUserRoles(c.Request.Context(), userId)
+	if err != nil {
+		logger.Error("failed to get user roles", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, rolesResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to get user roles")
+		return
+	}
+
+	logger.Info("successfully got user roles")
+	c.JSON(http.StatusOK, rolesResponse{Data: roles})
+}
+
+// assignUserRolesRequestBody - represents assignUserRoles request body.
+type assignUserRolesRequestBody struct {
+	UserID int      `json:"userId" binding:"required"`
+	Roles  []string `json:"roles" binding:"required"`
+}
+
+func (r *adminRoutes) assignUserRoles(c *gin.Context) {
+	logger := r.logger.Named("assignUserRoles")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body assignUserRolesRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	roles, err := r.service.AssignUserRoles(c.Request.Context(), body.UserID, body.Roles)
+	if err != nil {
+		logger.Error("failed to assign user roles", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, rolesResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to assign user roles")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully assigned roles %s to user #%d", strings.Join(body.Roles, ", "), body.UserID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully assigned user roles")
+	c.JSON(http.StatusOK, rolesResponse{Data: roles})
+}
@@ -28,5 +28,15 @@ func NewRouter(handler *gin.Engine, s service.Services, l logger.Interface, r se
 		newBankAccountRoutes(h, s, l, r)
 		newPaymentRoutes(h, s, l, r)
 		newAdminRoutes(h, s, l, r)
+		newDepositRoutes(h, s, l, r)
+		newLoanRoutes(h, s, l, r)
+		newWebhookRoutes(h, s, l, r)
+		newMerchantRoutes(h, s, l, r)
+		newPaymentLinkRoutes(h, s, l, r)
+		newSplitRoutes(h, s, l, r)
+		newSpendingRoutes(h, s, l, r)
+		newBudgetRoutes(h, s, l, r)
+		newNotificationRoutes(h, s, l, r)
+		newDevRoutes(h, s, l)
 	}
 }
@@ -0,0 +1,307 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// spendingRoutes - represents spending analytics service router.
+type spendingRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newSpendingRoutes - implements new spending analytics service routes.
+func newSpendingRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &spendingRoutes{s, repo, l}
+	h := handler.Group("/spending")
+	{
+		// routes
+		h.GET("/analytics", newAuthMiddleware(s, l, "payments:read"), r.getSpendingAnalytics)
+		h.GET("/categories", newAuthMiddleware(s, l, "payments:read"), r.getCategories)
+		h.PATCH("/category", newAuthMiddleware(s, l, "payments:write"), r.setPaymentCategory)
+		h.GET("/rules", newAuthMiddleware(s, l), r.searchCategoryRules)
+		h.POST("/rules", newAuthMiddleware(s, l), r.createCategoryRule)
+		h.DELETE("/rules", newAuthMiddleware(s, l), r.deleteCategoryRule)
+	}
+}
+
+// spendingAnalyticsRequestQuery - represents spending analytics request query.
+// Dates are YYYY-MM-DD, both ends are inclusive.
+type spendingAnalyticsRequestQuery struct {
+	From    string `form:"from"`
+	To      string `form:"to"`
+	Account string `form:"account"`
+}
+
+// spendingAnalyticsResponse - represents spending analytics response.
+type spendingAnalyticsResponse struct {
+	Analytics *service.SpendingAnalytics `json:"analytics,omitempty"`
+	Error     *service.Error             `json:"error,omitempty"`
+}
+
+func (r *spendingRoutes) getSpendingAnalytics(c *gin.Context) {
+	logger := r.logger.Named("getSpendingAnalytics")
+
+	// parse request query
+	var query spendingAnalyticsRequestQuery
+	logger.Info("parsing request query")
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logger.Error("failed to parse request query", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+		return
+	}
+	inp := &service.SpendingAnalyticsInput{
+		AccountIBAN: query.Account,
+	}
+	if query.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", query.From, time.Local)
+		if err != nil {
+			logger.Error("failed to parse request query", "err", err)
+			errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+			return
+		}
+		inp.From = &from
+	}
+	if query.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", query.To, time.Local)
+		if err != nil {
+			logger.Error("failed to parse request query", "err", err)
+			errorResponse(c, http.StatusBadRequest, "failed to parse request query")
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		inp.To = &to
+	}
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	analytics, err := r.service.GetSpendingAnalytics(c.Request.Context(), client.ID, inp)
+	if err != nil {
+		logger.Error("failed to get spending analytics", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, spendingAnalyticsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to get spending analytics")
+		return
+	}
+
+	logger.Info("successfully got spending analytics")
+	c.JSON(http.StatusOK, spendingAnalyticsResponse{Analytics: analytics})
+}
+
+// categoriesResponse - represents payment categories response.
+type categoriesResponse struct {
+	Categories []string `json:"categories"`
+}
+
+func (r *spendingRoutes) getCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, categoriesResponse{Categories: domain.PaymentCategories})
+}
+
+// setPaymentCategoryRequestBody - represents setPaymentCategory request body.
+// Empty category returns payment to automatic categorization.
+type setPaymentCategoryRequestBody struct {
+	PaymentID int64  `json:"paymentId" binding:"required"`
+	Category  string `json:"category"`
+}
+
+// paymentCategoryResponse - represents payment category response.
+type paymentCategoryResponse struct {
+	Payment *domain.Payment `json:"payment,omitempty"`
+	Error   *service.Error  `json:"error,omitempty"`
+}
+
+func (r *spendingRoutes) setPaymentCategory(c *gin.Context) {
+	logger := r.logger.Named("setPaymentCategory")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body setPaymentCategoryRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	payment, err := r.service.SetPaymentCategory(c.Request.Context(), client.ID,
+		&service.PaymentCategoryInput{
+			PaymentID: body.PaymentID,
+			Category:  body.Category,
+		})
+	if err != nil {
+		logger.Error("failed to set payment category", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, paymentCategoryResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to set payment category")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully changed category of payment #%d", payment.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully set payment category")
+	c.JSON(http.StatusOK, paymentCategoryResponse{Payment: payment})
+}
+
+// searchCategoryRulesResponse - represents search category rules response.
+type searchCategoryRulesResponse struct {
+	Data  []domain.CategoryRule `json:"data"`
+	Error *service.Error        `json:"error,omitempty"`
+}
+
+func (r *spendingRoutes) searchCategoryRules(c *gin.Context) {
+	logger := r.logger.Named("searchCategoryRules")
+
+	rules, err := r.service.SearchCategoryRules(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to search category rules", "err", err)
+		errorResponse(c, http.StatusInternalServerError, "failed to search category rules")
+		return
+	}
+
+	logger.Info("successfully searched category rules")
+	c.JSON(http.StatusOK, searchCategoryRulesResponse{Data: rules})
+}
+
+// createCategoryRuleRequestBody - represents createCategoryRule request body.
+type createCategoryRuleRequestBody struct {
+	Keyword  string `json:"keyword" binding:"required"`
+	Category string `json:"category" binding:"required"`
+}
+
+// categoryRuleResponse - represents category rule response.
+type categoryRuleResponse struct {
+	Rule  *domain.CategoryRule `json:"rule,omitempty"`
+	Error *service.Error       `json:"error,omitempty"`
+}
+
+func (r *spendingRoutes) createCategoryRule(c *gin.Context) {
+	logger := r.logger.Named("createCategoryRule")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createCategoryRuleRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	rule, err := r.service.CreateCategoryRule(c.Request.Context(), client.ID,
+		&service.CategoryRuleInput{
+			Keyword:  body.Keyword,
+			Category: body.Category,
+		})
+	if err != nil {
+		logger.Error("failed to create category rule", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, categoryRuleResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create category rule")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully created category rule %q -> %s", rule.Keyword, rule.Category),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created category rule")
+	c.JSON(http.StatusOK, categoryRuleResponse{Rule: rule})
+}
+
+func (r *spendingRoutes) deleteCategoryRule(c *gin.Context) {
+	logger := r.logger.Named("deleteCategoryRule")
+
+	ruleId, err := strconv.Atoi(c.Query("ruleId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+	logger = logger.With("ruleId", ruleId)
+
+	err = r.service.DeleteCategoryRule(c.Request.Context(), c.GetInt("clientID"), ruleId)
+	if err != nil {
+		logger.Error("failed to delete category rule", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, categoryRuleResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to delete category rule")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully deleted category rule #%d", ruleId),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully deleted category rule")
+	c.JSON(http.StatusOK, categoryRuleResponse{})
+}
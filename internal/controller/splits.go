@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// splitRoutes - represents split bills service router.
+type splitRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newSplitRoutes - implements new split bills service routes.
+func newSplitRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &splitRoutes{s, repo, l}
+	h := handler.Group("/split")
+	{
+		// routes
+		h.POST("/create", newAuthMiddleware(s, l), r.createSplit)
+		h.GET("/search", newAuthMiddleware(s, l), r.searchSplits)
+		h.PATCH("/pay", newAuthMiddleware(s, l), r.paySplitShare)
+		h.PATCH("/cancel", newAuthMiddleware(s, l), r.cancelSplit)
+	}
+}
+
+// splitParticipantRequestBody - represents split participant in createSplit request body.
+type splitParticipantRequestBody struct {
+	UserID     int     `json:"userId" binding:"required"`
+	Amount     float64 `json:"amount"`
+	Percentage float64 `json:"percentage"`
+}
+
+// createSplitRequestBody - represents createSplit request body.
+type createSplitRequestBody struct {
+	CardNumber   int64                         `json:"cardNumber" binding:"required"`
+	Description  string                        `json:"description" binding:"required"`
+	Total        float64                       `json:"total" binding:"required"`
+	Method       string                        `json:"method" binding:"required"`
+	Participants []splitParticipantRequestBody `json:"participants" binding:"required,dive"`
+}
+
+// splitResponse - represents split response.
+type splitResponse struct {
+	Split *domain.Split  `json:"split,omitempty"`
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *splitRoutes) createSplit(c *gin.Context) {
+	logger := r.logger.Named("createSplit")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createSplitRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	participants := make([]service.SplitParticipantInput, 0, len(body.Participants))
+	for _, p := range body.Participants {
+		participants = append(participants, service.SplitParticipantInput{
+			UserID:     p.UserID,
+			Amount:     p.Amount,
+			Percentage: p.Percentage,
+		})
+	}
+
+	split, err := r.service.CreateSplit(c.Request.Context(), client.ID,
+		&service.SplitInput{
+			CardNumber:   body.CardNumber,
+			Description:  body.Description,
+			Total:        body.Total,
+			Method:       body.Method,
+			Participants: participants,
+		})
+	if err != nil {
+		logger.Error("failed to create split", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, splitResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create split")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully split %.2f among %d participants", split.Total, len(split.Shares)),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created split")
+	c.JSON(http.StatusOK, splitResponse{Split: split})
+}
+
+// searchSplitsResponse - represents search splits response.
+type searchSplitsResponse struct {
+	Data       []domain.Split     `json:"data"`
+	Pagination *domain.Pagination `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *splitRoutes) searchSplits(c *gin.Context) {
+	logger := r.logger.Named("searchSplits")
+
+	filter, err := getFilterFromQuery(c.Request, domain.SplitSortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := r.service.SearchSplits(c.Request.Context(), filter, c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to search splits", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchSplitsResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search splits")
+		return
+	}
+
+	logger.Info("successfully search splits")
+	c.JSON(http.StatusOK, searchSplitsResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// paySplitShareRequestBody - represents paySplitShare request body.
+type paySplitShareRequestBody struct {
+	ShareID     int    `json:"shareId" binding:"required"`
+	CardNumber  int64  `json:"cardNumber" binding:"required"`
+	SecretValue string `json:"secretValue" binding:"required"`
+}
+
+func (r *splitRoutes) paySplitShare(c *gin.Context) {
+	logger := r.logger.Named("paySplitShare")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body paySplitShareRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("shareId", body.ShareID, "cardNumber", body.CardNumber)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	split, err := r.service.PaySplitShare(c.Request.Context(), client.ID,
+		&service.PaySplitShareInput{
+			ShareID:     body.ShareID,
+			CardNumber:  body.CardNumber,
+			SecretValue: body.SecretValue,
+		})
+	if err != nil {
+		logger.Error("failed to pay split share", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, splitResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to pay split share")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully paid share #%d of split #%d", body.ShareID, split.ID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully paid split share")
+	c.JSON(http.StatusOK, splitResponse{Split: split})
+}
+
+// cancelSplitRequestBody - represents cancelSplit request body.
+type cancelSplitRequestBody struct {
+	SplitID int `json:"splitId" binding:"required"`
+}
+
+func (r *splitRoutes) cancelSplit(c *gin.Context) {
+	logger := r.logger.Named("cancelSplit")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body cancelSplitRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("splitId", body.SplitID)
+
+	split, err := r.service.CancelSplit(c.Request.Context(), c.GetInt("clientID"), body.SplitID)
+	if err != nil {
+		logger.Error("failed to cancel split", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, splitResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to cancel split")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully cancelled split #%d", body.SplitID),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully cancelled split")
+	c.JSON(http.StatusOK, splitResponse{Split: split})
+}
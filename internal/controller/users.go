@@ -1,6 +1,9 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 
 	// third party
@@ -27,12 +30,16 @@ func newUserRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interf
 	h := handler.Group("/users")
 	{
 		// routes
-		h.GET("/search", newAuthMiddleware(s, l), r.searchUser)
+		h.GET("/search", newAuthMiddleware(s, l), requirePermission(l, domain.PermissionUsersReadAll), r.searchUser)
 		h.GET("/search_logs", newAuthMiddleware(s, l), r.searchLogs)
 		h.POST("/register", r.registerUser)
 		h.POST("/login", r.loginUser)
+		h.POST("/refresh", r.refreshSession)
+		h.POST("/logout", newAuthMiddleware(s, l), r.logout)
 		h.POST("/sendemail", r.sendEmail)
 		h.PATCH("/resetpassword", r.resetPassword)
+		h.PATCH("/language", newAuthMiddleware(s, l), r.updateLanguage)
+		h.PATCH("/phone", newAuthMiddleware(s, l), r.updatePhone)
 	}
 }
 
@@ -52,10 +59,10 @@ type searchUserResponse struct {
 func (r *userRoutes) searchUser(c *gin.Context) {
 	logger := r.logger.Named("searchUser")
 
-	filter, err := getFilterFromQuery(c.Request)
+	filter, err := getFilterFromQuery(c.Request, domain.UserSortFields)
 	if err != nil {
 		logger.Error("failed to parse query params", "err", err)
-		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -68,31 +75,26 @@ func (r *userRoutes) searchUser(c *gin.Context) {
 		return
 	}
 
-	// chear role
-	if c.GetString("userRole") == "admin" {
-		response, err := r.service.Users.SearchUsers(c.Request.Context(), filter)
-		if err != nil {
-			logger.Error("failed to search users", "err", err)
-			// get service error
-			err, ok := err.(*service.Error)
-			if ok {
-				c.AbortWithStatusJSON(http.StatusBadRequest, searchUserResponse{Error: err})
-				return
-			}
-			errorResponse(c, http.StatusInternalServerError, "failed to search users")
+	response, err := r.service.Users.SearchUsers(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("failed to search users", "err", err)
+		// get service error
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchUserResponse{Error: err})
 			return
 		}
-		logger = logger.With("search user", response)
-		logger.Debug("got user")
-
-		logger.Info("successfully search users")
-		c.JSON(http.StatusOK, searchUserResponse{
-			Data:       response.Data,
-			Pagination: response.Pagination,
-		})
-	} else {
-		c.JSON(http.StatusForbidden, "you need admin rights!")
+		errorResponse(c, http.StatusInternalServerError, "failed to search users")
+		return
 	}
+	logger = logger.With("search user", response)
+	logger.Debug("got user")
+
+	logger.Info("successfully search users")
+	c.JSON(http.StatusOK, searchUserResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
 }
 
 // searchLogsRequestQuery - represents search logs request query.
@@ -111,10 +113,10 @@ type searchLogsResponse struct {
 func (r *userRoutes) searchLogs(c *gin.Context) {
 	logger := r.logger.Named("searchLogs")
 
-	filter, err := getFilterFromQuery(c.Request)
+	filter, err := getFilterFromQuery(c.Request, domain.MessageLogSortFields)
 	if err != nil {
 		logger.Error("failed to parse query params", "err", err)
-		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -137,7 +139,7 @@ func (r *userRoutes) searchLogs(c *gin.Context) {
 		return
 	}
 
-	response, err := r.service.SearchLogs(c.Request.Context(), filter, client.FullName, c.GetString("userRole"))
+	response, err := r.service.SearchLogs(c.Request.Context(), filter, client.ID, getPermissions(c))
 	if err != nil {
 		logger.Error("failed to search logs", "err", err)
 		// get service error
@@ -160,19 +162,23 @@ func (r *userRoutes) searchLogs(c *gin.Context) {
 }
 
 // registerUserRequestBody - represents registerUser request body.
+// Language of emails is Ukrainian when empty.
 type registerUserRequestBody struct {
 	FullName string `json:"fullName" binding:"required"`
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Language string `json:"language"`
 }
 
 // registerUserResponse - represents registerUser response.
 type registerUserResponse struct {
-	Token    string         `json:"token"`
-	UserID   int            `json:"userId"`
-	FullName string         `json:"fullName" binding:"required"`
-	Email    string         `json:"email" binding:"required"`
-	Error    *service.Error `json:"error,omitempty"`
+	Token        string         `json:"token"`
+	RefreshToken string         `json:"refreshToken"`
+	ExpiresIn    int64          `json:"expiresIn"`
+	UserID       int            `json:"userId"`
+	FullName     string         `json:"fullName" binding:"required"`
+	Email        string         `json:"email" binding:"required"`
+	Error        *service.Error `json:"error,omitempty"`
 }
 
 func (r *userRoutes) registerUser(c *gin.Context) {
@@ -193,9 +199,12 @@ func (r *userRoutes) registerUser(c *gin.Context) {
 	logger.Debug("registering user")
 	registerData, err := r.service.RegisterUser(c.Request.Context(),
 		&service.RegisterUserInput{
-			FullName: body.FullName,
-			Email:    body.Email,
-			Password: body.Password,
+			FullName:  body.FullName,
+			Email:     body.Email,
+			Password:  body.Password,
+			Language:  body.Language,
+			UserAgent: c.Request.UserAgent(),
+			IP:        c.ClientIP(),
 		})
 	if err != nil {
 		logger.Error("failed to register user", "err", err)
@@ -211,10 +220,12 @@ func (r *userRoutes) registerUser(c *gin.Context) {
 	logger = logger.With("registerData", registerData)
 	logger.Info("successfully registered in")
 	c.JSON(http.StatusOK, registerUserResponse{
-		Token:    registerData.Token,
-		UserID:   registerData.UserID,
-		FullName: registerData.FullName,
-		Email:    registerData.Email,
+		Token:        registerData.Token,
+		RefreshToken: registerData.RefreshToken,
+		ExpiresIn:    registerData.ExpiresIn,
+		UserID:       registerData.UserID,
+		FullName:     registerData.FullName,
+		Email:        registerData.Email,
 	})
 }
 
@@ -226,11 +237,13 @@ type loginUserRequestBody struct {
 
 // loginUserResponse - represents login response.
 type loginUserResponse struct {
-	Token    string         `json:"token"`
-	UserID   int            `json:"userId"`
-	FullName string         `json:"fullName" binding:"required"`
-	Email    string         `json:"email" binding:"required"`
-	Error    *service.Error `json:"error,omitempty"`
+	Token        string         `json:"token"`
+	RefreshToken string         `json:"refreshToken"`
+	ExpiresIn    int64          `json:"expiresIn"`
+	UserID       int            `json:"userId"`
+	FullName     string         `json:"fullName" binding:"required"`
+	Email        string         `json:"email" binding:"required"`
+	Error        *service.Error `json:"error,omitempty"`
 }
 
 func (r *userRoutes) loginUser(c *gin.Context) {
@@ -251,8 +264,10 @@ func (r *userRoutes) loginUser(c *gin.Context) {
 	logger.Debug("loginning user")
 	loginData, err := r.service.LoginUser(c.Request.Context(),
 		&service.LoginUserInput{
-			Email:    body.Email,
-			Password: body.Password,
+			Email:     body.Email,
+			Password:  body.Password,
+			UserAgent: c.Request.UserAgent(),
+			IP:        c.ClientIP(),
 		})
 	if err != nil {
 		logger.Error("failed to login user", "err", err)
@@ -267,13 +282,118 @@ func (r *userRoutes) loginUser(c *gin.Context) {
 
 	logger.Info("successfully logged in")
 	c.JSON(http.StatusOK, loginUserResponse{
-		Token:    loginData.Token,
-		UserID:   loginData.UserID,
-		FullName: loginData.FullName,
-		Email:    loginData.Email,
+		Token:        loginData.Token,
+		RefreshToken: loginData.RefreshToken,
+		ExpiresIn:    loginData.ExpiresIn,
+		UserID:       loginData.UserID,
+		FullName:     loginData.FullName,
+		Email:        loginData.Email,
+	})
+}
+
+// refreshSessionRequestBody - represents refreshSession request body.
+type refreshSessionRequestBody struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// refreshSessionResponse - represents refreshSession response.
+type refreshSessionResponse struct {
+	Token        string         `json:"token,omitempty"`
+	RefreshToken string         `json:"refreshToken,omitempty"`
+	ExpiresIn    int64          `json:"expiresIn,omitempty"`
+	Error        *service.Error `json:"error,omitempty"`
+}
+
+func (r *userRoutes) refreshSession(c *gin.Context) {
+	logger := r.logger.Named("refreshSession")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body refreshSessionRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	// refresh session
+	logger.Debug("refreshing session")
+	tokens, err := r.service.RefreshSession(c.Request.Context(),
+		&service.RefreshSessionInput{
+			RefreshToken: body.RefreshToken,
+			UserAgent:    c.Request.UserAgent(),
+			IP:           c.ClientIP(),
+		})
+	if err != nil {
+		logger.Error("failed to refresh session", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, refreshSessionResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to refresh session")
+		return
+	}
+
+	logger.Info("successfully refreshed session")
+	c.JSON(http.StatusOK, refreshSessionResponse{
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	})
 }
 
+// logoutRequestBody - represents logout request body, body is optional.
+type logoutRequestBody struct {
+	All bool `json:"all"`
+}
+
+// logoutResponse - represents logout response.
+type logoutResponse struct {
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *userRoutes) logout(c *gin.Context) {
+	logger := r.logger.Named("logout")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body logoutRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	// revoke sessions
+	logger.Debug("logging out")
+	err = r.service.Logout(c.Request.Context(), c.GetInt("clientID"), c.GetInt("sessionID"), body.All)
+	if err != nil {
+		logger.Error("failed to logout", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, logoutResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to logout")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: "Successfully logged out",
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully logged out")
+	c.JSON(http.StatusOK, logoutResponse{})
+}
+
 // sendEmailRequestBody - represents sendEmail request body.
 type sendEmailRequestBody struct {
 	Email string `json:"email" binding:"required"`
@@ -365,3 +485,100 @@ func (r *userRoutes) resetPassword(c *gin.Context) {
 	logger.Info("successfully resetted password")
 	c.JSON(http.StatusOK, resetPasswordResponse{})
 }
+
+// updateLanguageRequestBody - represents updateLanguage request body.
+type updateLanguageRequestBody struct {
+	Language string `json:"language" binding:"required"`
+}
+
+// updateLanguageResponse - represents updateLanguage response.
+type updateLanguageResponse struct {
+	Language string         `json:"language,omitempty"`
+	Error    *service.Error `json:"error,omitempty"`
+}
+
+func (r *userRoutes) updateLanguage(c *gin.Context) {
+	logger := r.logger.Named("updateLanguage")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body updateLanguageRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("body", body)
+
+	err = r.service.UpdateUserLanguage(c.Request.Context(), c.GetInt("clientID"), body.Language)
+	if err != nil {
+		logger.Error("failed to update language", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, updateLanguageResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to update language")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully changed language to %s", body.Language),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully updated language")
+	c.JSON(http.StatusOK, updateLanguageResponse{Language: body.Language})
+}
+
+// updatePhoneRequestBody - represents updatePhone request body.
+// Empty phone removes phone number.
+type updatePhoneRequestBody struct {
+	Phone string `json:"phone"`
+}
+
+// updatePhoneResponse - represents updatePhone response.
+type updatePhoneResponse struct {
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *userRoutes) updatePhone(c *gin.Context) {
+	logger := r.logger.Named("updatePhone")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body updatePhoneRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = r.service.UpdateUserPhone(c.Request.Context(), c.GetInt("clientID"), body.Phone)
+	if err != nil {
+		logger.Error("failed to update phone", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, updatePhoneResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to update phone")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: "Successfully changed phone number",
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully updated phone")
+	c.JSON(http.StatusOK, updatePhoneResponse{})
+}
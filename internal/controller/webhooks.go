@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	// third party
+	"github.com/gin-gonic/gin"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/logger"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// webhookRoutes - represents webhook service router.
+type webhookRoutes struct {
+	service service.Services
+	repos   service.Repositories
+	logger  logger.Interface
+}
+
+// newWebhookRoutes - implements new webhook service routes.
+func newWebhookRoutes(handler *gin.RouterGroup, s service.Services, l logger.Interface, repo service.Repositories) {
+	r := &webhookRoutes{s, repo, l}
+	h := handler.Group("/webhook")
+	{
+		// routes
+		h.POST("/create", newAuthMiddleware(s, l, "webhooks:manage"), r.createWebhook)
+		h.GET("/search", newAuthMiddleware(s, l, "webhooks:manage"), r.searchWebhooks)
+		h.DELETE("/delete", newAuthMiddleware(s, l, "webhooks:manage"), r.deleteWebhook)
+		h.GET("/deliveries", newAuthMiddleware(s, l, "webhooks:manage"), r.searchWebhookDeliveries)
+		h.PATCH("/replay", newAuthMiddleware(s, l, "webhooks:manage"), r.replayWebhookDelivery)
+	}
+}
+
+// createWebhookRequestBody - represents createWebhook request body.
+type createWebhookRequestBody struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+}
+
+// webhookResponse - represents webhook response.
+type webhookResponse struct {
+	Webhook *domain.Webhook `json:"webhook,omitempty"`
+	Error   *service.Error  `json:"error,omitempty"`
+}
+
+func (r *webhookRoutes) createWebhook(c *gin.Context) {
+	logger := r.logger.Named("createWebhook")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body createWebhookRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("url", body.URL, "events", body.Events)
+
+	// get client
+	client, err := r.repos.Users.GetUserByID(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		return
+	}
+	if client.Status == "LOCK" {
+		errorResponse(c, http.StatusInternalServerError, "Your account is blocked! Please, turn to the nearest branch of our bank")
+		return
+	}
+
+	webhook, err := r.service.CreateWebhook(c.Request.Context(), client.ID,
+		&service.WebhookInput{
+			URL:    body.URL,
+			Events: body.Events,
+		})
+	if err != nil {
+		logger.Error("failed to create webhook", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, webhookResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to create webhook")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully registered webhook #%d for %s", webhook.ID, webhook.Events),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully created webhook")
+	c.JSON(http.StatusOK, webhookResponse{Webhook: webhook})
+}
+
+// searchWebhooksResponse - represents search webhooks response.
+type searchWebhooksResponse struct {
+	Data  []domain.Webhook `json:"data"`
+	Error *service.Error   `json:"error,omitempty"`
+}
+
+func (r *webhookRoutes) searchWebhooks(c *gin.Context) {
+	logger := r.logger.Named("searchWebhooks")
+
+	webhooks, err := r.service.SearchWebhooks(c.Request.Context(), c.GetInt("clientID"))
+	if err != nil {
+		logger.Error("failed to search webhooks", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchWebhooksResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search webhooks")
+		return
+	}
+
+	logger.Info("successfully search webhooks")
+	c.JSON(http.StatusOK, searchWebhooksResponse{Data: webhooks})
+}
+
+func (r *webhookRoutes) deleteWebhook(c *gin.Context) {
+	logger := r.logger.Named("deleteWebhook")
+
+	webhookId, err := strconv.Atoi(c.Query("webhookId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+	logger = logger.With("webhookId", webhookId)
+
+	err = r.service.DeleteWebhook(c.Request.Context(), c.GetInt("clientID"), webhookId)
+	if err != nil {
+		logger.Error("failed to delete webhook", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, webhookResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to delete webhook")
+		return
+	}
+
+	_, err = r.service.MessageLogs.CreateMessageLog(c.Request.Context(), c.GetInt("clientID"),
+		&service.MessageLogInput{
+			MessageLog: fmt.Sprintf("Successfully deleted webhook #%d", webhookId),
+		})
+	if err != nil {
+		return
+	}
+
+	logger.Info("successfully deleted webhook")
+	c.JSON(http.StatusOK, webhookResponse{})
+}
+
+// searchWebhookDeliveriesResponse - represents search webhook deliveries response.
+type searchWebhookDeliveriesResponse struct {
+	Data       []domain.WebhookDelivery `json:"data"`
+	Pagination *domain.Pagination       `json:"pagination"`
+
+	Error *service.Error `json:"error,omitempty"`
+}
+
+func (r *webhookRoutes) searchWebhookDeliveries(c *gin.Context) {
+	logger := r.logger.Named("searchWebhookDeliveries")
+
+	filter, err := getFilterFromQuery(c.Request, domain.WebhookDeliverySortFields)
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	webhookId, err := strconv.Atoi(c.Query("webhookId"))
+	if err != nil {
+		logger.Error("failed to parse query params", "err", err)
+		errorResponse(c, http.StatusBadRequest, "failed to parse query params")
+		return
+	}
+
+	response, err := r.service.SearchWebhookDeliveries(c.Request.Context(), filter, c.GetInt("clientID"), webhookId)
+	if err != nil {
+		logger.Error("failed to search webhook deliveries", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, searchWebhookDeliveriesResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to search webhook deliveries")
+		return
+	}
+
+	logger.Info("successfully search webhook deliveries")
+	c.JSON(http.StatusOK, searchWebhookDeliveriesResponse{
+		Data:       response.Data,
+		Pagination: response.Pagination,
+	})
+}
+
+// replayWebhookDeliveryRequestBody - represents replayWebhookDelivery request body.
+type replayWebhookDeliveryRequestBody struct {
+	DeliveryID int `json:"deliveryId" binding:"required"`
+}
+
+// webhookDeliveryResponse - represents webhook delivery response.
+type webhookDeliveryResponse struct {
+	Delivery *domain.WebhookDelivery `json:"delivery,omitempty"`
+	Error    *service.Error          `json:"error,omitempty"`
+}
+
+func (r *webhookRoutes) replayWebhookDelivery(c *gin.Context) {
+	logger := r.logger.Named("replayWebhookDelivery")
+
+	// parse request body
+	logger.Debug("parsing request body")
+	var body replayWebhookDeliveryRequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		logger.Error("failed to parse body", "err", err)
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	logger = logger.With("deliveryId", body.DeliveryID)
+
+	delivery, err := r.service.ReplayWebhookDelivery(c.Request.Context(), c.GetInt("clientID"), body.DeliveryID)
+	if err != nil {
+		logger.Error("failed to replay webhook delivery", "err", err)
+		err, ok := err.(*service.Error)
+		if ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, webhookDeliveryResponse{Error: err})
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "failed to replay webhook delivery")
+		return
+	}
+
+	logger.Info("successfully replayed webhook delivery")
+	c.JSON(http.StatusOK, webhookDeliveryResponse{Delivery: delivery})
+}
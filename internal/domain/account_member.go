@@ -0,0 +1,26 @@
+package domain
+
+import "github.com/Shevchenkko/payment_system/pkg/mysql"
+
+// AccountMember represents the user access to bank account stored in the database.
+type AccountMember struct {
+	ID            int    `json:"id,omitempty" gorm:"primaryKey"`
+	BankAccountID int    `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;uniqueIndex:idx_account_member"`
+	UserID        int    `json:"userId,omitempty" gorm:"column:user_id;not null;uniqueIndex:idx_account_member;index"`
+	Role          string `json:"role,omitempty" gorm:"column:role;type:enum('owner','co-owner','viewer');default:'viewer'"`
+
+	BankAccount *BankAccount `json:"-" gorm:"foreignKey:BankAccountID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User        *User        `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// CanPay reports whether member may move funds and lock or unlock the account.
+func (m *AccountMember) CanPay() bool {
+	return m.Role == "owner" || m.Role == "co-owner"
+}
+
+// IsOwner reports whether member may manage other members.
+func (m *AccountMember) IsOwner() bool {
+	return m.Role == "owner"
+}
@@ -1,17 +1,42 @@
 package domain
 
-import "github.com/Shevchenkko/payment_system/pkg/mysql"
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
 
 // BankAccount represents the bank account model stored in the database.
 type BankAccount struct {
-	ID          int     `json:"id,omitempty" gorm:"primaryKey"`
-	Client      string  `json:"client,omitempty" gorm:"column:client"`
-	SecretValue string  `json:"secretValue" gorm:"column:secret_value"`
-	ITN         int64   `json:"itn,omitempty" gorm:"column:itn;not null;index"`
-	CardNumber  int64   `json:"cardNumber,omitempty" gorm:"column:card_number;not null;unique;index"`
-	IBAN        string  `json:"iban,omitempty" gorm:"column:iban;not null;unique;index"`
-	Balance     float64 `json:"balance,omitempty" gorm:"column:balance"`
-	Status      string  `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','LOCK');default:'ACTIVE'"`
+	ID           int        `json:"id,omitempty" gorm:"primaryKey"`
+	ClientID     int        `json:"clientId,omitempty" gorm:"column:client_id;index"`
+	Client       string     `json:"client,omitempty" gorm:"column:client"`
+	SecretValue  string     `json:"secretValue" gorm:"column:secret_value"`
+	ITN          int64      `json:"itn,omitempty" gorm:"column:itn;not null;index"`
+	CardNumber   int64      `json:"cardNumber,omitempty" gorm:"column:card_number;not null;unique;index"`
+	IBAN         string     `json:"iban,omitempty" gorm:"column:iban;not null;unique;index"`
+	Balance      float64    `json:"balance,omitempty" gorm:"column:balance"`
+	Status       string     `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','LOCK','FROZEN','CLOSED');default:'ACTIVE'"`
+	Type         string     `json:"type,omitempty" gorm:"column:type;type:enum('CURRENT','DEPOSIT');default:'CURRENT'"`
+	MaturityDate *time.Time `json:"maturityDate,omitempty" gorm:"column:maturity_date"`
+	ClosedAt     *time.Time `json:"closedAt,omitempty" gorm:"column:closed_at"`
+
+	Owner *User `json:"-" gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 
 	mysql.Model
 }
+
+// BankAccountSortFields lists fields bank accounts can be sorted by.
+var BankAccountSortFields = SortFields{
+	"id":           "id",
+	"client":       "client",
+	"cardNumber":   "card_number",
+	"iban":         "iban",
+	"balance":      "balance",
+	"status":       "status",
+	"type":         "type",
+	"maturityDate": "maturity_date",
+	"closedAt":     "closed_at",
+	"createdAt":    "created_at",
+	"updatedAt":    "updated_at",
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// BudgetThresholds - represents percents of budget user is alerted at.
+var BudgetThresholds = []int{80, 100}
+
+// BudgetExcludedCategories - represents categories not counted by overall budget,
+// moving money between own accounts is not spending.
+var BudgetExcludedCategories = []string{"transfers", "savings"}
+
+// Budget represents the monthly spending limit of user stored in the database.
+// Empty category limits overall spending. Spent is consumption of Month (YYYY-MM),
+// Alerted is the highest threshold user was alerted about in that month.
+type Budget struct {
+	ID       int     `json:"id,omitempty" gorm:"primaryKey"`
+	UserID   int     `json:"userId,omitempty" gorm:"column:user_id;not null;uniqueIndex:idx_budget_category"`
+	Category string  `json:"category" gorm:"column:category;size:32;uniqueIndex:idx_budget_category"`
+	Amount   float64 `json:"amount" gorm:"column:amount;not null"`
+	Month    string  `json:"month,omitempty" gorm:"column:month;size:7"`
+	Spent    float64 `json:"spent" gorm:"column:spent"`
+	Alerted  int     `json:"alerted" gorm:"column:alerted"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// CrossedThreshold returns the highest threshold reached by spending, 0 when none is reached.
+func (b *Budget) CrossedThreshold() int {
+	for i := len(BudgetThresholds) - 1; i >= 0; i-- {
+		if b.Spent >= b.Amount*float64(BudgetThresholds[i])/100 {
+			return BudgetThresholds[i]
+		}
+	}
+	return 0
+}
+
+// Counts reports whether spending of category is counted by budget.
+func (b *Budget) Counts(category string) bool {
+	if b.Category != "" {
+		return b.Category == category
+	}
+	for _, c := range BudgetExcludedCategories {
+		if c == category {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// BusinessDay represents the closed business day stored in the database.
+// No money movements can be recorded into closed day.
+type BusinessDay struct {
+	ID       int       `json:"id,omitempty" gorm:"primaryKey"`
+	Date     time.Time `json:"date" gorm:"column:date;type:date;not null;uniqueIndex"`
+	Accounts int64     `json:"accounts" gorm:"column:accounts"`
+
+	mysql.Model
+}
+
+// DailyPaymentVolume represents payments created during business day grouped by status.
+type DailyPaymentVolume struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	BusinessDayID int     `json:"businessDayId,omitempty" gorm:"column:business_day_id;not null;index"`
+	Status        string  `json:"status,omitempty" gorm:"column:status"`
+	Count         int64   `json:"count" gorm:"column:count"`
+	Amount        float64 `json:"amount" gorm:"column:amount"`
+
+	mysql.Model
+}
+
+// BalanceSnapshot represents the bank account balance at the end of business day.
+type BalanceSnapshot struct {
+	ID            int       `json:"id,omitempty" gorm:"primaryKey"`
+	BankAccountID int       `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;uniqueIndex:idx_balance_snapshot"`
+	Date          time.Time `json:"date" gorm:"column:date;type:date;not null;uniqueIndex:idx_balance_snapshot"`
+	Balance       float64   `json:"balance" gorm:"column:balance"`
+
+	mysql.Model
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// PaymentCategories - represents categories outgoing payments are sorted into.
+var PaymentCategories = []string{
+	"groceries",
+	"restaurants",
+	"transport",
+	"utilities",
+	"health",
+	"entertainment",
+	"shopping",
+	"travel",
+	"savings",
+	"loans",
+	"transfers",
+	"other",
+}
+
+// DefaultCategoryRules - represents keyword rules applied after rules of user.
+var DefaultCategoryRules = []CategoryRule{
+	{Keyword: "deposit", Category: "savings"},
+	{Keyword: "loan", Category: "loans"},
+	{Keyword: "share of split", Category: "transfers"},
+	{Keyword: "account closure", Category: "transfers"},
+	{Keyword: "supermarket", Category: "groceries"},
+	{Keyword: "grocer", Category: "groceries"},
+	{Keyword: "сільпо", Category: "groceries"},
+	{Keyword: "атб", Category: "groceries"},
+	{Keyword: "restaurant", Category: "restaurants"},
+	{Keyword: "cafe", Category: "restaurants"},
+	{Keyword: "coffee", Category: "restaurants"},
+	{Keyword: "кафе", Category: "restaurants"},
+	{Keyword: "taxi", Category: "transport"},
+	{Keyword: "uber", Category: "transport"},
+	{Keyword: "bolt", Category: "transport"},
+	{Keyword: "fuel", Category: "transport"},
+	{Keyword: "таксі", Category: "transport"},
+	{Keyword: "electricity", Category: "utilities"},
+	{Keyword: "internet", Category: "utilities"},
+	{Keyword: "mobile", Category: "utilities"},
+	{Keyword: "комунальн", Category: "utilities"},
+	{Keyword: "pharmacy", Category: "health"},
+	{Keyword: "clinic", Category: "health"},
+	{Keyword: "аптека", Category: "health"},
+	{Keyword: "cinema", Category: "entertainment"},
+	{Keyword: "netflix", Category: "entertainment"},
+	{Keyword: "spotify", Category: "entertainment"},
+	{Keyword: "hotel", Category: "travel"},
+	{Keyword: "airline", Category: "travel"},
+	{Keyword: "booking", Category: "travel"},
+	{Keyword: "shop", Category: "shopping"},
+	{Keyword: "store", Category: "shopping"},
+	{Keyword: "магазин", Category: "shopping"},
+}
+
+// CategoryRule represents the user keyword rule of payment categorization stored in the database.
+// Outgoing payment containing keyword in description gets category of the rule.
+type CategoryRule struct {
+	ID       int    `json:"id,omitempty" gorm:"primaryKey"`
+	UserID   int    `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	Keyword  string `json:"keyword,omitempty" gorm:"column:keyword;not null"`
+	Category string `json:"category,omitempty" gorm:"column:category;not null"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// IsPaymentCategory reports whether category is one of payment categories.
+func IsPaymentCategory(category string) bool {
+	for _, c := range PaymentCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
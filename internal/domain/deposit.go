@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// DepositProduct represents the term deposit catalog entry stored in the database.
+type DepositProduct struct {
+	ID          int     `json:"id,omitempty" gorm:"primaryKey"`
+	Name        string  `json:"name,omitempty" gorm:"column:name;not null"`
+	TermMonths  int     `json:"termMonths,omitempty" gorm:"column:term_months;not null"`
+	Rate        float64 `json:"rate,omitempty" gorm:"column:rate;not null"`
+	PenaltyRate float64 `json:"penaltyRate" gorm:"column:penalty_rate"`
+	MinAmount   float64 `json:"minAmount" gorm:"column:min_amount"`
+	Status      string  `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','ARCHIVED');default:'ACTIVE'"`
+
+	mysql.Model
+}
+
+// DepositProductSortFields lists fields deposit products can be sorted by.
+var DepositProductSortFields = SortFields{
+	"id":          "id",
+	"name":        "name",
+	"termMonths":  "term_months",
+	"rate":        "rate",
+	"penaltyRate": "penalty_rate",
+	"minAmount":   "min_amount",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+}
+
+// Deposit represents the term deposit model stored in the database.
+// Funds are held on a separate DEPOSIT bank account until maturity.
+type Deposit struct {
+	ID              int        `json:"id,omitempty" gorm:"primaryKey"`
+	ProductID       int        `json:"productId,omitempty" gorm:"column:product_id;not null;index"`
+	BankAccountID   int        `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	SourceAccountID int        `json:"sourceAccountId,omitempty" gorm:"column:source_account_id;not null;index"`
+	Principal       float64    `json:"principal,omitempty" gorm:"column:principal"`
+	Rate            float64    `json:"rate,omitempty" gorm:"column:rate"`
+	PenaltyRate     float64    `json:"penaltyRate" gorm:"column:penalty_rate"`
+	TermMonths      int        `json:"termMonths,omitempty" gorm:"column:term_months"`
+	StartDate       time.Time  `json:"startDate" gorm:"column:start_date"`
+	MaturityDate    time.Time  `json:"maturityDate" gorm:"column:maturity_date;index"`
+	Rollover        bool       `json:"rollover" gorm:"column:rollover"`
+	Rollovers       int        `json:"rollovers" gorm:"column:rollovers"`
+	InterestPaid    float64    `json:"interestPaid" gorm:"column:interest_paid"`
+	Status          string     `json:"status,omitempty" gorm:"column:status;type:enum('OPEN','MATURED','WITHDRAWN');default:'OPEN'"`
+	ClosedAt        *time.Time `json:"closedAt,omitempty" gorm:"column:closed_at"`
+
+	mysql.Model
+}
+
+// DepositSortFields lists fields deposits can be sorted by.
+var DepositSortFields = SortFields{
+	"id":           "id",
+	"principal":    "principal",
+	"rate":         "rate",
+	"termMonths":   "term_months",
+	"startDate":    "start_date",
+	"maturityDate": "maturity_date",
+	"interestPaid": "interest_paid",
+	"status":       "status",
+	"closedAt":     "closed_at",
+	"createdAt":    "created_at",
+	"updatedAt":    "updated_at",
+}
+
+// DepositInterest represents interest credited to deposit bank account on rollover or closure,
+// so balance of the account can be recounted at any moment.
+type DepositInterest struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	DepositID     int     `json:"depositId,omitempty" gorm:"column:deposit_id;not null;index"`
+	BankAccountID int     `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	Amount        float64 `json:"amount" gorm:"column:amount"`
+
+	Deposit *Deposit `json:"-" gorm:"foreignKey:DepositID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
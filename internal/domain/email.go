@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// OutboxEmail represents the email waiting for delivery with its attempts stored in the database.
+// Emails failed every attempt stay DEAD until admin sends them again.
+type OutboxEmail struct {
+	ID            int        `json:"id,omitempty" gorm:"primaryKey"`
+	To            string     `json:"to,omitempty" gorm:"column:recipient;not null"`
+	Subject       string     `json:"subject,omitempty" gorm:"column:subject"`
+	ContentType   string     `json:"contentType,omitempty" gorm:"column:content_type"`
+	Body          string     `json:"-" gorm:"column:body;type:mediumtext"`
+	TextBody      string     `json:"-" gorm:"column:text_body;type:mediumtext"`
+	Status        string     `json:"status,omitempty" gorm:"column:status;type:enum('PENDING','SENDING','SENT','DEAD');default:'PENDING';index"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`
+	LastError     string     `json:"lastError,omitempty" gorm:"column:last_error;type:text"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" gorm:"column:next_attempt_at;index"`
+	SentAt        *time.Time `json:"sentAt,omitempty" gorm:"column:sent_at"`
+
+	mysql.Model
+}
+
+// OutboxEmailSortFields lists fields outbox emails can be sorted by.
+var OutboxEmailSortFields = SortFields{
+	"id":            "id",
+	"to":            "recipient",
+	"subject":       "subject",
+	"status":        "status",
+	"attempts":      "attempts",
+	"nextAttemptAt": "next_attempt_at",
+	"sentAt":        "sent_at",
+	"createdAt":     "created_at",
+	"updatedAt":     "updated_at",
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// LoanProduct represents the consumer loan product stored in the database.
+type LoanProduct struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	Name          string  `json:"name,omitempty" gorm:"column:name;not null"`
+	Rate          float64 `json:"rate,omitempty" gorm:"column:rate;not null"`
+	TermMonths    int     `json:"termMonths,omitempty" gorm:"column:term_months;not null"`
+	RepaymentType string  `json:"repaymentType,omitempty" gorm:"column:repayment_type;type:enum('annuity','differentiated');default:'annuity'"`
+	PenaltyFee    float64 `json:"penaltyFee" gorm:"column:penalty_fee"`
+	MinAmount     float64 `json:"minAmount" gorm:"column:min_amount"`
+	MaxAmount     float64 `json:"maxAmount" gorm:"column:max_amount"`
+	Status        string  `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','ARCHIVED');default:'ACTIVE'"`
+
+	mysql.Model
+}
+
+// LoanProductSortFields lists fields loan products can be sorted by.
+var LoanProductSortFields = SortFields{
+	"id":         "id",
+	"name":       "name",
+	"rate":       "rate",
+	"termMonths": "term_months",
+	"minAmount":  "min_amount",
+	"maxAmount":  "max_amount",
+	"createdAt":  "created_at",
+	"updatedAt":  "updated_at",
+}
+
+// Loan represents the consumer loan model stored in the database.
+type Loan struct {
+	ID            int        `json:"id,omitempty" gorm:"primaryKey"`
+	ProductID     int        `json:"productId,omitempty" gorm:"column:product_id;not null;index"`
+	BankAccountID int        `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	Principal     float64    `json:"principal,omitempty" gorm:"column:principal"`
+	Outstanding   float64    `json:"outstanding" gorm:"column:outstanding"`
+	Rate          float64    `json:"rate,omitempty" gorm:"column:rate"`
+	TermMonths    int        `json:"termMonths,omitempty" gorm:"column:term_months"`
+	RepaymentType string     `json:"repaymentType,omitempty" gorm:"column:repayment_type;type:enum('annuity','differentiated');default:'annuity'"`
+	PenaltyFee    float64    `json:"penaltyFee" gorm:"column:penalty_fee"`
+	Status        string     `json:"status,omitempty" gorm:"column:status;type:enum('PENDING','ACTIVE','REJECTED','PAID_OFF');default:'PENDING'"`
+	DisbursedAt   *time.Time `json:"disbursedAt,omitempty" gorm:"column:disbursed_at"`
+	ClosedAt      *time.Time `json:"closedAt,omitempty" gorm:"column:closed_at"`
+
+	mysql.Model
+}
+
+// LoanSortFields lists fields loans can be sorted by.
+var LoanSortFields = SortFields{
+	"id":          "id",
+	"principal":   "principal",
+	"outstanding": "outstanding",
+	"rate":        "rate",
+	"termMonths":  "term_months",
+	"status":      "status",
+	"disbursedAt": "disbursed_at",
+	"closedAt":    "closed_at",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+}
+
+// LoanInstallment represents the amortization schedule entry stored in the database.
+type LoanInstallment struct {
+	ID         int        `json:"id,omitempty" gorm:"primaryKey"`
+	LoanID     int        `json:"loanId,omitempty" gorm:"column:loan_id;not null;index"`
+	Number     int        `json:"number,omitempty" gorm:"column:number"`
+	DueDate    time.Time  `json:"dueDate" gorm:"column:due_date;index"`
+	Principal  float64    `json:"principal" gorm:"column:principal"`
+	Interest   float64    `json:"interest" gorm:"column:interest"`
+	PenaltyFee float64    `json:"penaltyFee" gorm:"column:penalty_fee"`
+	Status     string     `json:"status,omitempty" gorm:"column:status;type:enum('SCHEDULED','OVERDUE','PAID');default:'SCHEDULED';index"`
+	PaidAt     *time.Time `json:"paidAt,omitempty" gorm:"column:paid_at"`
+
+	mysql.Model
+}
+
+// AmountDue returns the amount which must be debited to settle installment.
+func (i *LoanInstallment) AmountDue() float64 {
+	return i.Principal + i.Interest + i.PenaltyFee
+}
+
+// LoanRepayment represents money debited from bank account in favour of a loan.
+type LoanRepayment struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	LoanID        int     `json:"loanId,omitempty" gorm:"column:loan_id;not null;index"`
+	BankAccountID int     `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	InstallmentID int     `json:"installmentId,omitempty" gorm:"column:installment_id"`
+	Type          string  `json:"type,omitempty" gorm:"column:type;type:enum('installment','prepayment')"`
+	Amount        float64 `json:"amount,omitempty" gorm:"column:amount"`
+
+	mysql.Model
+}
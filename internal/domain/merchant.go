@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// APIKeyScopes - represents scopes that merchant api key can be granted.
+var APIKeyScopes = []string{"payments:read", "payments:write", "accounts:read", "webhooks:manage"}
+
+// Merchant represents the business profile with settlement bank account stored in the database.
+type Merchant struct {
+	ID                  int    `json:"id,omitempty" gorm:"primaryKey"`
+	UserID              int    `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	Name                string `json:"name,omitempty" gorm:"column:name;not null"`
+	SettlementAccountID int    `json:"settlementAccountId,omitempty" gorm:"column:settlement_account_id;not null;index"`
+	Category            string `json:"category,omitempty" gorm:"column:category"`
+	Status              string `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','DISABLED');default:'ACTIVE'"`
+
+	User              *User        `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	SettlementAccount *BankAccount `json:"-" gorm:"foreignKey:SettlementAccountID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	mysql.Model
+}
+
+// MerchantAPIKey represents the merchant api key stored in the database.
+// Only key prefix and hash of the whole key are stored.
+type MerchantAPIKey struct {
+	ID         int        `json:"id,omitempty" gorm:"primaryKey"`
+	MerchantID int        `json:"merchantId,omitempty" gorm:"column:merchant_id;not null;index"`
+	Name       string     `json:"name,omitempty" gorm:"column:name"`
+	Prefix     string     `json:"prefix,omitempty" gorm:"column:prefix;not null;uniqueIndex"`
+	KeyHash    string     `json:"-" gorm:"column:key_hash;not null"`
+	Scopes     string     `json:"scopes,omitempty" gorm:"column:scopes;not null"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" gorm:"column:revoked_at"`
+
+	Merchant *Merchant `json:"-" gorm:"foreignKey:MerchantID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// HasScope reports whether api key is granted scope.
+func (k *MerchantAPIKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
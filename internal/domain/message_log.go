@@ -4,9 +4,20 @@ import "github.com/Shevchenkko/payment_system/pkg/mysql"
 
 // MessageLog represents the message log model stored in the database.
 type MessageLog struct {
-	ID      int    `json:"id,omitempty" gorm:"primaryKey"`
-	Client  string `json:"client,omitempty" gorm:"column:client"`
-	Message string `json:"message,omitempty" gorm:"column:message"`
+	ID       int    `json:"id,omitempty" gorm:"primaryKey"`
+	ClientID int    `json:"clientId,omitempty" gorm:"column:client_id;index"`
+	Client   string `json:"client,omitempty" gorm:"column:client"`
+	Message  string `json:"message,omitempty" gorm:"column:message"`
+
+	Owner *User `json:"-" gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 
 	mysql.Model
 }
+
+// MessageLogSortFields lists fields message logs can be sorted by.
+var MessageLogSortFields = SortFields{
+	"id":        "id",
+	"client":    "client",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// NotificationEvents - represents events user is notified about.
+var NotificationEvents = []string{
+	"payment.sent",
+	"payment.received",
+	"topup.completed",
+	"account.locked",
+	"user.locked",
+	"user.login",
+	"budget.reached",
+}
+
+// Notification represents the in-app inbox notification stored in the database.
+type Notification struct {
+	ID     int        `json:"id,omitempty" gorm:"primaryKey"`
+	UserID int        `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	Event  string     `json:"event,omitempty" gorm:"column:event;not null"`
+	Title  string     `json:"title,omitempty" gorm:"column:title"`
+	Body   string     `json:"body,omitempty" gorm:"column:body;type:text"`
+	ReadAt *time.Time `json:"readAt,omitempty" gorm:"column:read_at;index"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// NotificationSortFields lists fields notifications can be sorted by.
+var NotificationSortFields = SortFields{
+	"id":        "id",
+	"event":     "event",
+	"readAt":    "read_at",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// NotificationPreference represents channels user enabled for event stored in the database.
+// Events without preference are sent through every channel.
+type NotificationPreference struct {
+	ID     int    `json:"id,omitempty" gorm:"primaryKey"`
+	UserID int    `json:"userId,omitempty" gorm:"column:user_id;not null;uniqueIndex:idx_notification_preference"`
+	Event  string `json:"event" gorm:"column:event;size:64;not null;uniqueIndex:idx_notification_preference"`
+	Email  bool   `json:"email" gorm:"column:email"`
+	Inbox  bool   `json:"inbox" gorm:"column:inbox"`
+	SMS    bool   `json:"sms" gorm:"column:sms"`
+	Push   bool   `json:"push" gorm:"column:push"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// IsNotificationEvent reports whether user can be notified about event.
+func IsNotificationEvent(event string) bool {
+	for _, e := range NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationChannels - represents channels notifications are delivered through besides email and inbox.
+var NotificationChannels = []string{"sms", "push"}
+
+// DevicePlatforms - represents platforms of devices push notifications are sent to.
+var DevicePlatforms = []string{"android", "ios", "web"}
+
+// DeviceToken represents the user device push notifications are sent to stored in the database.
+type DeviceToken struct {
+	ID       int    `json:"id,omitempty" gorm:"primaryKey"`
+	UserID   int    `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	Token    string `json:"token,omitempty" gorm:"column:token;size:255;not null;uniqueIndex"`
+	Platform string `json:"platform,omitempty" gorm:"column:platform;type:enum('android','ios','web');not null"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// NotificationDelivery represents the notification sent through sms or push channel with its attempts stored in the database.
+// Recipient is phone number for sms and device token for push.
+type NotificationDelivery struct {
+	ID            int        `json:"id,omitempty" gorm:"primaryKey"`
+	UserID        int        `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	Event         string     `json:"event,omitempty" gorm:"column:event;not null"`
+	Channel       string     `json:"channel,omitempty" gorm:"column:channel;type:enum('sms','push');not null"`
+	Recipient     string     `json:"recipient,omitempty" gorm:"column:recipient;not null"`
+	Title         string     `json:"title,omitempty" gorm:"column:title"`
+	Body          string     `json:"body,omitempty" gorm:"column:body;type:text"`
+	Status        string     `json:"status,omitempty" gorm:"column:status;type:enum('PENDING','SENT','FAILED');default:'PENDING';index"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`
+	LastError     string     `json:"lastError,omitempty" gorm:"column:last_error;type:text"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" gorm:"column:next_attempt_at;index"`
+	SentAt        *time.Time `json:"sentAt,omitempty" gorm:"column:sent_at"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// NotificationDeliverySortFields lists fields notification deliveries can be sorted by.
+var NotificationDeliverySortFields = SortFields{
+	"id":            "id",
+	"event":         "event",
+	"channel":       "channel",
+	"status":        "status",
+	"attempts":      "attempts",
+	"nextAttemptAt": "next_attempt_at",
+	"sentAt":        "sent_at",
+	"createdAt":     "created_at",
+	"updatedAt":     "updated_at",
+}
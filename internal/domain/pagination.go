@@ -1,8 +1,12 @@
 package domain
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -23,6 +27,9 @@ type Filter struct {
 	// SortBy
 	// in:query
 	SortBy []string `json:"sortBy"` // firstname asc, lastname dsc
+	// Cursor is a token of nextCursor or prevCursor, page is ignored when set
+	// in:query
+	Cursor string `json:"cursor"`
 }
 
 // Pagination is a struct for pagination
@@ -35,6 +42,36 @@ type Pagination struct {
 	List int `json:"list"`
 	// TotalItems is a number of items
 	Total *int64 `json:"total"`
+	// NextCursor is a token of the following page
+	NextCursor string `json:"nextCursor,omitempty"`
+	// PrevCursor is a token of the preceding page
+	PrevCursor string `json:"prevCursor,omitempty"`
+}
+
+// Cursor is a position in listing, keyed by id of the row on page boundary.
+// Rows are taken after the row, or before it when Prev is set.
+type Cursor struct {
+	ID   int64 `json:"id"`
+	Prev bool  `json:"prev,omitempty"`
+}
+
+// Encode returns opaque cursor token.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses cursor token.
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("cannot parse cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, errors.New("cannot parse cursor")
+	}
+	return &c, nil
 }
 
 func (f *Filter) OrderString() string {
@@ -42,50 +79,65 @@ func (f *Filter) OrderString() string {
 }
 
 func (f *Filter) Validate() {
-	f.SortBy = f.validatedSortBy()
-	if f.Page < 0 {
-		f.Page = 0
+	if f.Page < 1 {
+		f.Page = 1
 	}
 	if f.List < 1 || f.List > MaxSearchLimit {
 		f.List = DefaultSearchLimit
 	}
 }
 
-func (f *Filter) validatedSortBy() []string {
+// SortFields maps json fields a resource can be sorted by to its columns.
+type SortFields map[string]string
+
+// Allowed returns sortable json fields in alphabetical order.
+func (s SortFields) Allowed() []string {
+	fields := make([]string, 0, len(s))
+	for field := range s {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// column returns column of json field, field name is case insensitive.
+func (s SortFields) column(field string) (string, bool) {
+	for name, column := range s {
+		if strings.EqualFold(name, field) {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// ValidateSortBy replaces sort fields of filter ("createdAt desc") with resource columns ("created_at desc").
+// Fields missing in sortable fields of resource are rejected.
+func (f *Filter) ValidateSortBy(fields SortFields) error {
 	validatedSortByArray := make([]string, 0, len(f.SortBy))
 	for _, sortBy := range f.SortBy {
-		tokens := strings.Split(sortBy, " ")
+		tokens := strings.Fields(sortBy)
 		if len(tokens) != 1 && len(tokens) != 2 {
-			continue
+			return fmt.Errorf("cannot parse sortBy query param %q", sortBy)
 		}
-		validatedSortBy := tokens[0]
-		// update json model fields to database fields
-		switch strings.ToLower(validatedSortBy) {
-		case "createdat":
-			validatedSortBy = "created_at"
-		case "updatedat":
-			validatedSortBy = "updated_at"
-		case "paymentstatus":
-			validatedSortBy = "payment_status"
-		case "fullname":
-			validatedSortBy = "full_name"
+		column, ok := fields.column(tokens[0])
+		if !ok {
+			return fmt.Errorf("cannot sort by %q, allowed fields: %s", tokens[0], strings.Join(fields.Allowed(), ", "))
 		}
+		direction := "asc"
 		if len(tokens) == 2 {
-			switch strings.ToLower(tokens[1]) {
-			case "asc":
-				validatedSortBy += " asc"
-			case "desc":
-				validatedSortBy += " desc"
-			default:
-				continue
+			direction = strings.ToLower(tokens[1])
+			if direction != "asc" && direction != "desc" {
+				return fmt.Errorf("cannot parse sortBy query param %q, order can be asc or desc", sortBy)
 			}
 		}
-		validatedSortByArray = append(validatedSortByArray, validatedSortBy)
+		validatedSortByArray = append(validatedSortByArray, column+" "+direction)
 	}
-	return validatedSortByArray
+	f.SortBy = validatedSortByArray
+	return nil
 }
 
-func GetFilterFromQuery(r *http.Request) (*Filter, error) {
+// GetFilterFromQuery returns filter of query params, sortBy is validated against sortable fields of resource.
+func GetFilterFromQuery(r *http.Request, fields SortFields) (*Filter, error) {
 	var (
 		page int
 		list int
@@ -105,12 +157,22 @@ func GetFilterFromQuery(r *http.Request) (*Filter, error) {
 		}
 	}
 
+	if len(params["cursor"]) != 0 {
+		if _, err := DecodeCursor(params["cursor"][0]); err != nil {
+			return nil, errors.New("cannot parse cursor query param")
+		}
+	}
+
 	filter := &Filter{
 		Page:   page,
 		List:   list,
 		SortBy: params["sortBy"],
+		Cursor: params.Get("cursor"),
 	}
 	filter.Validate()
+	if err := filter.ValidateSortBy(fields); err != nil {
+		return nil, err
+	}
 
 	return filter, nil
 }
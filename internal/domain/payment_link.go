@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// PaymentLink represents the merchant checkout link stored in the database.
+// Single use link is completed by the first payment, multi use link stays active until disabled or expired.
+type PaymentLink struct {
+	ID          int        `json:"id,omitempty" gorm:"primaryKey"`
+	MerchantID  int        `json:"merchantId,omitempty" gorm:"column:merchant_id;not null;index"`
+	Code        string     `json:"code,omitempty" gorm:"column:code;not null;uniqueIndex"`
+	Amount      float64    `json:"amount,omitempty" gorm:"column:amount;not null"`
+	Description string     `json:"description,omitempty" gorm:"column:description"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" gorm:"column:expires_at"`
+	MultiUse    bool       `json:"multiUse" gorm:"column:multi_use"`
+	Status      string     `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','COMPLETED','DISABLED');default:'ACTIVE'"`
+	PaidCount   int        `json:"paidCount" gorm:"column:paid_count"`
+
+	Merchant *Merchant `json:"-" gorm:"foreignKey:MerchantID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// PaymentLinkSortFields lists fields payment links can be sorted by.
+var PaymentLinkSortFields = SortFields{
+	"id":        "id",
+	"amount":    "amount",
+	"expiresAt": "expires_at",
+	"status":    "status",
+	"paidCount": "paid_count",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// Expired reports whether link can no longer be paid because of its expiry.
+func (l *PaymentLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// CurrentStatus returns link status with EXPIRED for active link past its expiry.
+func (l *PaymentLink) CurrentStatus(now time.Time) string {
+	if l.Status == "ACTIVE" && l.Expired(now) {
+		return "EXPIRED"
+	}
+	return l.Status
+}
@@ -1,21 +1,45 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/Shevchenkko/payment_system/pkg/mysql"
 )
 
 // Payment represents the payments model stored in the database.
+// Category of outgoing payment is assigned from recipient merchant, keyword rules or by user (CategorySource).
 type Payment struct {
-	ID                   int64   `json:"id,omitempty" gorm:"primaryKey"`
-	PaymentStatus        string  `json:"paymentStatus,omitempty" gorm:"column:payment_status;type:enum('prepared','sent');default:'prepared'"`
-	FromClient           string  `json:"fromClient,omitempty" gorm:"column:from_client"`
-	FromClientITN        int64   `json:"fromClientItn,omitempty" gorm:"column:from_client_itn;not null;index"`
-	FromClientIBAN       string  `json:"fromClientIban,omitempty" gorm:"column:from_client_iban;not null;index"`
-	FromClientCardNumber int64   `json:"fromClientCardNumber,omitempty" gorm:"column:from_client_card_number;not null;index"`
-	Description          string  `json:"description" gorm:"column:description"`
-	ToClientIBAN         string  `json:"toClientIban,omitempty" gorm:"column:to_client_iban;not null;index"`
-	ToClient             string  `json:"toClient,omitempty" gorm:"column:to_client"`
-	OperationAmount      float64 `json:"operationAmount,omitempty" gorm:"column:operation_amount"`
+	ID                   int64      `json:"id,omitempty" gorm:"primaryKey"`
+	PaymentStatus        string     `json:"paymentStatus,omitempty" gorm:"column:payment_status;type:enum('prepared','sent');default:'prepared'"`
+	FromClientID         int        `json:"fromClientId,omitempty" gorm:"column:from_client_id;index"`
+	FromClient           string     `json:"fromClient,omitempty" gorm:"column:from_client"`
+	FromClientITN        int64      `json:"fromClientItn,omitempty" gorm:"column:from_client_itn;not null;index"`
+	FromClientIBAN       string     `json:"fromClientIban,omitempty" gorm:"column:from_client_iban;not null;index"`
+	FromClientCardNumber int64      `json:"fromClientCardNumber,omitempty" gorm:"column:from_client_card_number;not null;index"`
+	Description          string     `json:"description" gorm:"column:description"`
+	ToClientIBAN         string     `json:"toClientIban,omitempty" gorm:"column:to_client_iban;not null;index"`
+	ToClient             string     `json:"toClient,omitempty" gorm:"column:to_client"`
+	OperationAmount      float64    `json:"operationAmount,omitempty" gorm:"column:operation_amount"`
+	PaymentLinkID        *int       `json:"paymentLinkId,omitempty" gorm:"column:payment_link_id;index"`
+	Category             *string    `json:"category,omitempty" gorm:"column:category;index"`
+	CategorySource       *string    `json:"categorySource,omitempty" gorm:"column:category_source;type:enum('merchant','rule','auto','user')"`
+	SentAt               *time.Time `json:"sentAt,omitempty" gorm:"column:sent_at;index"`
+
+	Owner *User `json:"-" gorm:"foreignKey:FromClientID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 
 	mysql.Model
 }
+
+// PaymentSortFields lists fields payments can be sorted by.
+var PaymentSortFields = SortFields{
+	"id":              "id",
+	"paymentStatus":   "payment_status",
+	"fromClient":      "from_client",
+	"fromClientIban":  "from_client_iban",
+	"toClient":        "to_client",
+	"toClientIban":    "to_client_iban",
+	"operationAmount": "operation_amount",
+	"description":     "description",
+	"createdAt":       "created_at",
+	"updatedAt":       "updated_at",
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "github.com/Shevchenkko/payment_system/pkg/mysql"
+
+// ReconciliationRun represents the balance reconciliation run stored in the database.
+type ReconciliationRun struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	Accounts      int     `json:"accounts" gorm:"column:accounts"`
+	Discrepancies int     `json:"discrepancies" gorm:"column:discrepancies"`
+	Frozen        int     `json:"frozen" gorm:"column:frozen"`
+	Threshold     float64 `json:"threshold" gorm:"column:threshold"`
+
+	mysql.Model
+}
+
+// Discrepancy represents the bank account whose balance does not match its movements.
+type Discrepancy struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	RunID         int     `json:"runId,omitempty" gorm:"column:run_id;not null;index"`
+	BankAccountID int     `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	CardNumber    int64   `json:"cardNumber,omitempty" gorm:"column:card_number"`
+	IBAN          string  `json:"iban,omitempty" gorm:"column:iban"`
+	Client        string  `json:"client,omitempty" gorm:"column:client"`
+	Expected      float64 `json:"expected" gorm:"column:expected"`
+	Actual        float64 `json:"actual" gorm:"column:actual"`
+	Difference    float64 `json:"difference" gorm:"column:difference"`
+	Frozen        bool    `json:"frozen" gorm:"column:frozen"`
+
+	mysql.Model
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// Permissions of staff roles, plain users need none of them to manage own profile and accounts.
+const (
+	PermissionUsersReadAll       = "users:read_all"
+	PermissionUsersLock          = "users:lock"
+	PermissionRolesAssign        = "roles:assign"
+	PermissionAccountsReadAll    = "accounts:read_all"
+	PermissionAccountsLock       = "accounts:lock"
+	PermissionAccountsClose      = "accounts:close"
+	PermissionLogsReadAll        = "logs:read_all"
+	PermissionLoansReadAll       = "loans:read_all"
+	PermissionLoansManage        = "loans:manage"
+	PermissionDepositsManage     = "deposits:manage"
+	PermissionReconciliationRead = "reconciliation:read"
+	PermissionReconciliationRun  = "reconciliation:run"
+	PermissionEmailsManage       = "emails:manage"
+)
+
+// Roles seeded on start.
+const (
+	RoleAdmin      = "admin"
+	RoleSupport    = "support"
+	RoleCompliance = "compliance"
+	RoleUser       = "user"
+)
+
+// RoleDescriptions - represents every seeded role with its description.
+var RoleDescriptions = map[string]string{
+	RoleAdmin:      "Bank administrator with every permission",
+	RoleSupport:    "Support operator helping users with profiles and accounts",
+	RoleCompliance: "Compliance officer auditing users, accounts, loans and balances",
+	RoleUser:       "Bank client managing own profile and accounts",
+}
+
+// PermissionDescriptions - represents every permission with its description.
+var PermissionDescriptions = map[string]string{
+	PermissionUsersReadAll:       "Search all users",
+	PermissionUsersLock:          "Lock and unlock users",
+	PermissionRolesAssign:        "Assign roles to users",
+	PermissionAccountsReadAll:    "Read any bank account, its members and balance history",
+	PermissionAccountsLock:       "Lock and unlock any bank account",
+	PermissionAccountsClose:      "Close any bank account",
+	PermissionLogsReadAll:        "Search logs of all users",
+	PermissionLoansReadAll:       "Read any loan and its schedule",
+	PermissionLoansManage:        "Create loan products, approve and reject loans",
+	PermissionDepositsManage:     "Create deposit products",
+	PermissionReconciliationRead: "Read reconciliation reports",
+	PermissionReconciliationRun:  "Run balance reconciliation",
+	PermissionEmailsManage:       "Preview emails, read and retry email queue",
+}
+
+// RolePermissions - represents roles seeded on start with permissions they grant.
+// Admin is granted every permission.
+var RolePermissions = map[string][]string{
+	RoleAdmin: nil,
+	RoleSupport: {
+		PermissionUsersReadAll,
+		PermissionUsersLock,
+		PermissionAccountsReadAll,
+		PermissionAccountsLock,
+		PermissionLogsReadAll,
+	},
+	RoleCompliance: {
+		PermissionUsersReadAll,
+		PermissionAccountsReadAll,
+		PermissionLogsReadAll,
+		PermissionLoansReadAll,
+		PermissionReconciliationRead,
+	},
+	RoleUser: {},
+}
+
+// Permission represents the permission granted by roles stored in the database.
+type Permission struct {
+	ID          int    `json:"id,omitempty" gorm:"primaryKey"`
+	Name        string `json:"name,omitempty" gorm:"column:name;size:64;not null;uniqueIndex"`
+	Description string `json:"description,omitempty" gorm:"column:description"`
+
+	mysql.Model
+}
+
+// Role represents the role with its permissions stored in the database.
+type Role struct {
+	ID          int    `json:"id,omitempty" gorm:"primaryKey"`
+	Name        string `json:"name,omitempty" gorm:"column:name;size:32;not null;uniqueIndex"`
+	Description string `json:"description,omitempty" gorm:"column:description"`
+
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// Permissions represents permissions granted to user by all of their roles.
+type Permissions []string
+
+// Has reports whether permission is granted.
+func (p Permissions) Has(permission string) bool {
+	for _, granted := range p {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
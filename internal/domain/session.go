@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// Session represents the user login session stored in the database.
+// Refresh token is stored only as sha256 hash, previous hash is kept to detect reuse of rotated token.
+type Session struct {
+	ID                int        `json:"id,omitempty" gorm:"primaryKey"`
+	UserID            int        `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	RefreshTokenHash  string     `json:"-" gorm:"column:refresh_token_hash;size:64;not null;uniqueIndex"`
+	PreviousTokenHash string     `json:"-" gorm:"column:previous_token_hash;size:64;index"`
+	UserAgent         string     `json:"userAgent,omitempty" gorm:"column:user_agent;size:255"`
+	IP                string     `json:"ip,omitempty" gorm:"column:ip;size:64"`
+	ExpiresAt         time.Time  `json:"expiresAt" gorm:"column:expires_at;not null;index"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty" gorm:"column:revoked_at"`
+	RevokeReason      string     `json:"revokeReason,omitempty" gorm:"column:revoke_reason;size:64"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
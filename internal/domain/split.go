@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// Split represents the bill shared among several users stored in the database.
+// Split is completed once every share is paid into creator bank account.
+type Split struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	CreatorID     int     `json:"creatorId,omitempty" gorm:"column:creator_id;not null;index"`
+	BankAccountID int     `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	Description   string  `json:"description,omitempty" gorm:"column:description"`
+	Total         float64 `json:"total,omitempty" gorm:"column:total;not null"`
+	Method        string  `json:"method,omitempty" gorm:"column:method;type:enum('equal','fixed','percentage');default:'equal'"`
+	Status        string  `json:"status,omitempty" gorm:"column:status;type:enum('OPEN','COMPLETED','CANCELLED');default:'OPEN'"`
+
+	Shares []SplitShare `json:"shares,omitempty" gorm:"foreignKey:SplitID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	Creator     *User        `json:"-" gorm:"foreignKey:CreatorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	BankAccount *BankAccount `json:"-" gorm:"foreignKey:BankAccountID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	mysql.Model
+}
+
+// SplitSortFields lists fields splits can be sorted by.
+var SplitSortFields = SortFields{
+	"id":        "id",
+	"total":     "total",
+	"method":    "method",
+	"status":    "status",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// SplitShare represents the money request for participant share of split stored in the database.
+type SplitShare struct {
+	ID         int        `json:"id,omitempty" gorm:"primaryKey"`
+	SplitID    int        `json:"splitId,omitempty" gorm:"column:split_id;not null;uniqueIndex:idx_split_share"`
+	UserID     int        `json:"userId,omitempty" gorm:"column:user_id;not null;uniqueIndex:idx_split_share;index"`
+	Amount     float64    `json:"amount" gorm:"column:amount;not null"`
+	Percentage float64    `json:"percentage,omitempty" gorm:"column:percentage"`
+	Status     string     `json:"status,omitempty" gorm:"column:status;type:enum('REQUESTED','PAID','CANCELLED');default:'REQUESTED'"`
+	PaymentID  *int64     `json:"paymentId,omitempty" gorm:"column:payment_id"`
+	PaidAt     *time.Time `json:"paidAt,omitempty" gorm:"column:paid_at"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	mysql.Model
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "github.com/Shevchenkko/payment_system/pkg/mysql"
+
+// TopUp represents the bank account top up stored in the database.
+// Opening top up holds balance that account had before top ups were recorded.
+type TopUp struct {
+	ID            int     `json:"id,omitempty" gorm:"primaryKey"`
+	BankAccountID int     `json:"bankAccountId,omitempty" gorm:"column:bank_account_id;not null;index"`
+	Amount        float64 `json:"amount" gorm:"column:amount"`
+	Type          string  `json:"type,omitempty" gorm:"column:type;type:enum('top_up','opening');default:'top_up'"`
+
+	mysql.Model
+}
@@ -11,10 +11,24 @@ type User struct {
 	FullName string `json:"fullName,omitempty"`
 	Email    string `json:"email,omitempty" gorm:"column:email;not null;unique;index"`
 	Password string `json:"password,omitempty"`
+	Language string `json:"language,omitempty" gorm:"column:language;type:enum('uk','en');default:'uk'"`
+	Phone    string `json:"phone,omitempty" gorm:"column:phone;size:32"`
+
+	Roles []Role `json:"roles,omitempty" gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 
 	mysql.Model
 }
 
+// UserSortFields lists fields users can be sorted by.
+var UserSortFields = SortFields{
+	"id":        "id",
+	"fullName":  "full_name",
+	"email":     "email",
+	"status":    "status",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
 // UserToken represents the token model stored in the database.
 type UserToken struct {
 	ID    int    `json:"id,omitempty" gorm:"primaryKey"`
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// WebhookEvents - represents event types webhook can subscribe to.
+var WebhookEvents = []string{"payment.created", "payment.sent", "account.locked", "topup.completed", "payment_link.paid"}
+
+// Webhook represents the user webhook endpoint stored in the database.
+type Webhook struct {
+	ID     int    `json:"id,omitempty" gorm:"primaryKey"`
+	UserID int    `json:"userId,omitempty" gorm:"column:user_id;not null;index"`
+	URL    string `json:"url,omitempty" gorm:"column:url;not null"`
+	Secret string `json:"secret,omitempty" gorm:"column:secret;not null"`
+	Events string `json:"events,omitempty" gorm:"column:events;not null"`
+	Status string `json:"status,omitempty" gorm:"column:status;type:enum('ACTIVE','DISABLED');default:'ACTIVE'"`
+
+	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// Subscribed reports whether webhook receives event.
+func (w *Webhook) Subscribed(event string) bool {
+	for _, e := range strings.Split(w.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery represents the webhook event delivery with its attempts stored in the database.
+type WebhookDelivery struct {
+	ID            int        `json:"id,omitempty" gorm:"primaryKey"`
+	WebhookID     int        `json:"webhookId,omitempty" gorm:"column:webhook_id;not null;index"`
+	Event         string     `json:"event,omitempty" gorm:"column:event;not null"`
+	Payload       string     `json:"payload,omitempty" gorm:"column:payload;type:text"`
+	Status        string     `json:"status,omitempty" gorm:"column:status;type:enum('PENDING','DELIVERED','FAILED');default:'PENDING';index"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`
+	ResponseCode  int        `json:"responseCode,omitempty" gorm:"column:response_code"`
+	LastError     string     `json:"lastError,omitempty" gorm:"column:last_error"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" gorm:"column:next_attempt_at;index"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty" gorm:"column:delivered_at"`
+
+	Webhook *Webhook `json:"-" gorm:"foreignKey:WebhookID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	mysql.Model
+}
+
+// WebhookDeliverySortFields lists fields webhook deliveries can be sorted by.
+var WebhookDeliverySortFields = SortFields{
+	"id":            "id",
+	"event":         "event",
+	"status":        "status",
+	"attempts":      "attempts",
+	"nextAttemptAt": "next_attempt_at",
+	"deliveredAt":   "delivered_at",
+	"createdAt":     "created_at",
+	"updatedAt":     "updated_at",
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	// third party
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	// external
 	"github.com/Shevchenkko/payment_system/pkg/mysql"
@@ -29,54 +31,49 @@ func NewBankAccountsRepo(mysql *mysql.MySQL) *BankAccountsRepo {
 }
 
 // SearchBankAccounts - used to search bank account from the database.
-func (b *BankAccountsRepo) SearchBankAccounts(ctx context.Context, filter *domain.Filter, client string, role string) (*service.SearchBankAccounts, error) {
+// Not zero accountId limits search to that account.
+func (b *BankAccountsRepo) SearchBankAccounts(ctx context.Context, filter *domain.Filter, userId int, all bool, accountId int) (*service.SearchBankAccounts, error) {
 	if filter == nil {
 		filter = new(domain.Filter)
 		filter.Validate()
 	}
 
-	q := b.DB.
+	q := dbWithContext(ctx, b.DB).
 		Table("bank_accounts").
-		Offset((filter.Page - 1) * filter.List).
-		Limit(filter.List).
-		Order(filter.OrderString())
-
-	var bankAccountOutput []service.BankAccountOutput
-	var response *service.SearchBankAccounts
-
-	if role == "admin" {
-		if err := q.Find(&bankAccountOutput).Error; err != nil {
-			return nil, &service.Error{Message: "Bank accounts not found"}
-		}
-	} else {
-		if err := q.Where("client = ?", client).
-			Find(&bankAccountOutput).Error; err != nil {
-			return nil, &service.Error{Message: "Bank accounts not found"}
-		}
+		Where("deleted_at IS NULL")
+	if !all {
+		members := dbWithContext(ctx, b.DB).
+			Model(domain.AccountMember{}).
+			Select("bank_account_id").
+			Where("user_id = ?", userId)
+		q = q.Where("id IN (?)", members)
+	}
+	if accountId != 0 {
+		q = q.Where("id = ?", accountId)
 	}
 
 	var count int64
-	q = b.DB.
-		Table("bank_accounts")
 	if err := q.Count(&count).Error; err != nil {
 		return nil, &service.Error{Message: "Bank accounts not found"}
 	}
 
-	response = &service.SearchBankAccounts{
-		Data: bankAccountOutput,
-		Pagination: &domain.Pagination{
-			Order: filter.OrderString(),
-			Page:  filter.Page,
-			List:  filter.List,
-			Total: &count,
-		},
+	bankAccountOutput, pagination, err := findPage(q, "bank_accounts", filter, count,
+		func(a service.BankAccountOutput) int64 { return int64(a.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Bank accounts not found"}
 	}
 
-	return response, nil
+	return &service.SearchBankAccounts{
+		Data:       bankAccountOutput,
+		Pagination: pagination,
+	}, nil
 }
 
 // CreateBankAccount - used to create bank account in the database.
-func (b *BankAccountsRepo) CreateBankAccount(ctx context.Context, inp *service.BankAccountInput, client string) (*domain.BankAccount, error) {
+func (b *BankAccountsRepo) CreateBankAccount(ctx context.Context, inp *service.BankAccountInput, client *domain.User) (*domain.BankAccount, error) {
 	secretValueBytes, err := bcrypt.GenerateFromPassword([]byte(inp.SecretValue), 14)
 	if err != nil {
 		return nil, err
@@ -84,7 +81,8 @@ func (b *BankAccountsRepo) CreateBankAccount(ctx context.Context, inp *service.B
 
 	card, iban := utils.GenerateNumber(int(inp.ITN))
 	account := &domain.BankAccount{
-		Client:      client,
+		ClientID:    client.ID,
+		Client:      client.FullName,
 		SecretValue: string(secretValueBytes),
 		ITN:         inp.ITN,
 		CardNumber:  card,
@@ -92,10 +90,17 @@ func (b *BankAccountsRepo) CreateBankAccount(ctx context.Context, inp *service.B
 		Balance:     0,
 	}
 
-	err = b.DB.
-		WithContext(ctx).
-		Create(account).
-		Error
+	err = dbWithContext(ctx, b.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(account).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&domain.AccountMember{
+			BankAccountID: account.ID,
+			UserID:        client.ID,
+			Role:          "owner",
+		}).Error
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -103,25 +108,36 @@ func (b *BankAccountsRepo) CreateBankAccount(ctx context.Context, inp *service.B
 	return account, nil
 }
 
-// TopUpBankAccount - used to top up bank account in the database.
-func (b *BankAccountsRepo) TopUpBankAccount(ctx context.Context, inp *service.TopUpBankAccountInput, balance float64) error {
-	err := b.DB.
-		Model(domain.BankAccount{}).
-		Where("card_number = ?", inp.CardNumber).
-		Update("balance", balance).
-		Error
-	if err != nil {
-		return err
-	}
+// TopUpBankAccount - used to top up bank account and record top up in the database.
+func (b *BankAccountsRepo) TopUpBankAccount(ctx context.Context, account *domain.BankAccount, amount float64) error {
+	return dbWithContext(ctx, b.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
 
-	return err
+		res := tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND status <> ?", account.ID, "CLOSED").
+			Update("balance", gorm.Expr("balance + ?", amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Bank account is closed"}
+		}
+
+		return tx.Create(&domain.TopUp{
+			BankAccountID: account.ID,
+			Amount:        amount,
+			Type:          "top_up",
+		}).Error
+	})
 }
 
 // CheckCreditCard - used to check credit card in the database.
 func (b *BankAccountsRepo) CheckCreditCard(ctx context.Context, cardNumber int64) (*domain.BankAccount, error) {
 	var card domain.BankAccount
-	err := b.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, b.DB).
 		Where("card_number = ?", cardNumber).
 		First(&card).
 		Error
@@ -135,11 +151,27 @@ func (b *BankAccountsRepo) CheckCreditCard(ctx context.Context, cardNumber int64
 	return &card, nil
 }
 
+// GetBankAccountByID - used to get bank account by id from the database.
+func (b *BankAccountsRepo) GetBankAccountByID(ctx context.Context, accountId int) (*domain.BankAccount, error) {
+	var account domain.BankAccount
+	err := dbWithContext(ctx, b.DB).
+		Where("id = ?", accountId).
+		First(&account).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Bank account not found"}
+		}
+		return nil, err
+	}
+
+	return &account, nil
+}
+
 // GetInfoByIBAN - used to get credit card info by IBAN in the database.
 func (b *BankAccountsRepo) GetInfoByIBAN(ctx context.Context, IBAN string) (*domain.BankAccount, error) {
 	var card domain.BankAccount
-	err := b.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, b.DB).
 		Where("iban = ?", IBAN).
 		First(&card).
 		Error
@@ -155,7 +187,7 @@ func (b *BankAccountsRepo) GetInfoByIBAN(ctx context.Context, IBAN string) (*dom
 
 // ChangeCreditCardStatus - used to change the credit card in the database.
 func (b *BankAccountsRepo) ChangeCreditCardStatus(ctx context.Context, cardNumber int64, status string) (string, error) {
-	err := b.DB.
+	err := dbWithContext(ctx, b.DB).
 		Model(domain.BankAccount{}).
 		Where("card_number = ?", cardNumber).
 		Update("status", status).
@@ -167,3 +199,207 @@ func (b *BankAccountsRepo) ChangeCreditCardStatus(ctx context.Context, cardNumbe
 
 	return updatedStatus, err
 }
+
+// GetAccountMember - used to get user membership in bank account from the database.
+func (b *BankAccountsRepo) GetAccountMember(ctx context.Context, accountId int, userId int) (*domain.AccountMember, error) {
+	var member domain.AccountMember
+	err := dbWithContext(ctx, b.DB).
+		Where("bank_account_id = ? AND user_id = ?", accountId, userId).
+		First(&member).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "This is not your bank account!"}
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// SearchAccountMembers - used to get all members of bank account from the database.
+func (b *BankAccountsRepo) SearchAccountMembers(ctx context.Context, accountId int) ([]service.AccountMemberOutput, error) {
+	var members []service.AccountMemberOutput
+	err := dbWithContext(ctx, b.DB).
+		Table("account_members m").
+		Select("m.user_id, u.full_name, m.role").
+		Joins("JOIN users u ON u.id = m.user_id").
+		Where("m.bank_account_id = ? AND m.deleted_at IS NULL", accountId).
+		Order("m.id").
+		Find(&members).
+		Error
+	if err != nil {
+		return nil, &service.Error{Message: "Account members not found"}
+	}
+
+	return members, nil
+}
+
+// AddAccountMember - used to grant user access to bank account in the database.
+func (b *BankAccountsRepo) AddAccountMember(ctx context.Context, accountId int, userId int, role string) (*domain.AccountMember, error) {
+	_, err := b.GetAccountMember(ctx, accountId, userId)
+	if err == nil {
+		return nil, &service.Error{Message: "User is already a member of this bank account"}
+	}
+
+	member := &domain.AccountMember{
+		BankAccountID: accountId,
+		UserID:        userId,
+		Role:          role,
+	}
+	err = dbWithContext(ctx, b.DB).
+		Create(member).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// RemoveAccountMember - used to revoke user access to bank account in the database.
+func (b *BankAccountsRepo) RemoveAccountMember(ctx context.Context, accountId int, userId int) error {
+	return dbWithContext(ctx, b.DB).
+		Unscoped().
+		Where("bank_account_id = ? AND user_id = ?", accountId, userId).
+		Delete(&domain.AccountMember{}).
+		Error
+}
+
+// CloseBankAccount - used to close bank account and move its remaining balance.
+func (b *BankAccountsRepo) CloseBankAccount(ctx context.Context, inp *service.CloseBankAccountRepoInput) error {
+	return dbWithContext(ctx, b.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		var account domain.BankAccount
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", inp.Account.ID).
+			First(&account).
+			Error
+		if err != nil {
+			return err
+		}
+		if account.Status == "CLOSED" {
+			return &service.Error{Message: "Bank account is already closed"}
+		}
+
+		// check pending payments and holds
+		holds := []struct {
+			query   *gorm.DB
+			message string
+		}{
+			{tx.Model(domain.Payment{}).Where("from_client_iban = ? AND payment_status = ?", account.IBAN, "prepared"),
+				"Bank account has pending payments"},
+			{tx.Model(domain.Loan{}).Where("bank_account_id = ? AND status IN ?", account.ID, []string{"PENDING", "ACTIVE"}),
+				"Bank account has active loans"},
+			{tx.Model(domain.Deposit{}).Where("source_account_id = ? AND status = ?", account.ID, "OPEN"),
+				"Bank account has open deposits"},
+		}
+		for _, hold := range holds {
+			var count int64
+			if err := hold.query.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return &service.Error{Message: hold.message}
+			}
+		}
+
+		now := time.Now()
+		err = tx.
+			Model(&account).
+			Updates(map[string]interface{}{"status": "CLOSED", "balance": 0, "closed_at": now}).
+			Error
+		if err != nil {
+			return err
+		}
+		if account.Balance <= 0 {
+			return nil
+		}
+
+		// sweep remaining balance
+		if inp.Target != nil {
+			res := tx.
+				Model(domain.BankAccount{}).
+				Where("id = ? AND status <> ?", inp.Target.ID, "CLOSED").
+				Update("balance", gorm.Expr("balance + ?", account.Balance))
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return &service.Error{Message: "Target bank account is closed"}
+			}
+
+			return tx.Create(transferPayment(&account, inp.Target, "Balance transfer on account closure", account.Balance)).Error
+		}
+
+		return tx.Create(&domain.Payment{
+			PaymentStatus:        "sent",
+			FromClientID:         account.ClientID,
+			FromClient:           account.Client,
+			FromClientITN:        account.ITN,
+			FromClientIBAN:       account.IBAN,
+			FromClientCardNumber: account.CardNumber,
+			Description:          "Balance payout on account closure",
+			ToClientIBAN:         inp.PayoutIBAN,
+			ToClient:             inp.PayoutName,
+			OperationAmount:      account.Balance,
+			SentAt:               &now,
+		}).Error
+	})
+}
+
+// MigrateClientIDs - used to set owner user id for accounts created before ownership was keyed by id.
+// Owner membership is used first, otherwise client name must match exactly one user.
+// Accounts with ambiguous client name are skipped and must be assigned manually.
+func (b *BankAccountsRepo) MigrateClientIDs(ctx context.Context) (int64, error) {
+	var migrated int64
+	err := dbWithContext(ctx, b.DB).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`
+			UPDATE bank_accounts b
+			JOIN account_members m ON m.bank_account_id = b.id AND m.role = 'owner' AND m.deleted_at IS NULL
+			SET b.client_id = m.user_id
+			WHERE b.client_id IS NULL`)
+		if res.Error != nil {
+			return res.Error
+		}
+		migrated += res.RowsAffected
+
+		res = tx.Exec(`
+			UPDATE bank_accounts b
+			JOIN users u ON u.full_name = b.client AND u.deleted_at IS NULL
+			SET b.client_id = u.id
+			WHERE b.client_id IS NULL
+				AND (SELECT COUNT(*) FROM users u2 WHERE u2.full_name = b.client AND u2.deleted_at IS NULL) = 1`)
+		if res.Error != nil {
+			return res.Error
+		}
+		migrated += res.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return migrated, nil
+}
+
+// MigrateAccountMembers - used to create owner membership for accounts created before joint accounts.
+func (b *BankAccountsRepo) MigrateAccountMembers(ctx context.Context) (int64, error) {
+	res := dbWithContext(ctx, b.DB).Exec(`
+		INSERT INTO account_members (bank_account_id, user_id, role, created_at, updated_at)
+		SELECT b.id, b.client_id, 'owner', NOW(), NOW()
+		FROM bank_accounts b
+		WHERE b.deleted_at IS NULL
+			AND b.client_id IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM account_members m WHERE m.bank_account_id = b.id)`)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// BudgetsRepo - represents budgets repository.
+type BudgetsRepo struct {
+	*mysql.MySQL
+}
+
+// NewBudgetsRepo - create new instance of budgets repo.
+func NewBudgetsRepo(mysql *mysql.MySQL) *BudgetsRepo {
+	return &BudgetsRepo{mysql}
+}
+
+// CreateBudget - used to create budget in the database.
+func (b *BudgetsRepo) CreateBudget(ctx context.Context, budget *domain.Budget) (*domain.Budget, error) {
+	err := dbWithContext(ctx, b.DB).
+		Create(budget).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// SearchBudgets - used to get user budgets, overall budget first.
+func (b *BudgetsRepo) SearchBudgets(ctx context.Context, userId int) ([]domain.Budget, error) {
+	var budgets []domain.Budget
+	err := dbWithContext(ctx, b.DB).
+		Where("user_id = ?", userId).
+		Order("category, id").
+		Find(&budgets).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+// GetBudgetByID - used to get budget by id from the database.
+func (b *BudgetsRepo) GetBudgetByID(ctx context.Context, budgetId int) (*domain.Budget, error) {
+	var budget domain.Budget
+	err := dbWithContext(ctx, b.DB).
+		Where("id = ?", budgetId).
+		First(&budget).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Budget not found"}
+		}
+		return nil, err
+	}
+
+	return &budget, nil
+}
+
+// GetUserBudget - used to get user budget of category from the database.
+func (b *BudgetsRepo) GetUserBudget(ctx context.Context, userId int, category string) (*domain.Budget, error) {
+	var budget domain.Budget
+	err := dbWithContext(ctx, b.DB).
+		Where("user_id = ? AND category = ?", userId, category).
+		First(&budget).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Budget not found"}
+		}
+		return nil, err
+	}
+
+	return &budget, nil
+}
+
+// UpdateBudgetAmount - used to change budget amount, thresholds are alerted again against new amount.
+func (b *BudgetsRepo) UpdateBudgetAmount(ctx context.Context, budgetId int, amount float64) error {
+	return dbWithContext(ctx, b.DB).
+		Model(domain.Budget{}).
+		Where("id = ?", budgetId).
+		Updates(map[string]interface{}{
+			"amount":  amount,
+			"alerted": 0,
+		}).
+		Error
+}
+
+// UpdateBudgetSpent - used to save budget consumption of month, alerts are reset when month changes.
+// MySQL assigns single table update columns from left to right, so alerted is set before month changes.
+func (b *BudgetsRepo) UpdateBudgetSpent(ctx context.Context, budgetId int, month string, spent float64) error {
+	return dbWithContext(ctx, b.DB).Exec(`
+		UPDATE budgets
+		SET alerted = CASE WHEN month = ? THEN alerted ELSE 0 END, month = ?, spent = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL`, month, month, spent, time.Now(), budgetId).
+		Error
+}
+
+// MarkBudgetAlerted - used to raise alerted threshold of budget in month.
+// Returns false when threshold was already alerted, so every threshold is alerted once.
+func (b *BudgetsRepo) MarkBudgetAlerted(ctx context.Context, budgetId int, month string, threshold int) (bool, error) {
+	res := dbWithContext(ctx, b.DB).
+		Model(domain.Budget{}).
+		Where("id = ? AND month = ? AND alerted < ?", budgetId, month, threshold).
+		Update("alerted", threshold)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// DeleteBudget - used to delete budget from the database, so budget of the category can be set again.
+func (b *BudgetsRepo) DeleteBudget(ctx context.Context, budgetId int) error {
+	return dbWithContext(ctx, b.DB).
+		Unscoped().
+		Delete(&domain.Budget{}, budgetId).
+		Error
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// dateLayout - layout of business day date.
+const dateLayout = "2006-01-02"
+
+// BusinessDaysRepo - represents business days repository.
+type BusinessDaysRepo struct {
+	*mysql.MySQL
+}
+
+// NewBusinessDaysRepo - create new instance of business days repo.
+func NewBusinessDaysRepo(mysql *mysql.MySQL) *BusinessDaysRepo {
+	return &BusinessDaysRepo{mysql}
+}
+
+// GetLastBusinessDay - used to get the latest closed business day, nil when no day was closed yet.
+func (d *BusinessDaysRepo) GetLastBusinessDay(ctx context.Context) (*domain.BusinessDay, error) {
+	var day domain.BusinessDay
+	err := dbWithContext(ctx, d.DB).
+		Order("date DESC").
+		First(&day).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &day, nil
+}
+
+// CloseBusinessDay - used to lock business day, snapshot balances at its end and total payment volume.
+func (d *BusinessDaysRepo) CloseBusinessDay(ctx context.Context, date time.Time) (*domain.BusinessDay, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 1)
+	day := &domain.BusinessDay{Date: start}
+
+	err := dbWithContext(ctx, d.DB).Transaction(func(tx *gorm.DB) error {
+		// closed day row waits for movements that are still recorded into the day
+		if err := tx.Create(day).Error; err != nil {
+			return err
+		}
+
+		// balance at the end of day is stored balance without movements recorded after it
+		res := tx.Exec(`
+			INSERT INTO balance_snapshots (bank_account_id, date, balance, created_at, updated_at)
+			SELECT b.id, ?, ROUND(b.balance
+				- COALESCE((SELECT SUM(t.amount) FROM top_ups t
+					WHERE t.bank_account_id = b.id AND t.created_at >= ? AND t.deleted_at IS NULL), 0)
+				- COALESCE((SELECT SUM(p.operation_amount) FROM payments p
+					WHERE p.to_client_iban = b.iban AND p.payment_status = 'sent' AND p.sent_at >= ? AND p.deleted_at IS NULL), 0)
+				+ COALESCE((SELECT SUM(p.operation_amount) FROM payments p
+					WHERE p.from_client_iban = b.iban AND p.payment_status = 'sent' AND p.sent_at >= ? AND p.deleted_at IS NULL), 0)
+				- COALESCE((SELECT SUM(l.principal) FROM loans l
+					WHERE l.bank_account_id = b.id AND l.disbursed_at >= ? AND l.deleted_at IS NULL), 0)
+				+ COALESCE((SELECT SUM(r.amount) FROM loan_repayments r
+					WHERE r.bank_account_id = b.id AND r.created_at >= ? AND r.deleted_at IS NULL), 0)
+				- COALESCE((SELECT SUM(i.amount) FROM deposit_interests i
+					WHERE i.bank_account_id = b.id AND i.created_at >= ? AND i.deleted_at IS NULL), 0), 2),
+				NOW(), NOW()
+			FROM bank_accounts b
+			WHERE b.created_at < ? AND b.deleted_at IS NULL`,
+			start.Format(dateLayout), end, end, end, end, end, end, end)
+		if res.Error != nil {
+			return res.Error
+		}
+		day.Accounts = res.RowsAffected
+
+		err := tx.Exec(`
+			INSERT INTO daily_payment_volumes (business_day_id, status, count, amount, created_at, updated_at)
+			SELECT ?, p.payment_status, COUNT(*), ROUND(SUM(p.operation_amount), 2), NOW(), NOW()
+			FROM payments p
+			WHERE p.created_at >= ? AND p.created_at < ? AND p.deleted_at IS NULL
+			GROUP BY p.payment_status`,
+			day.ID, start, end).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(day).Update("accounts", day.Accounts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return day, nil
+}
+
+// SearchBalanceSnapshots - used to get bank account balance snapshots between dates.
+func (d *BusinessDaysRepo) SearchBalanceSnapshots(ctx context.Context, accountId int, from time.Time, to time.Time) ([]domain.BalanceSnapshot, error) {
+	var snapshots []domain.BalanceSnapshot
+	err := dbWithContext(ctx, d.DB).
+		Where("bank_account_id = ? AND date >= ? AND date <= ?", accountId, from.Format(dateLayout), to.Format(dateLayout)).
+		Order("date").
+		Find(&snapshots).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// GetBalanceSnapshot - used to get the latest bank account balance snapshot on or before date.
+func (d *BusinessDaysRepo) GetBalanceSnapshot(ctx context.Context, accountId int, date time.Time) (*domain.BalanceSnapshot, error) {
+	var snapshot domain.BalanceSnapshot
+	err := dbWithContext(ctx, d.DB).
+		Where("bank_account_id = ? AND date <= ?", accountId, date.Format(dateLayout)).
+		Order("date DESC").
+		First(&snapshot).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Balance snapshot not found"}
+		}
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// checkDayOpen - fails when business day of moment is closed.
+// Shared lock makes closing of the day wait until movement is committed.
+func checkDayOpen(tx *gorm.DB, at time.Time) error {
+	var count int64
+	err := tx.
+		Model(domain.BusinessDay{}).
+		Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("date = ?", at.Format(dateLayout)).
+		Count(&count).
+		Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &service.Error{Message: "Business day is closed"}
+	}
+
+	return nil
+}
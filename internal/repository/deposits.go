@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// DepositsRepo - represents deposits repository.
+type DepositsRepo struct {
+	*mysql.MySQL
+}
+
+// NewDepositsRepo - create new instance of deposits repo.
+func NewDepositsRepo(mysql *mysql.MySQL) *DepositsRepo {
+	return &DepositsRepo{mysql}
+}
+
+// SearchDepositProducts - used to search deposit products from the database.
+func (d *DepositsRepo) SearchDepositProducts(ctx context.Context, filter *domain.Filter) (*service.SearchDepositProducts, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	q := dbWithContext(ctx, d.DB).
+		Model(domain.DepositProduct{}).
+		Where("status = ?", "ACTIVE")
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Deposit products not found"}
+	}
+
+	products, pagination, err := findPage(q, "deposit_products", filter, count,
+		func(d domain.DepositProduct) int64 { return int64(d.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Deposit products not found"}
+	}
+
+	return &service.SearchDepositProducts{
+		Data:       products,
+		Pagination: pagination,
+	}, nil
+}
+
+// CreateDepositProduct - used to create deposit product in the database.
+func (d *DepositsRepo) CreateDepositProduct(ctx context.Context, inp *service.DepositProductInput) (*domain.DepositProduct, error) {
+	product := &domain.DepositProduct{
+		Name:        inp.Name,
+		TermMonths:  inp.TermMonths,
+		Rate:        inp.Rate,
+		PenaltyRate: inp.PenaltyRate,
+		MinAmount:   inp.MinAmount,
+	}
+
+	err := dbWithContext(ctx, d.DB).
+		Create(product).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetDepositProductByID - used to get deposit product by id from the database.
+func (d *DepositsRepo) GetDepositProductByID(ctx context.Context, productId int) (*domain.DepositProduct, error) {
+	var product domain.DepositProduct
+	err := dbWithContext(ctx, d.DB).
+		Where("id = ?", productId).
+		First(&product).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Deposit product not found"}
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// SearchDeposits - used to search client deposits from the database.
+func (d *DepositsRepo) SearchDeposits(ctx context.Context, filter *domain.Filter, userId int) (*service.SearchDeposits, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	accounts := dbWithContext(ctx, d.DB).
+		Model(domain.AccountMember{}).
+		Select("bank_account_id").
+		Where("user_id = ?", userId)
+
+	q := dbWithContext(ctx, d.DB).
+		Model(domain.Deposit{}).
+		Where("source_account_id IN (?)", accounts)
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Deposits not found"}
+	}
+
+	deposits, pagination, err := findPage(q, "deposits", filter, count,
+		func(d domain.Deposit) int64 { return int64(d.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Deposits not found"}
+	}
+
+	return &service.SearchDeposits{
+		Data:       deposits,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetDepositByID - used to get deposit by id from the database.
+func (d *DepositsRepo) GetDepositByID(ctx context.Context, depositId int) (*domain.Deposit, error) {
+	var deposit domain.Deposit
+	err := dbWithContext(ctx, d.DB).
+		Where("id = ?", depositId).
+		First(&deposit).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Deposit not found"}
+		}
+		return nil, err
+	}
+
+	return &deposit, nil
+}
+
+// GetMaturedDeposits - used to get open deposits which reached maturity date.
+func (d *DepositsRepo) GetMaturedDeposits(ctx context.Context, now time.Time) ([]domain.Deposit, error) {
+	var deposits []domain.Deposit
+	err := dbWithContext(ctx, d.DB).
+		Where("status = ? AND maturity_date <= ?", "OPEN", now).
+		Order("maturity_date").
+		Find(&deposits).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deposits, nil
+}
+
+// OpenDeposit - used to move funds from current account to new deposit account.
+func (d *DepositsRepo) OpenDeposit(ctx context.Context, inp *service.OpenDepositRepoInput) (*domain.Deposit, error) {
+	var deposit *domain.Deposit
+	err := dbWithContext(ctx, d.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		// debit current account
+		res := tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND balance >= ?", inp.Source.ID, inp.Amount).
+			Update("balance", gorm.Expr("balance - ?", inp.Amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return service.ErrInsufficientFunds
+		}
+
+		// create locked deposit account
+		card, iban := utils.GenerateNumber(int(inp.Source.ITN))
+		account := &domain.BankAccount{
+			ClientID:     inp.Source.ClientID,
+			Client:       inp.Source.Client,
+			SecretValue:  inp.Source.SecretValue,
+			ITN:          inp.Source.ITN,
+			CardNumber:   card,
+			IBAN:         iban,
+			Balance:      inp.Amount,
+			Status:       "LOCK",
+			Type:         "DEPOSIT",
+			MaturityDate: &inp.MaturityDate,
+		}
+		if err := tx.Create(account).Error; err != nil {
+			return err
+		}
+
+		// deposit account is shared with members of source account
+		err := tx.Exec(`
+			INSERT INTO account_members (bank_account_id, user_id, role, created_at, updated_at)
+			SELECT ?, user_id, role, NOW(), NOW()
+			FROM account_members
+			WHERE bank_account_id = ? AND deleted_at IS NULL`, account.ID, inp.Source.ID).Error
+		if err != nil {
+			return err
+		}
+
+		deposit = &domain.Deposit{
+			ProductID:       inp.Product.ID,
+			BankAccountID:   account.ID,
+			SourceAccountID: inp.Source.ID,
+			Principal:       inp.Amount,
+			Rate:            inp.Product.Rate,
+			PenaltyRate:     inp.Product.PenaltyRate,
+			TermMonths:      inp.Product.TermMonths,
+			StartDate:       inp.StartDate,
+			MaturityDate:    inp.MaturityDate,
+			Rollover:        inp.Rollover,
+		}
+		if err := tx.Create(deposit).Error; err != nil {
+			return err
+		}
+
+		// keep movement in payments history
+		return tx.Create(transferPayment(inp.Source, account,
+			fmt.Sprintf("Opening of deposit #%d", deposit.ID), inp.Amount)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// RolloverDeposit - used to capitalize interest and start new deposit term.
+func (d *DepositsRepo) RolloverDeposit(ctx context.Context, deposit *domain.Deposit, interest float64, maturityDate time.Time) error {
+	return dbWithContext(ctx, d.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		// move deposit to new term first so concurrent runs cannot roll the same term over twice
+		res := tx.
+			Model(domain.Deposit{}).
+			Where("id = ? AND status = ? AND maturity_date = ?", deposit.ID, "OPEN", deposit.MaturityDate).
+			Updates(map[string]interface{}{
+				"principal":     gorm.Expr("principal + ?", interest),
+				"interest_paid": gorm.Expr("interest_paid + ?", interest),
+				"rollovers":     gorm.Expr("rollovers + 1"),
+				"start_date":    deposit.MaturityDate,
+				"maturity_date": maturityDate,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Deposit is already closed or rolled over"}
+		}
+
+		err := tx.
+			Model(domain.BankAccount{}).
+			Where("id = ?", deposit.BankAccountID).
+			Updates(map[string]interface{}{
+				"balance":       gorm.Expr("balance + ?", interest),
+				"maturity_date": maturityDate,
+			}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return createDepositInterest(tx, deposit, interest)
+	})
+}
+
+// CloseDeposit - used to pay deposit funds with interest back to source account.
+func (d *DepositsRepo) CloseDeposit(ctx context.Context, deposit *domain.Deposit, interest float64, status string) error {
+	return dbWithContext(ctx, d.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		var account, source domain.BankAccount
+		if err := tx.Where("id = ?", deposit.BankAccountID).First(&account).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", deposit.SourceAccountID).First(&source).Error; err != nil {
+			return err
+		}
+
+		// mark deposit closed first so concurrent runs cannot pay it twice
+		now := time.Now()
+		res := tx.
+			Model(domain.Deposit{}).
+			Where("id = ? AND status = ?", deposit.ID, "OPEN").
+			Updates(map[string]interface{}{
+				"status":        status,
+				"interest_paid": gorm.Expr("interest_paid + ?", interest),
+				"closed_at":     now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Deposit is already closed"}
+		}
+
+		if err := createDepositInterest(tx, deposit, interest); err != nil {
+			return err
+		}
+
+		amount := utils.RoundMoney(account.Balance + interest)
+		err := tx.
+			Model(domain.BankAccount{}).
+			Where("id = ?", account.ID).
+			Update("balance", 0).
+			Error
+		if err != nil {
+			return err
+		}
+		err = tx.
+			Model(domain.BankAccount{}).
+			Where("id = ?", source.ID).
+			Update("balance", gorm.Expr("balance + ?", amount)).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(transferPayment(&account, &source,
+			fmt.Sprintf("Payout of deposit #%d", deposit.ID), amount)).Error
+	})
+}
+
+// createDepositInterest - records interest credited to deposit bank account.
+func createDepositInterest(tx *gorm.DB, deposit *domain.Deposit, interest float64) error {
+	if interest == 0 {
+		return nil
+	}
+
+	return tx.Create(&domain.DepositInterest{
+		DepositID:     deposit.ID,
+		BankAccountID: deposit.BankAccountID,
+		Amount:        interest,
+	}).Error
+}
+
+// transferPayment - builds already sent payment between two bank accounts.
+func transferPayment(from *domain.BankAccount, to *domain.BankAccount, description string, amount float64) *domain.Payment {
+	now := time.Now()
+	return &domain.Payment{
+		PaymentStatus:        "sent",
+		FromClientID:         from.ClientID,
+		FromClient:           from.Client,
+		FromClientITN:        from.ITN,
+		FromClientIBAN:       from.IBAN,
+		FromClientCardNumber: from.CardNumber,
+		Description:          description,
+		ToClientIBAN:         to.IBAN,
+		ToClient:             to.Client,
+		OperationAmount:      amount,
+		SentAt:               &now,
+	}
+}
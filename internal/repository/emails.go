@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// EmailsRepo - represents email outbox repository.
+type EmailsRepo struct {
+	*mysql.MySQL
+}
+
+// NewEmailsRepo - create new instance of emails repo.
+func NewEmailsRepo(mysql *mysql.MySQL) *EmailsRepo {
+	return &EmailsRepo{mysql}
+}
+
+// SearchOutboxEmails - used to search outbox emails from the database, all statuses when status is empty.
+func (e *EmailsRepo) SearchOutboxEmails(ctx context.Context, filter *domain.Filter, status string) (*service.SearchOutboxEmails, error) {
+	q := dbWithContext(ctx, e.DB).
+		Model(domain.OutboxEmail{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Emails not found"}
+	}
+
+	emails, pagination, err := findPage(q, "outbox_emails", filter, count,
+		func(o domain.OutboxEmail) int64 { return int64(o.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Emails not found"}
+	}
+
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err = dbWithContext(ctx, e.DB).
+		Model(domain.OutboxEmail{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, &service.Error{Message: "Emails not found"}
+	}
+	counts := map[string]int64{"PENDING": 0, "SENDING": 0, "SENT": 0, "DEAD": 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return &service.SearchOutboxEmails{
+		Data:       emails,
+		Counts:     counts,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetOutboxEmailByID - used to get outbox email by id from the database.
+func (e *EmailsRepo) GetOutboxEmailByID(ctx context.Context, emailId int) (*domain.OutboxEmail, error) {
+	var email domain.OutboxEmail
+	err := dbWithContext(ctx, e.DB).
+		Where("id = ?", emailId).
+		First(&email).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Email not found"}
+		}
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// ClaimDueOutboxEmails - used to claim emails whose next attempt is due, so every email is sent by one worker only.
+// Claimed emails are SENDING until lease ends, emails of worker stopped while sending are claimed again after it.
+func (e *EmailsRepo) ClaimDueOutboxEmails(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.OutboxEmail, error) {
+	var emails []domain.OutboxEmail
+	err := dbWithContext(ctx, e.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?", []string{"PENDING", "SENDING"}, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&emails).
+			Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
+
+		ids := make([]int, 0, len(emails))
+		for _, email := range emails {
+			ids = append(ids, email.ID)
+		}
+		until := now.Add(lease)
+		for i := range emails {
+			emails[i].Status = "SENDING"
+			emails[i].NextAttemptAt = &until
+		}
+
+		return tx.
+			Model(domain.OutboxEmail{}).
+			Where("id IN ?", ids).
+			UpdateColumns(map[string]interface{}{"status": "SENDING", "next_attempt_at": until}).
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// UpdateOutboxEmail - used to save email attempt result in the database.
+func (e *EmailsRepo) UpdateOutboxEmail(ctx context.Context, email *domain.OutboxEmail) error {
+	return dbWithContext(ctx, e.DB).
+		Model(email).
+		Select("status", "attempts", "last_error", "next_attempt_at", "sent_at").
+		Updates(email).
+		Error
+}
@@ -0,0 +1,424 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// LoansRepo - represents loans repository.
+type LoansRepo struct {
+	*mysql.MySQL
+}
+
+// NewLoansRepo - create new instance of loans repo.
+func NewLoansRepo(mysql *mysql.MySQL) *LoansRepo {
+	return &LoansRepo{mysql}
+}
+
+// SearchLoanProducts - used to search loan products from the database.
+func (l *LoansRepo) SearchLoanProducts(ctx context.Context, filter *domain.Filter) (*service.SearchLoanProducts, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	q := dbWithContext(ctx, l.DB).
+		Model(domain.LoanProduct{}).
+		Where("status = ?", "ACTIVE")
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Loan products not found"}
+	}
+
+	products, pagination, err := findPage(q, "loan_products", filter, count,
+		func(l domain.LoanProduct) int64 { return int64(l.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Loan products not found"}
+	}
+
+	return &service.SearchLoanProducts{
+		Data:       products,
+		Pagination: pagination,
+	}, nil
+}
+
+// CreateLoanProduct - used to create loan product in the database.
+func (l *LoansRepo) CreateLoanProduct(ctx context.Context, inp *service.LoanProductInput) (*domain.LoanProduct, error) {
+	product := &domain.LoanProduct{
+		Name:          inp.Name,
+		Rate:          inp.Rate,
+		TermMonths:    inp.TermMonths,
+		RepaymentType: inp.RepaymentType,
+		PenaltyFee:    inp.PenaltyFee,
+		MinAmount:     inp.MinAmount,
+		MaxAmount:     inp.MaxAmount,
+	}
+
+	err := dbWithContext(ctx, l.DB).
+		Create(product).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetLoanProductByID - used to get loan product by id from the database.
+func (l *LoansRepo) GetLoanProductByID(ctx context.Context, productId int) (*domain.LoanProduct, error) {
+	var product domain.LoanProduct
+	err := dbWithContext(ctx, l.DB).
+		Where("id = ?", productId).
+		First(&product).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Loan product not found"}
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// SearchLoans - used to search loans from the database.
+func (l *LoansRepo) SearchLoans(ctx context.Context, filter *domain.Filter, userId int, all bool) (*service.SearchLoans, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	q := dbWithContext(ctx, l.DB).
+		Model(domain.Loan{})
+	if !all {
+		accounts := dbWithContext(ctx, l.DB).
+			Model(domain.AccountMember{}).
+			Select("bank_account_id").
+			Where("user_id = ?", userId)
+		q = q.Where("bank_account_id IN (?)", accounts)
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Loans not found"}
+	}
+
+	loans, pagination, err := findPage(q, "loans", filter, count,
+		func(l domain.Loan) int64 { return int64(l.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Loans not found"}
+	}
+
+	return &service.SearchLoans{
+		Data:       loans,
+		Pagination: pagination,
+	}, nil
+}
+
+// CreateLoan - used to create loan application in the database.
+func (l *LoansRepo) CreateLoan(ctx context.Context, loan *domain.Loan) (*domain.Loan, error) {
+	err := dbWithContext(ctx, l.DB).
+		Create(loan).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// GetLoanByID - used to get loan by id from the database.
+func (l *LoansRepo) GetLoanByID(ctx context.Context, loanId int) (*domain.Loan, error) {
+	var loan domain.Loan
+	err := dbWithContext(ctx, l.DB).
+		Where("id = ?", loanId).
+		First(&loan).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Loan not found"}
+		}
+		return nil, err
+	}
+
+	return &loan, nil
+}
+
+// RejectLoan - used to reject pending loan application.
+func (l *LoansRepo) RejectLoan(ctx context.Context, loanId int) error {
+	res := dbWithContext(ctx, l.DB).
+		Model(domain.Loan{}).
+		Where("id = ? AND status = ?", loanId, "PENDING").
+		Update("status", "REJECTED")
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "Loan is not pending"}
+	}
+
+	return nil
+}
+
+// DisburseLoan - used to activate loan, credit principal and store amortization schedule.
+func (l *LoansRepo) DisburseLoan(ctx context.Context, loan *domain.Loan, schedule []domain.LoanInstallment) error {
+	return dbWithContext(ctx, l.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		res := tx.
+			Model(domain.Loan{}).
+			Where("id = ? AND status = ?", loan.ID, "PENDING").
+			Updates(map[string]interface{}{
+				"status":       "ACTIVE",
+				"outstanding":  loan.Principal,
+				"disbursed_at": now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Loan is not pending"}
+		}
+
+		err := tx.
+			Model(domain.BankAccount{}).
+			Where("id = ?", loan.BankAccountID).
+			Update("balance", gorm.Expr("balance + ?", loan.Principal)).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&schedule).Error
+	})
+}
+
+// GetLoanSchedule - used to get loan amortization schedule from the database.
+func (l *LoansRepo) GetLoanSchedule(ctx context.Context, loanId int) ([]domain.LoanInstallment, error) {
+	var schedule []domain.LoanInstallment
+	err := dbWithContext(ctx, l.DB).
+		Where("loan_id = ?", loanId).
+		Order("number").
+		Find(&schedule).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetDueInstallments - used to get unpaid installments of active loans due before now.
+func (l *LoansRepo) GetDueInstallments(ctx context.Context, now time.Time) ([]domain.LoanInstallment, error) {
+	active := dbWithContext(ctx, l.DB).
+		Model(domain.Loan{}).
+		Select("id").
+		Where("status = ?", "ACTIVE")
+
+	var installments []domain.LoanInstallment
+	err := dbWithContext(ctx, l.DB).
+		Where("status IN (?) AND due_date <= ? AND loan_id IN (?)", []string{"SCHEDULED", "OVERDUE"}, now, active).
+		Order("due_date, number").
+		Find(&installments).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return installments, nil
+}
+
+// PayInstallment - used to debit installment from loan bank account.
+func (l *LoansRepo) PayInstallment(ctx context.Context, loan *domain.Loan, installment *domain.LoanInstallment) error {
+	return dbWithContext(ctx, l.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		amount := installment.AmountDue()
+		if err := debitLoanAccount(tx, loan, amount); err != nil {
+			return err
+		}
+		if err := settleInstallments(tx, loan, []domain.LoanInstallment{*installment}); err != nil {
+			return err
+		}
+
+		err := tx.
+			Model(domain.Loan{}).
+			Where("id = ?", loan.ID).
+			Update("outstanding", gorm.Expr("outstanding - ?", installment.Principal)).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return closeLoanIfRepaid(tx, loan.ID)
+	})
+}
+
+// MarkInstallmentOverdue - used to mark installment overdue and charge penalty fee once.
+func (l *LoansRepo) MarkInstallmentOverdue(ctx context.Context, installment *domain.LoanInstallment, penaltyFee float64) error {
+	return dbWithContext(ctx, l.DB).
+		Model(domain.LoanInstallment{}).
+		Where("id = ? AND status = ?", installment.ID, "SCHEDULED").
+		Updates(map[string]interface{}{
+			"status":      "OVERDUE",
+			"penalty_fee": gorm.Expr("penalty_fee + ?", penaltyFee),
+		}).
+		Error
+}
+
+// PrepayLoan - used to settle overdue installments and repay part of principal early.
+func (l *LoansRepo) PrepayLoan(ctx context.Context, inp *service.PrepayLoanRepoInput) error {
+	return dbWithContext(ctx, l.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		total := inp.Principal
+		repaid := inp.Principal
+		for _, installment := range inp.Overdue {
+			total += installment.AmountDue()
+			repaid += installment.Principal
+		}
+		if err := debitLoanAccount(tx, inp.Loan, total); err != nil {
+			return err
+		}
+		if err := settleInstallments(tx, inp.Loan, inp.Overdue); err != nil {
+			return err
+		}
+
+		if inp.Principal > 0 {
+			err := tx.Create(&domain.LoanRepayment{
+				LoanID:        inp.Loan.ID,
+				BankAccountID: inp.Loan.BankAccountID,
+				Type:          "prepayment",
+				Amount:        inp.Principal,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			// replace rest of schedule with recalculated one
+			err = tx.
+				Where("loan_id = ? AND status = ?", inp.Loan.ID, "SCHEDULED").
+				Delete(&domain.LoanInstallment{}).
+				Error
+			if err != nil {
+				return err
+			}
+			if len(inp.Schedule) > 0 {
+				if err := tx.Create(&inp.Schedule).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		err := tx.
+			Model(domain.Loan{}).
+			Where("id = ?", inp.Loan.ID).
+			Update("outstanding", gorm.Expr("GREATEST(outstanding - ?, 0)", repaid)).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return closeLoanIfRepaid(tx, inp.Loan.ID)
+	})
+}
+
+// debitLoanAccount - debits loan bank account if it is active and has enough funds.
+func debitLoanAccount(tx *gorm.DB, loan *domain.Loan, amount float64) error {
+	res := tx.
+		Model(domain.BankAccount{}).
+		Where("id = ? AND status = ? AND balance >= ?", loan.BankAccountID, "ACTIVE", amount).
+		Update("balance", gorm.Expr("balance - ?", amount))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return service.ErrInsufficientFunds
+	}
+
+	return nil
+}
+
+// settleInstallments - marks installments paid and records repayments.
+func settleInstallments(tx *gorm.DB, loan *domain.Loan, installments []domain.LoanInstallment) error {
+	now := time.Now()
+	for _, installment := range installments {
+		res := tx.
+			Model(domain.LoanInstallment{}).
+			Where("id = ? AND status <> ?", installment.ID, "PAID").
+			Updates(map[string]interface{}{
+				"status":  "PAID",
+				"paid_at": now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Installment is already paid"}
+		}
+
+		err := tx.Create(&domain.LoanRepayment{
+			LoanID:        loan.ID,
+			BankAccountID: loan.BankAccountID,
+			InstallmentID: installment.ID,
+			Type:          "installment",
+			Amount:        installment.AmountDue(),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// closeLoanIfRepaid - marks loan paid off when nothing is left to pay.
+func closeLoanIfRepaid(tx *gorm.DB, loanId int) error {
+	var unpaid int64
+	err := tx.
+		Model(domain.LoanInstallment{}).
+		Where("loan_id = ? AND status <> ?", loanId, "PAID").
+		Count(&unpaid).
+		Error
+	if err != nil {
+		return err
+	}
+	if unpaid > 0 {
+		return nil
+	}
+
+	return tx.
+		Model(domain.Loan{}).
+		Where("id = ?", loanId).
+		Updates(map[string]interface{}{
+			"status":      "PAID_OFF",
+			"outstanding": 0,
+			"closed_at":   time.Now(),
+		}).
+		Error
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// MerchantsRepo - represents merchants repository.
+type MerchantsRepo struct {
+	*mysql.MySQL
+}
+
+// NewMerchantsRepo - create new instance of merchants repo.
+func NewMerchantsRepo(mysql *mysql.MySQL) *MerchantsRepo {
+	return &MerchantsRepo{mysql}
+}
+
+// CreateMerchant - used to create merchant in the database.
+func (m *MerchantsRepo) CreateMerchant(ctx context.Context, merchant *domain.Merchant) (*domain.Merchant, error) {
+	err := dbWithContext(ctx, m.DB).
+		Create(merchant).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+// SearchMerchants - used to get user merchants from the database.
+func (m *MerchantsRepo) SearchMerchants(ctx context.Context, userId int) ([]domain.Merchant, error) {
+	var merchants []domain.Merchant
+	err := dbWithContext(ctx, m.DB).
+		Where("user_id = ?", userId).
+		Order("id").
+		Find(&merchants).
+		Error
+	if err != nil {
+		return nil, &service.Error{Message: "Merchants not found"}
+	}
+
+	return merchants, nil
+}
+
+// GetMerchantByID - used to get merchant by id from the database.
+func (m *MerchantsRepo) GetMerchantByID(ctx context.Context, merchantId int) (*domain.Merchant, error) {
+	var merchant domain.Merchant
+	err := dbWithContext(ctx, m.DB).
+		Where("id = ?", merchantId).
+		First(&merchant).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Merchant not found"}
+		}
+		return nil, err
+	}
+
+	return &merchant, nil
+}
+
+// CreateAPIKey - used to create merchant api key in the database.
+func (m *MerchantsRepo) CreateAPIKey(ctx context.Context, key *domain.MerchantAPIKey) (*domain.MerchantAPIKey, error) {
+	err := dbWithContext(ctx, m.DB).
+		Create(key).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// SearchAPIKeys - used to get merchant api keys from the database.
+func (m *MerchantsRepo) SearchAPIKeys(ctx context.Context, merchantId int) ([]domain.MerchantAPIKey, error) {
+	var keys []domain.MerchantAPIKey
+	err := dbWithContext(ctx, m.DB).
+		Where("merchant_id = ?", merchantId).
+		Order("id").
+		Find(&keys).
+		Error
+	if err != nil {
+		return nil, &service.Error{Message: "API keys not found"}
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByID - used to get merchant api key by id from the database.
+func (m *MerchantsRepo) GetAPIKeyByID(ctx context.Context, keyId int) (*domain.MerchantAPIKey, error) {
+	var key domain.MerchantAPIKey
+	err := dbWithContext(ctx, m.DB).
+		Where("id = ?", keyId).
+		First(&key).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "API key not found"}
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// GetAPIKeyByPrefix - used to get merchant api key with its merchant and merchant owner by key prefix from the database.
+func (m *MerchantsRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.MerchantAPIKey, error) {
+	var key domain.MerchantAPIKey
+	err := dbWithContext(ctx, m.DB).
+		Preload("Merchant.User").
+		Where("prefix = ?", prefix).
+		First(&key).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "API key not found"}
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// RevokeAPIKey - used to revoke merchant api key in the database.
+func (m *MerchantsRepo) RevokeAPIKey(ctx context.Context, keyId int, at time.Time) error {
+	res := dbWithContext(ctx, m.DB).
+		Model(domain.MerchantAPIKey{}).
+		Where("id = ? AND revoked_at IS NULL", keyId).
+		Update("revoked_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "API key is already revoked"}
+	}
+
+	return nil
+}
+
+// RotateAPIKey - used to revoke merchant api key and create its replacement in one transaction.
+func (m *MerchantsRepo) RotateAPIKey(ctx context.Context, keyId int, key *domain.MerchantAPIKey, at time.Time) (*domain.MerchantAPIKey, error) {
+	err := dbWithContext(ctx, m.DB).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Model(domain.MerchantAPIKey{}).
+			Where("id = ? AND revoked_at IS NULL", keyId).
+			Update("revoked_at", at)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "API key is already revoked"}
+		}
+
+		return tx.Create(key).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// TouchAPIKey - used to set api key last usage time in the database.
+func (m *MerchantsRepo) TouchAPIKey(ctx context.Context, keyId int, at time.Time) error {
+	return dbWithContext(ctx, m.DB).
+		Model(domain.MerchantAPIKey{}).
+		Where("id = ?", keyId).
+		Update("last_used_at", at).
+		Error
+}
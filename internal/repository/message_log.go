@@ -24,11 +24,11 @@ func NewMessageLogsRepo(mysql *mysql.MySQL) *MessageLogsRepo {
 // CreateMessageLog - used to create message log in the database.
 func (m *MessageLogsRepo) CreateMessageLog(ctx context.Context, inp *service.MessageLogInput) (*domain.MessageLog, error) {
 	message := &domain.MessageLog{
-		Client:  inp.Client,
-		Message: inp.MessageLog,
+		ClientID: inp.ClientID,
+		Client:   inp.Client,
+		Message:  inp.MessageLog,
 	}
-	err := m.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, m.DB).
 		Create(message).
 		Error
 	if err != nil {
@@ -39,47 +39,51 @@ func (m *MessageLogsRepo) CreateMessageLog(ctx context.Context, inp *service.Mes
 }
 
 // Search logs - used to search log from the database.
-func (m *MessageLogsRepo) SearchLogs(ctx context.Context, filter *domain.Filter, client string, role string) (*service.SearchLogs, error) {
+func (m *MessageLogsRepo) SearchLogs(ctx context.Context, filter *domain.Filter, userId int, all bool) (*service.SearchLogs, error) {
 	if filter == nil {
 		filter = new(domain.Filter)
 		filter.Validate()
 	}
 
-	q := m.DB.
+	q := dbWithContext(ctx, m.DB).
 		Table("message_logs").
-		Offset((filter.Page - 1) * filter.List).
-		Limit(filter.List).
-		Order(filter.OrderString())
-
-	var logOutput []domain.MessageLog
-	var response *service.SearchLogs
-	if role == "admin" {
-		if err := q.Find(&logOutput).Error; err != nil {
-			return nil, &service.Error{Message: "Logs not found"}
-		}
-	} else {
-		if err := q.Where("client = ?", client).
-			Find(&logOutput).Error; err != nil {
-			return nil, &service.Error{Message: "Logs not found"}
-		}
+		Where("deleted_at IS NULL")
+	if !all {
+		q = q.Where("client_id = ?", userId)
 	}
 
 	var count int64
-	q = m.DB.
-		Table("message_logs")
 	if err := q.Count(&count).Error; err != nil {
 		return nil, &service.Error{Message: "Logs not found"}
 	}
 
-	response = &service.SearchLogs{
-		Data: logOutput,
-		Pagination: &domain.Pagination{
-			Order: filter.OrderString(),
-			Page:  filter.Page,
-			List:  filter.List,
-			Total: &count,
-		},
+	logOutput, pagination, err := findPage(q, "message_logs", filter, count,
+		func(l domain.MessageLog) int64 { return int64(l.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Logs not found"}
+	}
+
+	return &service.SearchLogs{
+		Data:       logOutput,
+		Pagination: pagination,
+	}, nil
+}
+
+// MigrateClientIDs - used to set user id for logs created before ownership was keyed by id.
+// Logs whose client name matches more than one user are skipped.
+func (m *MessageLogsRepo) MigrateClientIDs(ctx context.Context) (int64, error) {
+	res := dbWithContext(ctx, m.DB).Exec(`
+		UPDATE message_logs l
+		JOIN users u ON u.full_name = l.client AND u.deleted_at IS NULL
+		SET l.client_id = u.id
+		WHERE l.client_id IS NULL
+			AND (SELECT COUNT(*) FROM users u2 WHERE u2.full_name = l.client AND u2.deleted_at IS NULL) = 1`)
+	if res.Error != nil {
+		return 0, res.Error
 	}
 
-	return response, nil
+	return res.RowsAffected, nil
 }
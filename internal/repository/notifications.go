@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// NotificationsRepo - represents notifications repository.
+type NotificationsRepo struct {
+	*mysql.MySQL
+}
+
+// NewNotificationsRepo - create new instance of notifications repo.
+func NewNotificationsRepo(mysql *mysql.MySQL) *NotificationsRepo {
+	return &NotificationsRepo{mysql}
+}
+
+// CreateNotification - used to create inbox notification and queue its email and channel deliveries in one transaction.
+// Notification and email are skipped when nil.
+func (n *NotificationsRepo) CreateNotification(ctx context.Context, notification *domain.Notification, email *domain.OutboxEmail, deliveries []domain.NotificationDelivery) error {
+	return dbWithContext(ctx, n.DB).Transaction(func(tx *gorm.DB) error {
+		if notification != nil {
+			if err := tx.Create(notification).Error; err != nil {
+				return err
+			}
+		}
+		if email != nil {
+			if err := tx.Create(email).Error; err != nil {
+				return err
+			}
+		}
+		if len(deliveries) > 0 {
+			if err := tx.Create(&deliveries).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SearchNotifications - used to search user inbox notifications from the database, newest first by default.
+func (n *NotificationsRepo) SearchNotifications(ctx context.Context, filter *domain.Filter, userId int, unread bool) (*service.SearchNotifications, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+	if len(filter.SortBy) == 0 {
+		filter.SortBy = []string{"created_at desc", "id desc"}
+	}
+
+	q := dbWithContext(ctx, n.DB).
+		Table("notifications").
+		Where("deleted_at IS NULL AND user_id = ?", userId)
+	if unread {
+		q = q.Where("read_at IS NULL")
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Notifications not found"}
+	}
+
+	var unreadCount int64
+	err := dbWithContext(ctx, n.DB).
+		Model(domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userId).
+		Count(&unreadCount).
+		Error
+	if err != nil {
+		return nil, &service.Error{Message: "Notifications not found"}
+	}
+
+	notifications, pagination, err := findPage(q, "notifications", filter, count,
+		func(n domain.Notification) int64 { return int64(n.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Notifications not found"}
+	}
+
+	return &service.SearchNotifications{
+		Data:       notifications,
+		Unread:     unreadCount,
+		Pagination: pagination,
+	}, nil
+}
+
+// MarkNotificationsRead - used to mark user notifications read, all unread when ids are empty.
+func (n *NotificationsRepo) MarkNotificationsRead(ctx context.Context, userId int, ids []int, at time.Time) (int64, error) {
+	q := dbWithContext(ctx, n.DB).
+		Model(domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userId)
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+
+	res := q.Update("read_at", at)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// GetNotificationPreferences - used to get channels user chose for events.
+func (n *NotificationsRepo) GetNotificationPreferences(ctx context.Context, userId int) ([]domain.NotificationPreference, error) {
+	var preferences []domain.NotificationPreference
+	err := dbWithContext(ctx, n.DB).
+		Where("user_id = ?", userId).
+		Find(&preferences).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// SaveNotificationPreferences - used to create or update user preferences of events in one transaction.
+func (n *NotificationsRepo) SaveNotificationPreferences(ctx context.Context, preferences []domain.NotificationPreference) error {
+	return dbWithContext(ctx, n.DB).Transaction(func(tx *gorm.DB) error {
+		for _, p := range preferences {
+			var preference domain.NotificationPreference
+			err := tx.
+				Where(domain.NotificationPreference{UserID: p.UserID, Event: p.Event}).
+				Assign(map[string]interface{}{
+					"email": p.Email,
+					"inbox": p.Inbox,
+					"sms":   p.SMS,
+					"push":  p.Push,
+				}).
+				FirstOrCreate(&preference).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SaveDeviceToken - used to register device token of user, token registered by other user moves to this one.
+func (n *NotificationsRepo) SaveDeviceToken(ctx context.Context, device *domain.DeviceToken) (*domain.DeviceToken, error) {
+	var saved domain.DeviceToken
+	err := dbWithContext(ctx, n.DB).
+		Where(domain.DeviceToken{Token: device.Token}).
+		Assign(map[string]interface{}{
+			"user_id":  device.UserID,
+			"platform": device.Platform,
+		}).
+		FirstOrCreate(&saved).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+// GetDeviceTokens - used to get device tokens of user.
+func (n *NotificationsRepo) GetDeviceTokens(ctx context.Context, userId int) ([]domain.DeviceToken, error) {
+	var devices []domain.DeviceToken
+	err := dbWithContext(ctx, n.DB).
+		Where("user_id = ?", userId).
+		Order("id").
+		Find(&devices).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// DeleteDeviceToken - used to delete device token of user from the database, so token can be registered again.
+func (n *NotificationsRepo) DeleteDeviceToken(ctx context.Context, userId int, deviceId int) error {
+	res := dbWithContext(ctx, n.DB).
+		Unscoped().
+		Where("id = ? AND user_id = ?", deviceId, userId).
+		Delete(&domain.DeviceToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "Device not found"}
+	}
+
+	return nil
+}
+
+// SearchNotificationDeliveries - used to search sms and push deliveries of user from the database.
+func (n *NotificationsRepo) SearchNotificationDeliveries(ctx context.Context, filter *domain.Filter, userId int, channel string) (*service.SearchNotificationDeliveries, error) {
+	q := dbWithContext(ctx, n.DB).
+		Model(domain.NotificationDelivery{}).
+		Where("user_id = ?", userId)
+	if channel != "" {
+		q = q.Where("channel = ?", channel)
+	}
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Notification deliveries not found"}
+	}
+
+	deliveries, pagination, err := findPage(q, "notification_deliveries", filter, count,
+		func(n domain.NotificationDelivery) int64 { return int64(n.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Notification deliveries not found"}
+	}
+
+	return &service.SearchNotificationDeliveries{
+		Data:       deliveries,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetDueNotificationDeliveries - used to get pending deliveries whose next attempt is due.
+func (n *NotificationsRepo) GetDueNotificationDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.NotificationDelivery, error) {
+	var deliveries []domain.NotificationDelivery
+	err := dbWithContext(ctx, n.DB).
+		Where("status = ? AND next_attempt_at <= ?", "PENDING", now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// UpdateNotificationDelivery - used to save delivery attempt result in the database.
+func (n *NotificationsRepo) UpdateNotificationDelivery(ctx context.Context, delivery *domain.NotificationDelivery) error {
+	return dbWithContext(ctx, n.DB).
+		Model(delivery).
+		Select("status", "attempts", "last_error", "next_attempt_at", "sent_at").
+		Updates(delivery).
+		Error
+}
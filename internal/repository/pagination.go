@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	// third party
+	"gorm.io/gorm"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// sortTerm - represents one column of listing order.
+type sortTerm struct {
+	column string
+	desc   bool
+}
+
+// findPage - finds one page of rows of table and fills its pagination.
+// Rows are ordered by filter sort fields, then by created_at and id, so every row has stable position.
+// With filter cursor rows are taken after or before the cursor row (keyset paging), otherwise by page offset.
+// id returns primary key of row, used to build cursors of the page boundaries.
+func findPage[T any](q *gorm.DB, table string, filter *domain.Filter, count int64, id func(T) int64) ([]T, *domain.Pagination, error) {
+	terms := sortTerms(table, filter.SortBy)
+
+	var cursor *domain.Cursor
+	if filter.Cursor != "" {
+		var err error
+		cursor, err = domain.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, nil, &service.Error{Message: "Invalid cursor"}
+		}
+
+		cond, args, err := keysetCondition(q, table, terms, cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		q = q.Where(cond, args...)
+	} else {
+		q = q.Offset((filter.Page - 1) * filter.List)
+	}
+
+	// previous page is read backwards
+	reverse := cursor != nil && cursor.Prev
+	order := make([]string, 0, len(terms))
+	for _, t := range terms {
+		desc := t.desc != reverse
+		if desc {
+			order = append(order, t.column+" desc")
+		} else {
+			order = append(order, t.column+" asc")
+		}
+	}
+
+	// one extra row tells whether there is more
+	var rows []T
+	if err := q.Order(strings.Join(order, ",")).Limit(filter.List + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	more := len(rows) > filter.List
+	if more {
+		rows = rows[:filter.List]
+	}
+	if reverse {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	pagination := &domain.Pagination{
+		Order: filter.OrderString(),
+		Page:  filter.Page,
+		List:  filter.List,
+		Total: &count,
+	}
+	if len(rows) > 0 {
+		first := domain.Cursor{ID: id(rows[0]), Prev: true}
+		last := domain.Cursor{ID: id(rows[len(rows)-1])}
+		switch {
+		case cursor == nil:
+			if more {
+				pagination.NextCursor = last.Encode()
+			}
+			if filter.Page > 1 {
+				pagination.PrevCursor = first.Encode()
+			}
+		case cursor.Prev:
+			if more {
+				pagination.PrevCursor = first.Encode()
+			}
+			pagination.NextCursor = last.Encode()
+		default:
+			if more {
+				pagination.NextCursor = last.Encode()
+			}
+			pagination.PrevCursor = first.Encode()
+		}
+	}
+
+	return rows, pagination, nil
+}
+
+// sortTerms - returns listing order with created_at and id tie breakers.
+func sortTerms(table string, sortBy []string) []sortTerm {
+	terms := make([]sortTerm, 0, len(sortBy)+2)
+	seen := map[string]bool{}
+	for _, s := range sortBy {
+		tokens := strings.Fields(s)
+		if len(tokens) == 0 {
+			continue
+		}
+		column := tokens[0]
+		if !strings.Contains(column, ".") {
+			column = table + "." + column
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		terms = append(terms, sortTerm{
+			column: column,
+			desc:   len(tokens) == 2 && strings.EqualFold(tokens[1], "desc"),
+		})
+	}
+	for _, column := range []string{table + ".created_at", table + ".id"} {
+		if !seen[column] {
+			terms = append(terms, sortTerm{column: column})
+		}
+	}
+	return terms
+}
+
+// keysetCondition - returns condition selecting rows after cursor row in listing order
+// (or before it for previous page): (a > ?) OR (a = ? AND b > ?) OR ...
+// MySQL sorts NULL before any value, so nullable columns are compared with it in mind.
+func keysetCondition(q *gorm.DB, table string, terms []sortTerm, cursor *domain.Cursor) (string, []interface{}, error) {
+	columns := make([]string, len(terms))
+	for i, t := range terms {
+		columns[i] = fmt.Sprintf("%s AS k%d", t.column, i)
+	}
+
+	// sort values of cursor row
+	row := map[string]interface{}{}
+	err := q.
+		Session(&gorm.Session{NewDB: true}).
+		Table(table).
+		Select(columns).
+		Where(table+".id = ?", cursor.ID).
+		Take(&row).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, &service.Error{Message: "Invalid cursor"}
+		}
+		return "", nil, err
+	}
+
+	var (
+		or   []string
+		args []interface{}
+	)
+	for i, t := range terms {
+		and := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			cond, arg := keysetEqual(terms[j].column, row[fmt.Sprintf("k%d", j)])
+			and = append(and, cond)
+			args = append(args, arg...)
+		}
+		cond, arg := keysetBeyond(t.column, row[fmt.Sprintf("k%d", i)], t.desc != cursor.Prev)
+		if cond == "" {
+			// nothing sorts before NULL
+			continue
+		}
+		and = append(and, cond)
+		args = append(args, arg...)
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	if len(or) == 0 {
+		return "1 = 0", nil, nil
+	}
+
+	return "(" + strings.Join(or, " OR ") + ")", args, nil
+}
+
+// keysetEqual - returns condition selecting rows with the same column value as cursor row.
+func keysetEqual(column string, value interface{}) (string, []interface{}) {
+	if value == nil {
+		return column + " IS NULL", nil
+	}
+	return column + " = ?", []interface{}{value}
+}
+
+// keysetBeyond - returns condition selecting rows with column value after cursor row value
+// (or before it when less is set), empty when no value can be before NULL.
+func keysetBeyond(column string, value interface{}, less bool) (string, []interface{}) {
+	switch {
+	case value == nil && less:
+		return "", nil
+	case value == nil:
+		return column + " IS NOT NULL", nil
+	case less:
+		return "(" + column + " < ? OR " + column + " IS NULL)", []interface{}{value}
+	default:
+		return column + " > ?", []interface{}{value}
+	}
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// PaymentLinksRepo - represents payment links repository.
+type PaymentLinksRepo struct {
+	*mysql.MySQL
+}
+
+// NewPaymentLinksRepo - create new instance of payment links repo.
+func NewPaymentLinksRepo(mysql *mysql.MySQL) *PaymentLinksRepo {
+	return &PaymentLinksRepo{mysql}
+}
+
+// CreatePaymentLink - used to create payment link in the database.
+func (p *PaymentLinksRepo) CreatePaymentLink(ctx context.Context, link *domain.PaymentLink) (*domain.PaymentLink, error) {
+	err := dbWithContext(ctx, p.DB).
+		Create(link).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// SearchPaymentLinks - used to search merchant payment links from the database.
+func (p *PaymentLinksRepo) SearchPaymentLinks(ctx context.Context, filter *domain.Filter, merchantId int) (*service.SearchPaymentLinks, error) {
+	q := dbWithContext(ctx, p.DB).
+		Model(domain.PaymentLink{}).
+		Where("merchant_id = ?", merchantId)
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Payment links not found"}
+	}
+
+	links, pagination, err := findPage(q, "payment_links", filter, count,
+		func(p domain.PaymentLink) int64 { return int64(p.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Payment links not found"}
+	}
+
+	return &service.SearchPaymentLinks{
+		Data:       links,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetPaymentLinkByID - used to get payment link by id from the database.
+func (p *PaymentLinksRepo) GetPaymentLinkByID(ctx context.Context, linkId int) (*domain.PaymentLink, error) {
+	var link domain.PaymentLink
+	err := dbWithContext(ctx, p.DB).
+		Where("id = ?", linkId).
+		First(&link).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Payment link not found"}
+		}
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// GetPaymentLinkByCode - used to get payment link with its merchant by public code from the database.
+func (p *PaymentLinksRepo) GetPaymentLinkByCode(ctx context.Context, code string) (*domain.PaymentLink, error) {
+	var link domain.PaymentLink
+	err := dbWithContext(ctx, p.DB).
+		Preload("Merchant").
+		Where("code = ?", code).
+		First(&link).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Payment link not found"}
+		}
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// DisablePaymentLink - used to disable active payment link in the database.
+func (p *PaymentLinksRepo) DisablePaymentLink(ctx context.Context, linkId int) error {
+	res := dbWithContext(ctx, p.DB).
+		Model(domain.PaymentLink{}).
+		Where("id = ? AND status = ?", linkId, "ACTIVE").
+		Update("status", "DISABLED")
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "Payment link is not active"}
+	}
+
+	return nil
+}
+
+// PayPaymentLink - used to pay payment link: marks link used, moves funds to settlement account
+// and records sent payment in one transaction.
+func (p *PaymentLinksRepo) PayPaymentLink(ctx context.Context, inp *service.PayPaymentLinkRepoInput) (*domain.Payment, error) {
+	payment := transferPayment(inp.Payer, inp.Settlement, inp.Link.Description, inp.Link.Amount)
+	payment.ToClient = inp.Link.Merchant.Name
+	payment.PaymentLinkID = &inp.Link.ID
+
+	err := dbWithContext(ctx, p.DB).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := checkDayOpen(tx, now); err != nil {
+			return err
+		}
+
+		// single use link is completed by the first payment
+		updates := map[string]interface{}{"paid_count": gorm.Expr("paid_count + 1")}
+		query := tx.
+			Model(domain.PaymentLink{}).
+			Where("id = ? AND status = ?", inp.Link.ID, "ACTIVE").
+			Where("expires_at IS NULL OR expires_at > ?", now)
+		if !inp.Link.MultiUse {
+			updates["status"] = "COMPLETED"
+			query = query.Where("paid_count = 0")
+		}
+		res := query.Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Payment link can no longer be paid"}
+		}
+
+		res = tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND status = ? AND balance >= ?", inp.Payer.ID, "ACTIVE", inp.Link.Amount).
+			Update("balance", gorm.Expr("balance - ?", inp.Link.Amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return service.ErrInsufficientFunds
+		}
+
+		res = tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND status <> ?", inp.Settlement.ID, "CLOSED").
+			Update("balance", gorm.Expr("balance + ?", inp.Link.Amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Merchant bank account is closed"}
+		}
+
+		return tx.Create(payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
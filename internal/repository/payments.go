@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	// third party
 	"gorm.io/gorm"
@@ -27,48 +29,113 @@ func NewPaymentsRepo(mysql *mysql.MySQL) *PaymentsRepo {
 }
 
 // SearchPayments - used to search payment from the database.
-func (p *PaymentsRepo) SearchPayments(ctx context.Context, filter *domain.Filter, client string) (*service.SearchPayments, error) {
+// Outgoing payments are sent from accounts the user is member of, incoming are sent to them.
+func (p *PaymentsRepo) SearchPayments(ctx context.Context, filter *domain.Filter, userId int, inp *service.PaymentSearchInput) (*service.SearchPayments, error) {
 	if filter == nil {
 		filter = new(domain.Filter)
 		filter.Validate()
 	}
+	if inp == nil {
+		inp = &service.PaymentSearchInput{Direction: "all"}
+	}
+
+	// payments from every account the user is member of
+	accounts := dbWithContext(ctx, p.DB).
+		Table("account_members").
+		Select("bank_accounts.iban").
+		Joins("JOIN bank_accounts ON bank_accounts.id = account_members.bank_account_id").
+		Where("account_members.user_id = ? AND account_members.deleted_at IS NULL", userId)
+
+	// outgoing side: counterparty is recipient
+	outgoing := p.DB.Where("from_client_id = ? OR from_client_iban IN (?)", userId, accounts)
+	if inp.AccountIBAN != "" {
+		outgoing = outgoing.Where("from_client_iban = ?", inp.AccountIBAN)
+	}
+	if inp.Counterparty != "" {
+		outgoing = outgoing.Where("to_client_iban = ? OR to_client LIKE ?", inp.Counterparty, likePattern(inp.Counterparty))
+	}
+
+	// incoming side: counterparty is sender, prepared payments are not visible to recipient
+	incoming := p.DB.Where("to_client_iban IN (?) AND payment_status = ?", accounts, "sent")
+	if inp.AccountIBAN != "" {
+		incoming = incoming.Where("to_client_iban = ?", inp.AccountIBAN)
+	}
+	if inp.Counterparty != "" {
+		incoming = incoming.Where("from_client_iban = ? OR from_client LIKE ?", inp.Counterparty, likePattern(inp.Counterparty))
+	}
 
-	q := p.DB.
+	q := dbWithContext(ctx, p.DB).
 		Table("payments").
-		Offset((filter.Page - 1) * filter.List).
-		Limit(filter.List).
-		Order(filter.OrderString())
-
-	var paymentOutput []service.PaymentOutput
-	var response *service.SearchPayments
-	if err := q.Where("from_client = ?", client).
-		Find(&paymentOutput).Error; err != nil {
-		return nil, &service.Error{Message: "Payments not found"}
+		Where("deleted_at IS NULL")
+	switch inp.Direction {
+	case "outgoing":
+		q = q.Where(outgoing)
+	case "incoming":
+		q = q.Where(incoming)
+	default:
+		q = q.Where(p.DB.Where(outgoing).Or(incoming))
+	}
+	if inp.From != nil {
+		q = q.Where("created_at >= ?", *inp.From)
+	}
+	if inp.To != nil {
+		q = q.Where("created_at < ?", *inp.To)
+	}
+	if inp.MinAmount != nil {
+		q = q.Where("operation_amount >= ?", *inp.MinAmount)
+	}
+	if inp.MaxAmount != nil {
+		q = q.Where("operation_amount <= ?", *inp.MaxAmount)
+	}
+	if inp.Status != "" {
+		q = q.Where("payment_status = ?", inp.Status)
+	}
+	if inp.Description != "" {
+		q = q.Where("description LIKE ?", likePattern(inp.Description))
+	}
+	if inp.Category != "" {
+		q = q.Where(outgoing).Where("category = ?", inp.Category)
 	}
 
 	var count int64
-	q = p.DB.
-		Table("payments")
 	if err := q.Count(&count).Error; err != nil {
 		return nil, &service.Error{Message: "Payments not found"}
 	}
 
-	response = &service.SearchPayments{
-		Data: paymentOutput,
-		Pagination: &domain.Pagination{
-			Order: filter.OrderString(),
-			Page:  filter.Page,
-			List:  filter.List,
-			Total: &count,
-		},
+	paymentOutput, pagination, err := findPage(
+		q.Select("payments.*, CASE WHEN from_client_id = ? OR from_client_iban IN (?) THEN 'outgoing' ELSE 'incoming' END AS direction", userId, accounts),
+		"payments", filter, count,
+		func(p service.PaymentOutput) int64 { return p.ID })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Payments not found"}
+	}
+
+	// category is sender's own
+	for i := range paymentOutput {
+		if paymentOutput[i].Direction == "incoming" {
+			paymentOutput[i].Category = nil
+		}
 	}
 
-	return response, nil
+	return &service.SearchPayments{
+		Data:       paymentOutput,
+		Pagination: pagination,
+	}, nil
+}
+
+// likePattern - returns LIKE pattern matching value as substring.
+func likePattern(value string) string {
+	escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+	return "%" + escaped + "%"
 }
 
 // CreatePayment - used to create payment in the database.
 func (p *PaymentsRepo) CreatePayment(ctx context.Context, inp *service.PaymentInput, client *domain.BankAccount) (*domain.Payment, error) {
 	payment := &domain.Payment{
+		FromClientID:         client.ClientID,
 		FromClient:           client.Client,
 		FromClientITN:        client.ITN,
 		FromClientIBAN:       client.IBAN,
@@ -79,8 +146,7 @@ func (p *PaymentsRepo) CreatePayment(ctx context.Context, inp *service.PaymentIn
 		OperationAmount:      inp.OperationAmount,
 	}
 
-	err := p.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, p.DB).
 		Create(payment).
 		Error
 	if err != nil {
@@ -93,8 +159,7 @@ func (p *PaymentsRepo) CreatePayment(ctx context.Context, inp *service.PaymentIn
 // GetPaymentByID - used to get payment by id from the database.
 func (p *PaymentsRepo) GetPaymentByID(ctx context.Context, paymentId int64) (*domain.Payment, error) {
 	var payment domain.Payment
-	err := p.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, p.DB).
 		Where("id = ?", paymentId).
 		First(&payment).
 		Error
@@ -108,14 +173,55 @@ func (p *PaymentsRepo) GetPaymentByID(ctx context.Context, paymentId int64) (*do
 	return &payment, nil
 }
 
-// SentPayment - used to sent payment.
-func (p *PaymentsRepo) SentPayment(ctx context.Context, paymentId int64) (string, error) {
+// SentPayment - used to sent payment, debit sender and credit internal recipient.
+func (p *PaymentsRepo) SentPayment(ctx context.Context, payment *domain.Payment, recipient *domain.BankAccount) (string, error) {
 	status := "sent"
-	err := p.DB.
-		Model(domain.Payment{}).
-		Where("id = ?", paymentId).
-		Update("payment_status", status).
-		Error
+	err := dbWithContext(ctx, p.DB).Transaction(func(tx *gorm.DB) error {
+		if err := checkDayOpen(tx, time.Now()); err != nil {
+			return err
+		}
+
+		res := tx.
+			Model(domain.Payment{}).
+			Where("id = ? AND payment_status = ?", payment.ID, "prepared").
+			Updates(map[string]interface{}{
+				"payment_status": status,
+				"sent_at":        time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Payment has already been sent"}
+		}
+
+		res = tx.
+			Model(domain.BankAccount{}).
+			Where("iban = ? AND status = ? AND balance >= ?", payment.FromClientIBAN, "ACTIVE", payment.OperationAmount).
+			Update("balance", gorm.Expr("balance - ?", payment.OperationAmount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return service.ErrInsufficientFunds
+		}
+
+		if recipient == nil {
+			return nil
+		}
+		res = tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND status <> ?", recipient.ID, "CLOSED").
+			Update("balance", gorm.Expr("balance + ?", payment.OperationAmount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Recipient bank account is closed"}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return "", err
 	}
@@ -123,3 +229,30 @@ func (p *PaymentsRepo) SentPayment(ctx context.Context, paymentId int64) (string
 
 	return updatedStatus, err
 }
+
+// MigrateClientIDs - used to set sender user id for payments created before ownership was keyed by id.
+func (p *PaymentsRepo) MigrateClientIDs(ctx context.Context) (int64, error) {
+	res := dbWithContext(ctx, p.DB).Exec(`
+		UPDATE payments p
+		JOIN bank_accounts b ON b.iban = p.from_client_iban
+		SET p.from_client_id = b.client_id
+		WHERE p.from_client_id IS NULL AND b.client_id IS NOT NULL`)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// MigrateSentAt - used to set sent time of payments sent before it was stored, last update is the closest known time.
+func (p *PaymentsRepo) MigrateSentAt(ctx context.Context) (int64, error) {
+	res := dbWithContext(ctx, p.DB).
+		Model(domain.Payment{}).
+		Where("payment_status = ? AND sent_at IS NULL", "sent").
+		UpdateColumn("sent_at", gorm.Expr("updated_at"))
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// expectedBalancesQuery - recomputes balance of every bank account from recorded money movements.
+const expectedBalancesQuery = `
+	SELECT b.id AS bank_account_id, b.card_number, b.iban, b.client, b.status, b.balance AS actual,
+		COALESCE((SELECT SUM(t.amount) FROM top_ups t
+			WHERE t.bank_account_id = b.id AND t.deleted_at IS NULL), 0)
+		+ COALESCE((SELECT SUM(p.operation_amount) FROM payments p
+			WHERE p.to_client_iban = b.iban AND p.payment_status = 'sent' AND p.deleted_at IS NULL), 0)
+		- COALESCE((SELECT SUM(p.operation_amount) FROM payments p
+			WHERE p.from_client_iban = b.iban AND p.payment_status = 'sent' AND p.deleted_at IS NULL), 0)
+		+ COALESCE((SELECT SUM(l.principal) FROM loans l
+			WHERE l.bank_account_id = b.id AND l.disbursed_at IS NOT NULL AND l.deleted_at IS NULL), 0)
+		- COALESCE((SELECT SUM(r.amount) FROM loan_repayments r
+			WHERE r.bank_account_id = b.id AND r.deleted_at IS NULL), 0)
+		+ COALESCE((SELECT SUM(d.interest_paid) FROM deposits d
+			WHERE d.bank_account_id = b.id AND d.deleted_at IS NULL), 0) AS expected
+	FROM bank_accounts b
+	WHERE b.deleted_at IS NULL`
+
+// ReconciliationRepo - represents balance reconciliation repository.
+type ReconciliationRepo struct {
+	*mysql.MySQL
+}
+
+// NewReconciliationRepo - create new instance of reconciliation repo.
+func NewReconciliationRepo(mysql *mysql.MySQL) *ReconciliationRepo {
+	return &ReconciliationRepo{mysql}
+}
+
+// GetBalanceChecks - used to get stored and expected balance of every bank account.
+func (r *ReconciliationRepo) GetBalanceChecks(ctx context.Context) ([]service.AccountBalanceCheck, error) {
+	var checks []service.AccountBalanceCheck
+	err := dbWithContext(ctx, r.DB).
+		Raw(expectedBalancesQuery).
+		Scan(&checks).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return checks, nil
+}
+
+// CreateReconciliationRun - used to save reconciliation run and freeze accounts marked as frozen.
+func (r *ReconciliationRepo) CreateReconciliationRun(ctx context.Context, run *domain.ReconciliationRun, discrepancies []domain.Discrepancy) error {
+	return dbWithContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+
+		for i := range discrepancies {
+			discrepancy := &discrepancies[i]
+			if discrepancy.Frozen {
+				err := tx.
+					Model(domain.BankAccount{}).
+					Where("id = ? AND status IN ?", discrepancy.BankAccountID, []string{"ACTIVE", "LOCK"}).
+					Update("status", "FROZEN").
+					Error
+				if err != nil {
+					return err
+				}
+			}
+			discrepancy.RunID = run.ID
+		}
+		if len(discrepancies) == 0 {
+			return nil
+		}
+
+		return tx.Create(&discrepancies).Error
+	})
+}
+
+// GetReconciliationRun - used to get reconciliation run by id, or the latest one when id is zero.
+func (r *ReconciliationRepo) GetReconciliationRun(ctx context.Context, runId int) (*domain.ReconciliationRun, error) {
+	var run domain.ReconciliationRun
+	q := dbWithContext(ctx, r.DB)
+	if runId != 0 {
+		q = q.Where("id = ?", runId)
+	}
+	err := q.
+		Order("id DESC").
+		First(&run).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Reconciliation run not found"}
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// GetDiscrepancies - used to get discrepancies found by reconciliation run.
+func (r *ReconciliationRepo) GetDiscrepancies(ctx context.Context, runId int) ([]domain.Discrepancy, error) {
+	var discrepancies []domain.Discrepancy
+	err := dbWithContext(ctx, r.DB).
+		Where("run_id = ?", runId).
+		Order("ABS(difference) DESC").
+		Find(&discrepancies).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+// MigrateFrozenAccounts - used once to move accounts locked by reconciliation before frozen status existed
+// to frozen status, so that their owners cannot unlock them.
+func (r *ReconciliationRepo) MigrateFrozenAccounts(ctx context.Context) (int64, error) {
+	res := dbWithContext(ctx, r.DB).Exec(`
+		UPDATE bank_accounts
+		SET status = 'FROZEN'
+		WHERE status = 'LOCK' AND id IN (SELECT bank_account_id FROM discrepancies WHERE frozen = 1)`)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// CreateOpeningBalances - used once to record balances that accounts had before top ups were recorded,
+// so that reconciliation starts from the stored balances.
+func (r *ReconciliationRepo) CreateOpeningBalances(ctx context.Context) (int64, error) {
+	res := dbWithContext(ctx, r.DB).Exec(`
+		INSERT INTO top_ups (bank_account_id, amount, type, created_at, updated_at)
+		SELECT x.bank_account_id, ROUND(x.actual - x.expected, 2), 'opening', NOW(), NOW()
+		FROM (` + expectedBalancesQuery + `) x
+		WHERE NOT EXISTS (SELECT 1 FROM top_ups t WHERE t.bank_account_id = x.bank_account_id AND t.type = 'opening')`)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
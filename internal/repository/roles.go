@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	// third party
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// RolesRepo - represents roles repository.
+type RolesRepo struct {
+	*mysql.MySQL
+}
+
+// NewRolesRepo - create new instance of roles repo.
+func NewRolesRepo(mysql *mysql.MySQL) *RolesRepo {
+	return &RolesRepo{mysql}
+}
+
+// SearchRoles - used to get every role with its permissions from the database.
+func (r *RolesRepo) SearchRoles(ctx context.Context) ([]domain.Role, error) {
+	var roles []domain.Role
+	err := dbWithContext(ctx, r.DB).
+		Preload("Permissions", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Order("id").
+		Find(&roles).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// GetUserRoles - used to get roles of user from the database.
+func (r *RolesRepo) GetUserRoles(ctx context.Context, userId int) ([]domain.Role, error) {
+	var user domain.User
+	err := dbWithContext(ctx, r.DB).
+		Preload("Roles", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("id = ?", userId).
+		First(&user).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "User not found"}
+		}
+		return nil, err
+	}
+
+	return user.Roles, nil
+}
+
+// GetUserPermissions - used to get permissions granted to user by all of their roles from the database.
+func (r *RolesRepo) GetUserPermissions(ctx context.Context, userId int) (domain.Permissions, error) {
+	var permissions []string
+	err := dbWithContext(ctx, r.DB).
+		Model(domain.Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Where("user_roles.user_id = ?", userId).
+		Distinct().
+		Pluck("permissions.name", &permissions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// SetUserRoles - used to replace roles of user in the database.
+func (r *RolesRepo) SetUserRoles(ctx context.Context, userId int, names []string) ([]domain.Role, error) {
+	var roles []domain.Role
+	err := dbWithContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Where("name IN ?", names).
+			Order("id").
+			Find(&roles).
+			Error
+		if err != nil {
+			return err
+		}
+		if len(roles) != len(names) {
+			return &service.Error{Message: "Role not found"}
+		}
+
+		var user domain.User
+		err = tx.
+			Where("id = ?", userId).
+			First(&user).
+			Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &service.Error{Message: "User not found"}
+			}
+			return err
+		}
+
+		return tx.Model(&user).Association("Roles").Replace(roles)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// CountRoleUsers - used to count users granted role in the database.
+// Counted grants stay locked until the end of transaction, so concurrent changes of the role wait for it.
+func (r *RolesRepo) CountRoleUsers(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := dbWithContext(ctx, r.DB).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(domain.User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.name = ?", role).
+		Count(&count).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// MigrateRoles - used to seed permissions and roles, grant admin every permission and give users without roles the user one.
+// Default permissions of seeded roles are granted again on every start, permissions granted by hand are kept.
+func (r *RolesRepo) MigrateRoles(ctx context.Context) (int64, error) {
+	var count int64
+	err := dbWithContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		permissions := make([]domain.Permission, 0, len(domain.PermissionDescriptions))
+		for _, name := range sortedKeys(domain.PermissionDescriptions) {
+			permissions = append(permissions, domain.Permission{Name: name, Description: domain.PermissionDescriptions[name]})
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions)
+		if res.Error != nil {
+			return res.Error
+		}
+		count += res.RowsAffected
+
+		roles := make([]domain.Role, 0, len(domain.RoleDescriptions))
+		for _, name := range sortedKeys(domain.RoleDescriptions) {
+			roles = append(roles, domain.Role{Name: name, Description: domain.RoleDescriptions[name]})
+		}
+		res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&roles)
+		if res.Error != nil {
+			return res.Error
+		}
+		count += res.RowsAffected
+
+		// load rows created before as well
+		var storedPermissions []domain.Permission
+		if err := tx.Find(&storedPermissions).Error; err != nil {
+			return err
+		}
+		var storedRoles []domain.Role
+		if err := tx.Find(&storedRoles).Error; err != nil {
+			return err
+		}
+
+		for i := range storedRoles {
+			role := &storedRoles[i]
+			granted, ok := domain.RolePermissions[role.Name]
+			if !ok {
+				continue
+			}
+
+			var grant []domain.Permission
+			for _, p := range storedPermissions {
+				if role.Name == domain.RoleAdmin || domain.Permissions(granted).Has(p.Name) {
+					grant = append(grant, p)
+				}
+			}
+			if len(grant) > 0 {
+				if err := tx.Model(role).Association("Permissions").Append(grant); err != nil {
+					return err
+				}
+			}
+
+			if role.Name == domain.RoleUser {
+				res = tx.Exec(
+					"INSERT INTO user_roles (user_id, role_id) "+
+						"SELECT users.id, ? FROM users "+
+						"WHERE NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)",
+					role.ID)
+				if res.Error != nil {
+					return res.Error
+				}
+				count += res.RowsAffected
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// sortedKeys - returns keys of map in order, so seeded rows get the same ids on every database.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// SpendingRepo - represents spending categorization and analytics repository.
+type SpendingRepo struct {
+	*mysql.MySQL
+}
+
+// NewSpendingRepo - create new instance of spending repo.
+func NewSpendingRepo(mysql *mysql.MySQL) *SpendingRepo {
+	return &SpendingRepo{mysql}
+}
+
+// CategorizePayments - used to categorize uncategorized sent payments from accounts user is member of.
+// Category is taken from recipient merchant, then from the first matching keyword rule of user
+// or default rule, transfers between user accounts are "transfers" and the rest "other".
+// Columns are updated without updated_at, categorization does not change payment.
+func (s *SpendingRepo) CategorizePayments(ctx context.Context, userId int) (int64, error) {
+	ibans, err := s.memberIBANs(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	if len(ibans) == 0 {
+		return 0, nil
+	}
+
+	rules, err := s.SearchCategoryRules(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	rules = append(rules, domain.DefaultCategoryRules...)
+
+	var count int64
+	err = dbWithContext(ctx, s.DB).Transaction(func(tx *gorm.DB) error {
+		uncategorized := func() *gorm.DB {
+			return tx.
+				Model(domain.Payment{}).
+				Where("category IS NULL AND payment_status = ? AND from_client_iban IN ?", "sent", ibans)
+		}
+
+		// recipient merchant
+		merchant := tx.
+			Table("merchants").
+			Select("merchants.category").
+			Joins("JOIN bank_accounts ON bank_accounts.id = merchants.settlement_account_id").
+			Where("bank_accounts.iban = payments.to_client_iban AND merchants.category <> '' AND merchants.deleted_at IS NULL").
+			Limit(1)
+		res := uncategorized().
+			Where("EXISTS (?)", merchant).
+			UpdateColumns(map[string]interface{}{
+				"category":        gorm.Expr("(?)", merchant),
+				"category_source": "merchant",
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		count += res.RowsAffected
+
+		// keyword rules, the first matching wins
+		cases := make([]string, 0, len(rules))
+		matches := make([]string, 0, len(rules))
+		var caseArgs, matchArgs []interface{}
+		for _, rule := range rules {
+			pattern := likePattern(rule.Keyword)
+			cases = append(cases, "WHEN description LIKE ? THEN ?")
+			caseArgs = append(caseArgs, pattern, rule.Category)
+			matches = append(matches, "description LIKE ?")
+			matchArgs = append(matchArgs, pattern)
+		}
+		res = uncategorized().
+			Where("("+strings.Join(matches, " OR ")+")", matchArgs...).
+			UpdateColumns(map[string]interface{}{
+				"category":        gorm.Expr("CASE "+strings.Join(cases, " ")+" END", caseArgs...),
+				"category_source": "rule",
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		count += res.RowsAffected
+
+		res = uncategorized().
+			UpdateColumns(map[string]interface{}{
+				"category":        gorm.Expr("CASE WHEN to_client_iban IN ? THEN ? ELSE ? END", ibans, "transfers", "other"),
+				"category_source": "auto",
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		count += res.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetUncategorizedPaymentSenders - used to get users who sent payments not categorized yet.
+func (s *SpendingRepo) GetUncategorizedPaymentSenders(ctx context.Context) ([]int, error) {
+	var users []int
+	err := dbWithContext(ctx, s.DB).
+		Model(domain.Payment{}).
+		Where("category IS NULL AND payment_status = ? AND from_client_id IS NOT NULL", "sent").
+		Distinct().
+		Pluck("from_client_id", &users).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// ResetPaymentCategories - used to clear categories assigned by rules to payments sent from accounts user is member of,
+// so they are categorized again. Categories chosen by user are kept.
+func (s *SpendingRepo) ResetPaymentCategories(ctx context.Context, userId int) error {
+	ibans, err := s.memberIBANs(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if len(ibans) == 0 {
+		return nil
+	}
+
+	return dbWithContext(ctx, s.DB).
+		Model(domain.Payment{}).
+		Where("from_client_iban IN ? AND category_source IN ?", ibans, []string{"rule", "auto"}).
+		UpdateColumns(map[string]interface{}{
+			"category":        nil,
+			"category_source": nil,
+		}).
+		Error
+}
+
+// SetPaymentCategory - used to set category chosen by user, empty category returns payment to automatic categorization.
+func (s *SpendingRepo) SetPaymentCategory(ctx context.Context, paymentId int64, category string) error {
+	values := map[string]interface{}{
+		"category":        nil,
+		"category_source": nil,
+	}
+	if category != "" {
+		values["category"] = category
+		values["category_source"] = "user"
+	}
+
+	return dbWithContext(ctx, s.DB).
+		Model(domain.Payment{}).
+		Where("id = ?", paymentId).
+		UpdateColumns(values).
+		Error
+}
+
+// CreateCategoryRule - used to create category rule in the database.
+func (s *SpendingRepo) CreateCategoryRule(ctx context.Context, rule *domain.CategoryRule) (*domain.CategoryRule, error) {
+	err := dbWithContext(ctx, s.DB).
+		Create(rule).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// SearchCategoryRules - used to get category rules of user in order they are applied.
+func (s *SpendingRepo) SearchCategoryRules(ctx context.Context, userId int) ([]domain.CategoryRule, error) {
+	var rules []domain.CategoryRule
+	err := dbWithContext(ctx, s.DB).
+		Where("user_id = ?", userId).
+		Order("id").
+		Find(&rules).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetCategoryRuleByID - used to get category rule by id from the database.
+func (s *SpendingRepo) GetCategoryRuleByID(ctx context.Context, ruleId int) (*domain.CategoryRule, error) {
+	var rule domain.CategoryRule
+	err := dbWithContext(ctx, s.DB).
+		Where("id = ?", ruleId).
+		First(&rule).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Category rule not found"}
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// DeleteCategoryRule - used to delete category rule from the database.
+func (s *SpendingRepo) DeleteCategoryRule(ctx context.Context, ruleId int) error {
+	return dbWithContext(ctx, s.DB).
+		Delete(&domain.CategoryRule{}, ruleId).
+		Error
+}
+
+// SumSpending - used to sum sent payments from accounts user is member of in period,
+// grouped by category, month (YYYY-MM) or counterparty IBAN, largest totals first, months in order.
+func (s *SpendingRepo) SumSpending(ctx context.Context, userId int, inp *service.SpendingQuery) ([]service.SpendingTotal, error) {
+	ibans, err := s.memberIBANs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if inp.AccountIBAN != "" {
+		ibans = filterIBAN(ibans, inp.AccountIBAN)
+	}
+	if len(ibans) == 0 {
+		return nil, nil
+	}
+
+	var key, name, order string
+	switch inp.GroupBy {
+	case "category":
+		key, name, order = "category", "''", "total DESC"
+	case "month":
+		key, name, order = "DATE_FORMAT(created_at, '%Y-%m')", "''", "`key`"
+	case "counterparty":
+		key, name, order = "to_client_iban", "MAX(to_client)", "total DESC"
+	default:
+		return nil, fmt.Errorf("unknown spending group %q", inp.GroupBy)
+	}
+
+	q := dbWithContext(ctx, s.DB).
+		Model(domain.Payment{}).
+		Select(key+" AS `key`, "+name+" AS name, SUM(operation_amount) AS total, COUNT(*) AS count").
+		Where("from_client_iban IN ? AND payment_status = ?", ibans, "sent").
+		Where("created_at >= ? AND created_at < ?", inp.From, inp.To).
+		Group(key).
+		Order(order)
+	if inp.Limit > 0 {
+		q = q.Limit(inp.Limit)
+	}
+
+	var totals []service.SpendingTotal
+	if err := q.Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// memberIBANs - returns IBANs of accounts user is member of.
+func (s *SpendingRepo) memberIBANs(ctx context.Context, userId int) ([]string, error) {
+	var ibans []string
+	err := dbWithContext(ctx, s.DB).
+		Table("account_members").
+		Joins("JOIN bank_accounts ON bank_accounts.id = account_members.bank_account_id").
+		Where("account_members.user_id = ? AND account_members.deleted_at IS NULL", userId).
+		Pluck("bank_accounts.iban", &ibans).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ibans, nil
+}
+
+// filterIBAN - returns iban if it is in ibans.
+func filterIBAN(ibans []string, iban string) []string {
+	for _, i := range ibans {
+		if i == iban {
+			return []string{iban}
+		}
+	}
+	return nil
+}
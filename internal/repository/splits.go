@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// SplitsRepo - represents split bills repository.
+type SplitsRepo struct {
+	*mysql.MySQL
+}
+
+// NewSplitsRepo - create new instance of splits repo.
+func NewSplitsRepo(mysql *mysql.MySQL) *SplitsRepo {
+	return &SplitsRepo{mysql}
+}
+
+// CreateSplit - used to create split with its shares in the database.
+func (s *SplitsRepo) CreateSplit(ctx context.Context, split *domain.Split) (*domain.Split, error) {
+	err := dbWithContext(ctx, s.DB).
+		Create(split).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return split, nil
+}
+
+// SearchSplits - used to search splits user created or takes part in from the database.
+func (s *SplitsRepo) SearchSplits(ctx context.Context, filter *domain.Filter, userId int) (*service.SearchSplits, error) {
+	q := dbWithContext(ctx, s.DB).
+		Model(domain.Split{}).
+		Where("creator_id = ? OR id IN (?)", userId, dbWithContext(ctx, s.DB).
+			Table("split_shares").
+			Select("split_id").
+			Where("user_id = ? AND deleted_at IS NULL", userId))
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Splits not found"}
+	}
+
+	splits, pagination, err := findPage(q.Preload("Shares"), "splits", filter, count,
+		func(split domain.Split) int64 { return int64(split.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Splits not found"}
+	}
+
+	return &service.SearchSplits{
+		Data:       splits,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetSplitByID - used to get split with its shares by id from the database.
+func (s *SplitsRepo) GetSplitByID(ctx context.Context, splitId int) (*domain.Split, error) {
+	var split domain.Split
+	err := dbWithContext(ctx, s.DB).
+		Preload("Shares").
+		Where("id = ?", splitId).
+		First(&split).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Split not found"}
+		}
+		return nil, err
+	}
+
+	return &split, nil
+}
+
+// GetSplitShareByID - used to get split share by id from the database.
+func (s *SplitsRepo) GetSplitShareByID(ctx context.Context, shareId int) (*domain.SplitShare, error) {
+	var share domain.SplitShare
+	err := dbWithContext(ctx, s.DB).
+		Where("id = ?", shareId).
+		First(&share).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Split share not found"}
+		}
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+// PaySplitShare - used to pay split share into creator account in one transaction.
+// Split is completed by the last paid share.
+func (s *SplitsRepo) PaySplitShare(ctx context.Context, inp *service.PaySplitShareRepoInput) (*domain.Payment, error) {
+	payment := transferPayment(inp.Payer, inp.Recipient,
+		fmt.Sprintf("Share of split #%d: %s", inp.Split.ID, inp.Split.Description), inp.Share.Amount)
+
+	err := dbWithContext(ctx, s.DB).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := checkDayOpen(tx, now); err != nil {
+			return err
+		}
+
+		res := tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND status = ? AND balance >= ?", inp.Payer.ID, "ACTIVE", inp.Share.Amount).
+			Update("balance", gorm.Expr("balance - ?", inp.Share.Amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return service.ErrInsufficientFunds
+		}
+
+		res = tx.
+			Model(domain.BankAccount{}).
+			Where("id = ? AND status <> ?", inp.Recipient.ID, "CLOSED").
+			Update("balance", gorm.Expr("balance + ?", inp.Share.Amount))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Recipient bank account is closed"}
+		}
+
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		res = tx.
+			Model(domain.SplitShare{}).
+			Where("id = ? AND status = ?", inp.Share.ID, "REQUESTED").
+			Updates(map[string]interface{}{"status": "PAID", "payment_id": payment.ID, "paid_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Split share is not requested"}
+		}
+
+		// complete split when no share is left unpaid
+		return tx.
+			Model(domain.Split{}).
+			Where("id = ? AND status = ?", inp.Split.ID, "OPEN").
+			Where("NOT EXISTS (?)", tx.
+				Table("split_shares").
+				Select("1").
+				Where("split_id = ? AND status = ? AND deleted_at IS NULL", inp.Split.ID, "REQUESTED")).
+			Update("status", "COMPLETED").
+			Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CancelSplit - used to cancel open split and its unpaid shares in the database.
+func (s *SplitsRepo) CancelSplit(ctx context.Context, splitId int) error {
+	return dbWithContext(ctx, s.DB).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Model(domain.Split{}).
+			Where("id = ? AND status = ?", splitId, "OPEN").
+			Update("status", "CANCELLED")
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &service.Error{Message: "Split is not open"}
+		}
+
+		return tx.
+			Model(domain.SplitShare{}).
+			Where("split_id = ? AND status = ?", splitId, "REQUESTED").
+			Update("status", "CANCELLED").
+			Error
+	})
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+)
+
+// txKey - represents context key of transaction started by TransactionsRepo.
+type txKey struct{}
+
+// TransactionsRepo - represents repository running calls of other repositories in one database transaction.
+type TransactionsRepo struct {
+	*mysql.MySQL
+}
+
+// NewTransactionsRepo - create new instance of transactions repo.
+func NewTransactionsRepo(mysql *mysql.MySQL) *TransactionsRepo {
+	return &TransactionsRepo{mysql}
+}
+
+// InTransaction - used to run fn in database transaction, repository calls made with context passed to fn join it
+// and their own transactions become savepoints. Transaction is rolled back when fn returns error.
+func (t *TransactionsRepo) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return dbWithContext(ctx, t.DB).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// dbWithContext - returns transaction of context started by InTransaction or db, both bound to context.
+func dbWithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
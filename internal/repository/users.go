@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	// third party
 	"golang.org/x/crypto/bcrypt"
@@ -34,36 +36,30 @@ func (r *UsersRepo) SearchUsers(ctx context.Context, filter *domain.Filter) (*se
 		filter.Validate()
 	}
 
-	q := r.DB.
-		Table("users").
-		Offset((filter.Page - 1) * filter.List).
-		Limit(filter.List).
-		Order(filter.OrderString())
-
-	var userOutput []service.User
-	var response *service.SearchUsers
-	if err := q.Find(&userOutput).Error; err != nil {
-		return nil, &service.Error{Message: "Users not found"}
-	}
+	q := dbWithContext(ctx, r.DB).
+		Table("users")
 
 	var count int64
-	q = r.DB.
-		Table("users")
 	if err := q.Count(&count).Error; err != nil {
 		return nil, &service.Error{Message: "Users not found"}
 	}
 
-	response = &service.SearchUsers{
-		Data: userOutput,
-		Pagination: &domain.Pagination{
-			Order: filter.OrderString(),
-			Page:  filter.Page,
-			List:  filter.List,
-			Total: &count,
-		},
+	userOutput, pagination, err := findPage(q, "users", filter, count,
+		func(u service.User) int64 {
+			id, _ := strconv.ParseInt(u.ID, 10, 64)
+			return id
+		})
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Users not found"}
 	}
 
-	return response, nil
+	return &service.SearchUsers{
+		Data:       userOutput,
+		Pagination: pagination,
+	}, nil
 }
 
 // CreateUser - used to create user in the database.
@@ -82,12 +78,19 @@ func (r *UsersRepo) CreateUser(ctx context.Context, inp *service.RegisterUserInp
 		FullName: inp.FullName,
 		Email:    inp.Email,
 		Password: string(passwordBytes),
+		Language: inp.Language,
 	}
 
-	err = r.DB.
-		WithContext(ctx).
-		Create(user).
-		Error
+	// every new user gets plain user role
+	err = dbWithContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		var role domain.Role
+		if err := tx.Where("name = ?", domain.RoleUser).First(&role).Error; err != nil {
+			return err
+		}
+		user.Roles = []domain.Role{role}
+
+		return tx.Omit("Roles.*").Create(user).Error
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -98,8 +101,7 @@ func (r *UsersRepo) CreateUser(ctx context.Context, inp *service.RegisterUserInp
 // GetUser is used to get a user from the database.
 func (r *UsersRepo) GetUser(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, r.DB).
 		Where("email = ?", email).
 		First(&user).
 		Error
@@ -116,8 +118,7 @@ func (r *UsersRepo) GetUser(ctx context.Context, email string) (*domain.User, er
 // GetUserByID is used to get user by id from the database.
 func (r *UsersRepo) GetUserByID(ctx context.Context, userId int) (*domain.User, error) {
 	var user domain.User
-	err := r.DB.
-		WithContext(ctx).
+	err := dbWithContext(ctx, r.DB).
 		Where("id = ?", userId).
 		First(&user).
 		Error
@@ -131,27 +132,31 @@ func (r *UsersRepo) GetUserByID(ctx context.Context, userId int) (*domain.User,
 	return &user, err
 }
 
-// CreateToken - used to create token in the database.
+// CreateToken - used to create token in the database, email with token is queued in the same transaction.
 func (r *UsersRepo) CreateToken(ctx context.Context, inp service.GenerateTokenInput) error {
-	err := r.DB.
-		WithContext(ctx).
-		Create(&domain.UserToken{
-			Email: inp.Email,
-			Token: inp.Token,
-		}).
-		Error
-	if err != nil {
-		return err
-	}
+	return dbWithContext(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Create(&domain.UserToken{
+				Email: inp.Email,
+				Token: inp.Token,
+			}).
+			Error
+		if err != nil {
+			return err
+		}
 
-	return nil
+		if inp.Message == nil {
+			return nil
+		}
+		return tx.Create(inp.Message).Error
+	})
 }
 
 // GetToken is used to get a token from the database.
 func (r *UsersRepo) GetToken(ctx context.Context, token string) (*domain.UserToken, error) {
 	// var user domain.User
 	var user domain.UserToken
-	err := r.DB.
+	err := dbWithContext(ctx, r.DB).
 		Model(domain.UserToken{}).
 		Where("token IN (?)", token).
 		First(&user).
@@ -168,7 +173,7 @@ func (r *UsersRepo) GetToken(ctx context.Context, token string) (*domain.UserTok
 
 // DeleteToken - used to delete token in the database.
 func (r *UsersRepo) DeleteToken(ctx context.Context, token string) error {
-	err := r.DB.
+	err := dbWithContext(ctx, r.DB).
 		Delete(&domain.UserToken{}, "token = ?", token).
 		Error
 	if err != nil {
@@ -185,7 +190,7 @@ func (r *UsersRepo) ResetPassword(ctx context.Context, inp *service.ResetPasswor
 		return err
 	}
 
-	err = r.DB.Model(domain.User{}).
+	err = dbWithContext(ctx, r.DB).Model(domain.User{}).
 		Where("email IN (?)", user.Email).
 		Update("password", inp.Password).
 		Error
@@ -198,7 +203,7 @@ func (r *UsersRepo) ResetPassword(ctx context.Context, inp *service.ResetPasswor
 
 // ChangeUserStatus is used to update user status in the database.
 func (r *UsersRepo) ChangeUserStatus(ctx context.Context, userId int64, status string) (string, error) {
-	err := r.DB.
+	err := dbWithContext(ctx, r.DB).
 		Model(domain.User{}).
 		Where("id = ?", userId).
 		Update("status", status).
@@ -210,3 +215,147 @@ func (r *UsersRepo) ChangeUserStatus(ctx context.Context, userId int64, status s
 
 	return updatedStatus, err
 }
+
+// UpdateUserPhone is used to update phone number sms notifications are sent to in the database.
+func (r *UsersRepo) UpdateUserPhone(ctx context.Context, userId int, phone string) error {
+	return dbWithContext(ctx, r.DB).
+		Model(domain.User{}).
+		Where("id = ?", userId).
+		Update("phone", phone).
+		Error
+}
+
+// UpdateUserPassword is used to update password hash of user in the database.
+func (r *UsersRepo) UpdateUserPassword(ctx context.Context, userId int, password string) error {
+	res := dbWithContext(ctx, r.DB).
+		Model(domain.User{}).
+		Where("id = ?", userId).
+		Update("password", password)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "User not found"}
+	}
+
+	return nil
+}
+
+// UpdateUserLanguage is used to update language of user emails in the database.
+func (r *UsersRepo) UpdateUserLanguage(ctx context.Context, userId int, language string) error {
+	return dbWithContext(ctx, r.DB).
+		Model(domain.User{}).
+		Where("id = ?", userId).
+		Update("language", language).
+		Error
+}
+
+// CreateSession is used to create login session in the database.
+func (r *UsersRepo) CreateSession(ctx context.Context, session *domain.Session) error {
+	return dbWithContext(ctx, r.DB).Create(session).Error
+}
+
+// GetSessionByID is used to get login session from the database.
+func (r *UsersRepo) GetSessionByID(ctx context.Context, sessionId int) (*domain.Session, error) {
+	var session domain.Session
+	err := dbWithContext(ctx, r.DB).
+		Where("id = ?", sessionId).
+		First(&session).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Session not found"}
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetSessionByRefreshToken is used to get login session by hash of its current or previous refresh token from the database.
+func (r *UsersRepo) GetSessionByRefreshToken(ctx context.Context, hash string) (*domain.Session, error) {
+	var session domain.Session
+	err := dbWithContext(ctx, r.DB).
+		Where("refresh_token_hash = ? OR previous_token_hash = ?", hash, hash).
+		First(&session).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Invalid refresh token"}
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RotateSession is used to replace refresh token of active session in the database.
+// Session is updated only while hash is still its current refresh token, so the token is rotated once.
+func (r *UsersRepo) RotateSession(ctx context.Context, session *domain.Session, hash string) error {
+	res := dbWithContext(ctx, r.DB).
+		Model(domain.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  session.RefreshTokenHash,
+			"previous_token_hash": hash,
+			"user_agent":          session.UserAgent,
+			"ip":                  session.IP,
+			"expires_at":          session.ExpiresAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "Invalid refresh token"}
+	}
+
+	return nil
+}
+
+// RevokeSession is used to revoke active login session of user in the database.
+func (r *UsersRepo) RevokeSession(ctx context.Context, userId int, sessionId int, reason string, at time.Time) error {
+	res := dbWithContext(ctx, r.DB).
+		Model(domain.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, userId).
+		Updates(map[string]interface{}{
+			"revoked_at":    at,
+			"revoke_reason": reason,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &service.Error{Message: "Session not found"}
+	}
+
+	return nil
+}
+
+// RevokeUserSessions is used to revoke every active login session of user in the database.
+func (r *UsersRepo) RevokeUserSessions(ctx context.Context, userId int, reason string, at time.Time) (int64, error) {
+	res := dbWithContext(ctx, r.DB).
+		Model(domain.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Updates(map[string]interface{}{
+			"revoked_at":    at,
+			"revoke_reason": reason,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// DeleteExpiredSessions is used to delete sessions expired before time from the database.
+func (r *UsersRepo) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	res := dbWithContext(ctx, r.DB).
+		Unscoped().
+		Where("expires_at < ?", before).
+		Delete(&domain.Session{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
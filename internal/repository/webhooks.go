@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// third party
+	"gorm.io/gorm"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/mysql"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/service"
+)
+
+// WebhooksRepo - represents webhooks repository.
+type WebhooksRepo struct {
+	*mysql.MySQL
+}
+
+// NewWebhooksRepo - create new instance of webhooks repo.
+func NewWebhooksRepo(mysql *mysql.MySQL) *WebhooksRepo {
+	return &WebhooksRepo{mysql}
+}
+
+// CreateWebhook - used to create webhook in the database.
+func (w *WebhooksRepo) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	err := dbWithContext(ctx, w.DB).
+		Create(webhook).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// SearchWebhooks - used to get user webhooks from the database.
+func (w *WebhooksRepo) SearchWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	err := dbWithContext(ctx, w.DB).
+		Where("user_id = ?", userId).
+		Order("id").
+		Find(&webhooks).
+		Error
+	if err != nil {
+		return nil, &service.Error{Message: "Webhooks not found"}
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhookByID - used to get webhook by id from the database.
+func (w *WebhooksRepo) GetWebhookByID(ctx context.Context, webhookId int) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := dbWithContext(ctx, w.DB).
+		Where("id = ?", webhookId).
+		First(&webhook).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Webhook not found"}
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// DeleteWebhook - used to delete webhook with its pending deliveries from the database.
+func (w *WebhooksRepo) DeleteWebhook(ctx context.Context, webhookId int) error {
+	return dbWithContext(ctx, w.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Model(domain.WebhookDelivery{}).
+			Where("webhook_id = ? AND status = ?", webhookId, "PENDING").
+			Updates(map[string]interface{}{"status": "FAILED", "next_attempt_at": nil, "last_error": "webhook deleted"}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&domain.Webhook{}, webhookId).Error
+	})
+}
+
+// GetAccountWebhooks - used to get active webhooks of bank account members from the database.
+func (w *WebhooksRepo) GetAccountWebhooks(ctx context.Context, accountId int) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	err := dbWithContext(ctx, w.DB).
+		Where("status = ?", "ACTIVE").
+		Where("user_id IN (?)", dbWithContext(ctx, w.DB).
+			Table("account_members").
+			Select("user_id").
+			Where("bank_account_id = ? AND deleted_at IS NULL", accountId)).
+		Find(&webhooks).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhookDeliveries - used to create webhook deliveries in the database.
+func (w *WebhooksRepo) CreateWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	return dbWithContext(ctx, w.DB).
+		Create(&deliveries).
+		Error
+}
+
+// SearchWebhookDeliveries - used to search webhook delivery log from the database.
+func (w *WebhooksRepo) SearchWebhookDeliveries(ctx context.Context, filter *domain.Filter, webhookId int) (*service.SearchWebhookDeliveries, error) {
+	q := dbWithContext(ctx, w.DB).
+		Model(domain.WebhookDelivery{}).
+		Where("webhook_id = ?", webhookId)
+
+	var count int64
+	if err := q.Count(&count).Error; err != nil {
+		return nil, &service.Error{Message: "Webhook deliveries not found"}
+	}
+
+	deliveries, pagination, err := findPage(q, "webhook_deliveries", filter, count,
+		func(w domain.WebhookDelivery) int64 { return int64(w.ID) })
+	if err != nil {
+		if _, ok := err.(*service.Error); ok {
+			return nil, err
+		}
+		return nil, &service.Error{Message: "Webhook deliveries not found"}
+	}
+
+	return &service.SearchWebhookDeliveries{
+		Data:       deliveries,
+		Pagination: pagination,
+	}, nil
+}
+
+// GetWebhookDeliveryByID - used to get webhook delivery by id from the database.
+func (w *WebhooksRepo) GetWebhookDeliveryByID(ctx context.Context, deliveryId int) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := dbWithContext(ctx, w.DB).
+		Where("id = ?", deliveryId).
+		First(&delivery).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &service.Error{Message: "Webhook delivery not found"}
+		}
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// GetDueWebhookDeliveries - used to get pending deliveries whose next attempt is due with their webhooks.
+func (w *WebhooksRepo) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := dbWithContext(ctx, w.DB).
+		Preload("Webhook").
+		Where("status = ? AND next_attempt_at <= ?", "PENDING", now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery - used to save delivery attempt result in the database.
+func (w *WebhooksRepo) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return dbWithContext(ctx, w.DB).
+		Model(delivery).
+		Select("status", "attempts", "response_code", "last_error", "next_attempt_at", "delivered_at").
+		Updates(delivery).
+		Error
+}
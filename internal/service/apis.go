@@ -1,10 +1,15 @@
 package service
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // APIs contains all available APIs.
 type APIs struct {
-	Emails EmailsAPI
+	Emails   EmailsAPI
+	Webhooks WebhooksAPI
+	Channels []NotificationChannel
 }
 
 // EmailsAPI - represents emails api.
@@ -12,9 +17,57 @@ type EmailsAPI interface {
 	SendEmail(ctx context.Context, inp SendEmailInput) error
 }
 
+// SendEmailInput - represents email, TextBody is plain text alternative of Body when set.
 type SendEmailInput struct {
 	To          string
 	Subject     string
 	ContentType string
 	Body        string
+	TextBody    string
+}
+
+// EmailsCaptureAPI - represents emails api which keeps emails instead of delivering them.
+type EmailsCaptureAPI interface {
+	EmailsAPI
+	CapturedEmails() []CapturedEmail
+	ClearCapturedEmails()
+}
+
+// CapturedEmail - represents email kept by capture api.
+type CapturedEmail struct {
+	To          string    `json:"to"`
+	Subject     string    `json:"subject"`
+	ContentType string    `json:"contentType"`
+	Body        string    `json:"body"`
+	TextBody    string    `json:"textBody,omitempty"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// NotificationChannel - represents api notifications are delivered through besides email, such as sms or push.
+type NotificationChannel interface {
+	// Channel returns name of channel, one of domain.NotificationChannels.
+	Channel() string
+	SendNotification(ctx context.Context, inp SendNotificationInput) error
+}
+
+// SendNotificationInput - represents notification, To is phone number for sms and device token for push.
+type SendNotificationInput struct {
+	To         string
+	Event      string
+	Title      string
+	Body       string
+	DeliveryID int
+}
+
+// WebhooksAPI - represents webhooks api.
+type WebhooksAPI interface {
+	SendWebhook(ctx context.Context, inp SendWebhookInput) (int, error)
+}
+
+type SendWebhookInput struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int
+	Payload    []byte
 }
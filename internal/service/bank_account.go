@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	// third party
 	"golang.org/x/crypto/bcrypt"
 
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
 	// internal
 	"github.com/Shevchenkko/payment_system/internal/domain"
 )
@@ -14,22 +18,29 @@ import (
 // BankAccountsService - represents bank accounts service.
 type BankAccountsService struct {
 	repos Repositories
+	apis  APIs
 }
 
 // NewBankAccountService - creates instance of new bank account service.
-func NewBankAccountService(repos Repositories) *BankAccountsService {
-	return &BankAccountsService{repos}
+func NewBankAccountService(repos Repositories, apis APIs) *BankAccountsService {
+	return &BankAccountsService{repos, apis}
 }
 
 // SearchBankAccount is used for search bank account.
-func (b *BankAccountsService) SearchBankAccounts(ctx context.Context, filter *domain.Filter, client string, role string) (*SearchBankAccounts, error) {
+func (b *BankAccountsService) SearchBankAccounts(ctx context.Context, filter *domain.Filter, userId int, permissions domain.Permissions) (*SearchBankAccounts, error) {
 	if filter == nil {
 		filter = new(domain.Filter)
 		filter.Validate()
 	}
 
+	// api key request gets only merchant settlement account
+	accountId := 0
+	if merchant := contextMerchant(ctx); merchant != nil {
+		accountId = merchant.SettlementAccountID
+	}
+
 	// search bank accounts from db
-	response, err := b.repos.Banks.SearchBankAccounts(ctx, filter, client, role)
+	response, err := b.repos.Banks.SearchBankAccounts(ctx, filter, userId, permissions.Has(domain.PermissionAccountsReadAll), accountId)
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +56,7 @@ func (b *BankAccountsService) CreateBankAccount(ctx context.Context, userId int,
 	}
 
 	// create bank account in db
-	account, err := b.repos.Banks.CreateBankAccount(ctx, inp, client.FullName)
+	account, err := b.repos.Banks.CreateBankAccount(ctx, inp, client)
 	if err != nil {
 		return BankAccountOutput{}, err
 	}
@@ -71,14 +82,29 @@ func (b *BankAccountsService) TopUpBankAccount(ctx context.Context, userId int,
 	if err != nil {
 		return BankAccountOutput{}, err
 	}
+	if card.Status == "CLOSED" {
+		return BankAccountOutput{}, &Error{Message: "Bank account is closed"}
+	}
 
-	cardBalance := card.Balance + inp.OperationAmount
+	cardBalance := utils.RoundMoney(card.Balance + inp.OperationAmount)
 
-	// top up bank account in db
-	err = b.repos.Banks.TopUpBankAccount(ctx, inp, cardBalance)
+	// top up bank account in db and queue its notifications
+	err = b.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		err := b.repos.Banks.TopUpBankAccount(ctx, card, inp.OperationAmount)
+		if err != nil {
+			return err
+		}
+		return notifyAccountMembers(ctx, b.repos, b.apis, card.ID, "topup.completed", "Top up completed",
+			fmt.Sprintf("Account %s was topped up by %.2f, balance is %.2f", card.IBAN, inp.OperationAmount, cardBalance))
+	})
 	if err != nil {
 		return BankAccountOutput{}, err
 	}
+	publishEvent(ctx, b.repos, "topup.completed", card.ID, map[string]interface{}{
+		"cardNumber": card.CardNumber,
+		"amount":     inp.OperationAmount,
+		"balance":    cardBalance,
+	})
 
 	return BankAccountOutput{
 		Client:     client.FullName,
@@ -89,59 +115,88 @@ func (b *BankAccountsService) TopUpBankAccount(ctx context.Context, userId int,
 }
 
 // BlockBankAccount is used for blocing bank account.
-func (b *BankAccountsService) BlockBankAccount(ctx context.Context, client string, userRole string, inp *ChangeBankAccountInput) (string, error) {
+func (b *BankAccountsService) BlockBankAccount(ctx context.Context, userId int, permissions domain.Permissions, inp *ChangeBankAccountInput) (string, error) {
 	status, err := b.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
 	if err != nil {
 		return "", err
 	}
+	if status.Type == "DEPOSIT" {
+		return "", &Error{Message: "Deposit account is locked until maturity"}
+	}
+	if status.Status == "CLOSED" {
+		return "", &Error{Message: "Bank account is closed"}
+	}
 	var accountChange string
 
-	// check user role
-	if userRole == "admin" {
+	// check user permission
+	if permissions.Has(domain.PermissionAccountsLock) {
 		if status.Status == "ACTIVE" {
-			accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "LOCK")
+			err = b.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+				accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "LOCK")
+				if err != nil {
+					return err
+				}
+				return notifyAccountMembers(ctx, b.repos, b.apis, status.ID, "account.locked", "Account locked",
+					fmt.Sprintf("Account %s was locked by the bank. Please, turn to the nearest branch of our bank", status.IBAN))
+			})
 			if err != nil {
 				return "", err
 			}
+			publishAccountLocked(ctx, b.repos, status)
 		} else {
 			accountChange = "The account has already been blocked"
 		}
 	} else {
-		if client == status.Client {
-			// check secret value
-			err = bcrypt.CompareHashAndPassword([]byte(status.SecretValue), []byte(inp.SecretValue))
+		// check membership
+		member, err := b.repos.Banks.GetAccountMember(ctx, status.ID, userId)
+		if err != nil {
+			if _, ok := err.(*Error); ok {
+				return "This is not your bank account!", nil
+			}
+			return "", err
+		}
+		if !member.CanPay() {
+			return "You don't have permission to change this bank account!", nil
+		}
+
+		// check secret value
+		err = bcrypt.CompareHashAndPassword([]byte(status.SecretValue), []byte(inp.SecretValue))
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return "", &Error{Message: "Wrong secret value"}
+			}
+			return "", err
+		}
+		if status.Status == "ACTIVE" {
+			accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "LOCK")
 			if err != nil {
-				if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-					return "", &Error{Message: "Wrong secret value"}
-				}
 				return "", err
 			}
-			if status.Status == "ACTIVE" {
-				accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "LOCK")
-				if err != nil {
-					return "", err
-				}
-			} else {
-				accountChange = "The account has already been blocked"
-			}
+			publishAccountLocked(ctx, b.repos, status)
 		} else {
-			accountChange = "This is not your bank account!"
+			accountChange = "The account has already been blocked"
 		}
 	}
 	return accountChange, nil
 }
 
 // UnlockBankAccount is used for unlocing bank account.
-func (b *BankAccountsService) UnlockBankAccount(ctx context.Context, client string, userRole string, inp *ChangeBankAccountInput) (string, error) {
+func (b *BankAccountsService) UnlockBankAccount(ctx context.Context, userId int, permissions domain.Permissions, inp *ChangeBankAccountInput) (string, error) {
 	status, err := b.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
 	if err != nil {
 		return "", err
 	}
+	if status.Type == "DEPOSIT" {
+		return "", &Error{Message: "Deposit account is locked until maturity"}
+	}
+	if status.Status == "CLOSED" {
+		return "", &Error{Message: "Bank account is closed"}
+	}
 	var accountChange string
 
-	// check user role
-	if userRole == "admin" {
-		if status.Status == "LOCK" {
+	// check user permission
+	if permissions.Has(domain.PermissionAccountsLock) {
+		if status.Status == "LOCK" || status.Status == "FROZEN" {
 			accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "ACTIVE")
 			if err != nil {
 				return "", err
@@ -150,26 +205,273 @@ func (b *BankAccountsService) UnlockBankAccount(ctx context.Context, client stri
 			accountChange = "The account has already been active"
 		}
 	} else {
-		if client == status.Client {
-			// check secret value
-			err = bcrypt.CompareHashAndPassword([]byte(status.SecretValue), []byte(inp.SecretValue))
+		// check membership
+		member, err := b.repos.Banks.GetAccountMember(ctx, status.ID, userId)
+		if err != nil {
+			if _, ok := err.(*Error); ok {
+				return "This is not your bank account!", nil
+			}
+			return "", err
+		}
+		if !member.CanPay() {
+			return "You don't have permission to change this bank account!", nil
+		}
+
+		// check secret value
+		err = bcrypt.CompareHashAndPassword([]byte(status.SecretValue), []byte(inp.SecretValue))
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return "", &Error{Message: "Wrong secret value"}
+			}
+			return "", err
+		}
+		// frozen account is unfrozen only by the bank after discrepancy is resolved
+		if status.Status == "FROZEN" {
+			return "", &Error{Message: "Bank account is frozen because of balance discrepancy, please turn to the nearest branch of our bank"}
+		}
+		if status.Status == "LOCK" {
+			accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "ACTIVE")
 			if err != nil {
-				if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-					return "", &Error{Message: "Wrong secret value"}
-				}
 				return "", err
 			}
-			if status.Status == "LOCK" {
-				accountChange, err = b.repos.Banks.ChangeCreditCardStatus(ctx, inp.CardNumber, "ACTIVE")
-				if err != nil {
-					return "", err
-				}
-			} else {
-				accountChange = "The account has already been active"
-			}
 		} else {
-			accountChange = "This is not your bank account!"
+			accountChange = "The account has already been active"
 		}
 	}
 	return accountChange, nil
 }
+
+// SearchAccountMembers is used for getting members of bank account.
+func (b *BankAccountsService) SearchAccountMembers(ctx context.Context, userId int, permissions domain.Permissions, cardNumber int64) ([]AccountMemberOutput, error) {
+	account, err := b.repos.Banks.CheckCreditCard(ctx, cardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// any member may see who else holds the account
+	if !permissions.Has(domain.PermissionAccountsReadAll) {
+		_, err = b.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b.repos.Banks.SearchAccountMembers(ctx, account.ID)
+}
+
+// AddAccountMember is used for granting another user access to bank account.
+func (b *BankAccountsService) AddAccountMember(ctx context.Context, userId int, inp *AccountMemberInput) (*domain.AccountMember, error) {
+	if inp.Role != "co-owner" && inp.Role != "viewer" {
+		return nil, &Error{Message: "Role must be co-owner or viewer"}
+	}
+
+	account, err := b.checkAccountOwner(ctx, userId, inp)
+	if err != nil {
+		return nil, err
+	}
+
+	// check new member
+	user, err := b.repos.Users.GetUserByID(ctx, inp.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.repos.Banks.AddAccountMember(ctx, account.ID, user.ID, inp.Role)
+}
+
+// RemoveAccountMember is used for revoking user access to bank account.
+func (b *BankAccountsService) RemoveAccountMember(ctx context.Context, userId int, inp *AccountMemberInput) error {
+	account, err := b.checkAccountOwner(ctx, userId, inp)
+	if err != nil {
+		return err
+	}
+
+	member, err := b.repos.Banks.GetAccountMember(ctx, account.ID, inp.UserID)
+	if err != nil {
+		return &Error{Message: "User is not a member of this bank account"}
+	}
+	if member.IsOwner() {
+		return &Error{Message: "Owner can not be removed from bank account"}
+	}
+
+	return b.repos.Banks.RemoveAccountMember(ctx, account.ID, inp.UserID)
+}
+
+// CloseBankAccount is used for closing bank account and moving its balance to another account or payout IBAN.
+func (b *BankAccountsService) CloseBankAccount(ctx context.Context, userId int, permissions domain.Permissions, inp *CloseBankAccountInput) (*domain.BankAccount, error) {
+	account, err := b.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if account.Type == "DEPOSIT" {
+		return nil, &Error{Message: "Deposit account is closed on withdrawal or maturity"}
+	}
+	if account.Status == "CLOSED" {
+		return nil, &Error{Message: "Bank account is already closed"}
+	}
+
+	// check user permission
+	if !permissions.Has(domain.PermissionAccountsClose) {
+		if account.Status == "FROZEN" {
+			return nil, &Error{Message: "Bank account is frozen because of balance discrepancy, please turn to the nearest branch of our bank"}
+		}
+		member, err := b.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+		if err != nil {
+			return nil, err
+		}
+		if !member.IsOwner() {
+			return nil, &Error{Message: "Only owner can close bank account"}
+		}
+
+		// check secret value
+		err = bcrypt.CompareHashAndPassword([]byte(account.SecretValue), []byte(inp.SecretValue))
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return nil, &Error{Message: "Wrong secret value"}
+			}
+			return nil, err
+		}
+	}
+
+	// find where remaining balance goes
+	repoInput := &CloseBankAccountRepoInput{Account: account}
+	switch {
+	case inp.TargetCardNumber != 0:
+		target, err := b.repos.Banks.CheckCreditCard(ctx, inp.TargetCardNumber)
+		if err != nil {
+			return nil, err
+		}
+		if target.ID == account.ID || target.ClientID != account.ClientID {
+			return nil, &Error{Message: "Balance can be moved only to another account of the same client"}
+		}
+		if target.Type != "CURRENT" || target.Status == "CLOSED" {
+			return nil, &Error{Message: "Balance can be moved only to open current account"}
+		}
+		repoInput.Target = target
+	case inp.PayoutIBAN != "":
+		if inp.PayoutIBAN == account.IBAN {
+			return nil, &Error{Message: "Payout IBAN must differ from closed account"}
+		}
+		target, err := getRecipientAccount(ctx, b.repos, inp.PayoutIBAN)
+		if err != nil {
+			return nil, err
+		}
+		repoInput.Target = target
+		repoInput.PayoutIBAN = inp.PayoutIBAN
+		repoInput.PayoutName = inp.PayoutName
+	case account.Balance > 0:
+		return nil, &Error{Message: "Remaining balance must be moved to another account or payout IBAN"}
+	}
+
+	err = b.repos.Banks.CloseBankAccount(ctx, repoInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.repos.Banks.GetBankAccountByID(ctx, account.ID)
+}
+
+// GetBalanceHistory is used for getting daily balances of bank account and its balance at the end of past date.
+func (b *BankAccountsService) GetBalanceHistory(ctx context.Context, userId int, permissions domain.Permissions, inp *BalanceHistoryInput) (*BalanceHistory, error) {
+	account, err := b.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	err = checkMerchantAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !permissions.Has(domain.PermissionAccountsReadAll) {
+		_, err = b.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if inp.To.Before(inp.From) {
+		return nil, &Error{Message: "Date from must not be after date to"}
+	}
+
+	snapshots, err := b.repos.BusinessDays.SearchBalanceSnapshots(ctx, account.ID, inp.From, inp.To)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &BalanceHistory{
+		CardNumber: account.CardNumber,
+		Series:     make([]BalanceHistoryPoint, 0, len(snapshots)),
+	}
+	for _, snapshot := range snapshots {
+		history.Series = append(history.Series, BalanceHistoryPoint{
+			Date:    snapshot.Date.Format("2006-01-02"),
+			Balance: snapshot.Balance,
+		})
+	}
+
+	if inp.AsOf != nil {
+		last, err := b.repos.BusinessDays.GetLastBusinessDay(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if last == nil || inp.AsOf.After(last.Date) {
+			return nil, &Error{Message: "Balance is available only for closed business days"}
+		}
+		snapshot, err := b.repos.BusinessDays.GetBalanceSnapshot(ctx, account.ID, *inp.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		history.AsOf = &BalanceHistoryPoint{
+			Date:    inp.AsOf.Format("2006-01-02"),
+			Balance: snapshot.Balance,
+		}
+	}
+
+	return history, nil
+}
+
+// publishAccountLocked - publishes account.locked event for bank account.
+func publishAccountLocked(ctx context.Context, repos Repositories, account *domain.BankAccount) {
+	publishEvent(ctx, repos, "account.locked", account.ID, map[string]interface{}{
+		"cardNumber": account.CardNumber,
+		"status":     "LOCK",
+	})
+}
+
+// checkAccountOwner - checks that user owns the account and knows its secret value.
+func (b *BankAccountsService) checkAccountOwner(ctx context.Context, userId int, inp *AccountMemberInput) (*domain.BankAccount, error) {
+	account, err := b.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := b.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !member.IsOwner() {
+		return nil, &Error{Message: "Only owner can manage bank account members"}
+	}
+
+	// check secret value
+	err = bcrypt.CompareHashAndPassword([]byte(account.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// checkAccountPayer - checks that user may move funds from bank account.
+func checkAccountPayer(ctx context.Context, repos Repositories, account *domain.BankAccount, userId int) error {
+	member, err := repos.Banks.GetAccountMember(ctx, account.ID, userId)
+	if err != nil {
+		return err
+	}
+	if !member.CanPay() {
+		return &Error{Message: "You don't have permission to pay from this bank account!"}
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// BudgetsService - represents budgets service.
+type BudgetsService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewBudgetsService - creates instance of new budgets service.
+func NewBudgetsService(repos Repositories, apis APIs) *BudgetsService {
+	return &BudgetsService{repos, apis}
+}
+
+// CreateBudget is used for setting monthly limit of category or overall spending.
+// Thresholds already crossed in current month are not alerted.
+func (b *BudgetsService) CreateBudget(ctx context.Context, userId int, inp *BudgetInput) (*BudgetProgress, error) {
+	if inp.Category != "" && !domain.IsPaymentCategory(inp.Category) {
+		return nil, &Error{Message: "Unknown category, allowed: " + strings.Join(domain.PaymentCategories, ", ")}
+	}
+	if inp.Amount <= 0 {
+		return nil, &Error{Message: "Budget amount must be positive"}
+	}
+
+	_, err := b.repos.Budgets.GetUserBudget(ctx, userId, inp.Category)
+	if err == nil {
+		return nil, &Error{Message: "Budget for this category already exists"}
+	}
+	if _, ok := err.(*Error); !ok {
+		return nil, err
+	}
+
+	budget, err := b.repos.Budgets.CreateBudget(ctx, &domain.Budget{
+		UserID:   userId,
+		Category: inp.Category,
+		Amount:   inp.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.budgetProgress(ctx, userId, budget.ID)
+}
+
+// UpdateBudget is used for changing budget amount, thresholds are alerted against new amount.
+func (b *BudgetsService) UpdateBudget(ctx context.Context, userId int, inp *UpdateBudgetInput) (*BudgetProgress, error) {
+	if inp.Amount <= 0 {
+		return nil, &Error{Message: "Budget amount must be positive"}
+	}
+
+	budget, err := b.getUserBudget(ctx, userId, inp.BudgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.repos.Budgets.UpdateBudgetAmount(ctx, budget.ID, inp.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.budgetProgress(ctx, userId, budget.ID)
+}
+
+// SearchBudgets is used for getting user budgets with consumption in current month.
+func (b *BudgetsService) SearchBudgets(ctx context.Context, userId int) ([]BudgetProgress, error) {
+	budgets, err := refreshBudgets(ctx, b.repos, b.apis, userId, time.Now(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]BudgetProgress, 0, len(budgets))
+	for _, budget := range budgets {
+		progress = append(progress, newBudgetProgress(budget))
+	}
+
+	return progress, nil
+}
+
+// DeleteBudget is used for deleting user budget.
+func (b *BudgetsService) DeleteBudget(ctx context.Context, userId int, budgetId int) error {
+	budget, err := b.getUserBudget(ctx, userId, budgetId)
+	if err != nil {
+		return err
+	}
+
+	return b.repos.Budgets.DeleteBudget(ctx, budget.ID)
+}
+
+// getUserBudget - returns budget if it belongs to user.
+func (b *BudgetsService) getUserBudget(ctx context.Context, userId int, budgetId int) (*domain.Budget, error) {
+	budget, err := b.repos.Budgets.GetBudgetByID(ctx, budgetId)
+	if err != nil {
+		return nil, err
+	}
+	if budget.UserID != userId {
+		return nil, &Error{Message: "Budget not found"}
+	}
+
+	return budget, nil
+}
+
+// budgetProgress - recounts user budgets without alerts and returns progress of budget.
+func (b *BudgetsService) budgetProgress(ctx context.Context, userId int, budgetId int) (*BudgetProgress, error) {
+	budgets, err := refreshBudgets(ctx, b.repos, b.apis, userId, time.Now(), false)
+	if err != nil {
+		return nil, err
+	}
+	for _, budget := range budgets {
+		if budget.ID == budgetId {
+			progress := newBudgetProgress(budget)
+			return &progress, nil
+		}
+	}
+
+	return nil, &Error{Message: "Budget not found"}
+}
+
+// trackBudgets - categorizes payment sent from bank account, recounts budgets of its members
+// and alerts them about crossed thresholds. Errors are ignored, payments left uncategorized
+// are categorized by scheduler and budgets are recounted by the next payment.
+func trackBudgets(ctx context.Context, repos Repositories, apis APIs, accountId int) {
+	members, err := repos.Banks.SearchAccountMembers(ctx, accountId)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, member := range members {
+		_, _ = repos.Spending.CategorizePayments(ctx, member.UserID)
+		_, _ = refreshBudgets(ctx, repos, apis, member.UserID, now, true)
+	}
+}
+
+// refreshBudgets - recounts consumption of user budgets in month of now.
+// With notify user is alerted about thresholds crossed since last recount, otherwise thresholds are marked silently.
+func refreshBudgets(ctx context.Context, repos Repositories, apis APIs, userId int, now time.Time, notify bool) ([]domain.Budget, error) {
+	budgets, err := repos.Budgets.SearchBudgets(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return budgets, nil
+	}
+
+	month := now.Format("2006-01")
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	totals, err := repos.Spending.SumSpending(ctx, userId, &SpendingQuery{
+		From:    from,
+		To:      from.AddDate(0, 1, 0),
+		GroupBy: "category",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range budgets {
+		budget := &budgets[i]
+
+		var spent float64
+		for _, total := range totals {
+			if budget.Counts(total.Key) {
+				spent += total.Total
+			}
+		}
+		if budget.Month != month {
+			budget.Alerted = 0
+		}
+		budget.Month = month
+		budget.Spent = roundAmount(spent)
+
+		err = repos.Budgets.UpdateBudgetSpent(ctx, budget.ID, month, budget.Spent)
+		if err != nil {
+			return nil, err
+		}
+
+		threshold := budget.CrossedThreshold()
+		if threshold <= budget.Alerted {
+			continue
+		}
+
+		// threshold is marked together with queued alert
+		budget.Alerted = threshold
+		err = repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+			alerted, err := repos.Budgets.MarkBudgetAlerted(ctx, budget.ID, month, threshold)
+			if err != nil || !alerted || !notify {
+				return err
+			}
+			return notifyBudget(ctx, repos, apis, userId, budget)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return budgets, nil
+}
+
+// notifyBudget - alerts user about crossed budget threshold by notification and message log.
+func notifyBudget(ctx context.Context, repos Repositories, apis APIs, userId int, budget *domain.Budget) error {
+	user, err := repos.Users.GetUserByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	name := "overall"
+	if budget.Category != "" {
+		name = budget.Category
+	}
+	message := fmt.Sprintf("Budget %s reached %d%%: spent %.2f of %.2f in %s", name, budget.Alerted, budget.Spent, budget.Amount, budget.Month)
+
+	_, _ = repos.Messages.CreateMessageLog(ctx, &MessageLogInput{
+		ClientID:   user.ID,
+		Client:     user.FullName,
+		MessageLog: message,
+	})
+
+	return notifyUser(ctx, repos, apis, user.ID, "budget.reached", fmt.Sprintf("%s budget reached %d%%", name, budget.Alerted),
+		fmt.Sprintf("You have spent %.2f of your %.2f %s budget for %s (%d%%).", budget.Spent, budget.Amount, name, budget.Month, budget.Alerted))
+}
+
+// newBudgetProgress - returns budget with its remaining amount and consumed percent.
+func newBudgetProgress(budget domain.Budget) BudgetProgress {
+	remaining := budget.Amount - budget.Spent
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return BudgetProgress{
+		Budget:    budget,
+		Remaining: roundAmount(remaining),
+		Percent:   roundAmount(budget.Spent / budget.Amount * 100),
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// BusinessDaysService - represents end of day closing service.
+type BusinessDaysService struct {
+	repos Repositories
+}
+
+// NewBusinessDaysService - creates instance of new business days service.
+func NewBusinessDaysService(repos Repositories) *BusinessDaysService {
+	return &BusinessDaysService{repos}
+}
+
+// CloseBusinessDays is used for closing every past business day that is still open.
+// On the first run only the previous day is closed.
+func (d *BusinessDaysService) CloseBusinessDays(ctx context.Context, now time.Time) (int, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	day := today.AddDate(0, 0, -1)
+	last, err := d.repos.BusinessDays.GetLastBusinessDay(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if last != nil {
+		day = time.Date(last.Date.Year(), last.Date.Month(), last.Date.Day()+1, 0, 0, 0, 0, time.Local)
+	}
+
+	closed := 0
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		_, err = d.repos.BusinessDays.CloseBusinessDay(ctx, day)
+		if err != nil {
+			return closed, err
+		}
+		closed++
+	}
+
+	return closed, nil
+}
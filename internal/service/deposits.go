@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// third party
+	"golang.org/x/crypto/bcrypt"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// DepositsService - represents term deposits service.
+type DepositsService struct {
+	repos Repositories
+}
+
+// NewDepositService - creates instance of new deposit service.
+func NewDepositService(repos Repositories) *DepositsService {
+	return &DepositsService{repos}
+}
+
+// SearchDepositProducts is used for search deposit product catalog.
+func (d *DepositsService) SearchDepositProducts(ctx context.Context, filter *domain.Filter) (*SearchDepositProducts, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	// search deposit products from db
+	response, err := d.repos.Deposits.SearchDepositProducts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CreateDepositProduct is used for adding product to deposit catalog.
+func (d *DepositsService) CreateDepositProduct(ctx context.Context, inp *DepositProductInput) (*domain.DepositProduct, error) {
+	if inp.TermMonths < 1 {
+		return nil, &Error{Message: "Deposit term must be at least one month"}
+	}
+	if inp.Rate <= 0 || inp.PenaltyRate < 0 || inp.PenaltyRate > inp.Rate {
+		return nil, &Error{Message: "Penalty rate must be between zero and deposit rate"}
+	}
+
+	// create deposit product in db
+	product, err := d.repos.Deposits.CreateDepositProduct(ctx, inp)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// SearchDeposits is used for search client deposits.
+func (d *DepositsService) SearchDeposits(ctx context.Context, filter *domain.Filter, userId int) (*SearchDeposits, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	// search deposits from db
+	response, err := d.repos.Deposits.SearchDeposits(ctx, filter, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// OpenDeposit is used for opening deposit from client current account.
+func (d *DepositsService) OpenDeposit(ctx context.Context, userId int, inp *OpenDepositInput) (*domain.Deposit, error) {
+	product, err := d.repos.Deposits.GetDepositProductByID(ctx, inp.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status != "ACTIVE" {
+		return nil, &Error{Message: "Deposit product is not available"}
+	}
+	if inp.Amount <= 0 || inp.Amount < product.MinAmount {
+		return nil, &Error{Message: "Deposit amount is less than product minimum"}
+	}
+
+	// check source account
+	source, err := d.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountPayer(ctx, d.repos, source, userId)
+	if err != nil {
+		return nil, err
+	}
+	if source.Type != "CURRENT" || source.Status != "ACTIVE" {
+		return nil, &Error{Message: "Deposit can be opened only from active current account"}
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(source.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+	if source.Balance < inp.Amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	// move funds to deposit account
+	start := time.Now()
+	deposit, err := d.repos.Deposits.OpenDeposit(ctx, &OpenDepositRepoInput{
+		Product:      product,
+		Source:       source,
+		Amount:       inp.Amount,
+		Rollover:     inp.Rollover,
+		StartDate:    start,
+		MaturityDate: start.AddDate(0, product.TermMonths, 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// WithdrawDeposit is used for closing deposit before maturity with penalty rate.
+func (d *DepositsService) WithdrawDeposit(ctx context.Context, userId int, inp *WithdrawDepositInput) (*domain.Deposit, error) {
+	deposit, err := d.repos.Deposits.GetDepositByID(ctx, inp.DepositID)
+	if err != nil {
+		return nil, err
+	}
+	if deposit.Status != "OPEN" {
+		return nil, &Error{Message: "Deposit is already closed"}
+	}
+
+	// check owner
+	account, err := d.repos.Banks.GetBankAccountByID(ctx, deposit.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountPayer(ctx, d.repos, account, userId)
+	if err != nil {
+		return nil, err
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(account.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !now.Before(deposit.MaturityDate) {
+		return nil, &Error{Message: "Deposit has matured and will be paid out automatically"}
+	}
+
+	// early withdrawal earns penalty rate for elapsed days only
+	interest := depositInterest(deposit.Principal, deposit.PenaltyRate, now.Sub(deposit.StartDate).Hours()/24/365)
+	err = d.repos.Deposits.CloseDeposit(ctx, deposit, interest, "WITHDRAWN")
+	if err != nil {
+		return nil, err
+	}
+
+	return d.repos.Deposits.GetDepositByID(ctx, deposit.ID)
+}
+
+// ProcessMaturedDeposits is used for paying out or rolling over matured deposits.
+// Failed deposit does not stop the run, failures are returned together after all deposits and retried by the next run.
+func (d *DepositsService) ProcessMaturedDeposits(ctx context.Context, now time.Time) (int, error) {
+	deposits, err := d.repos.Deposits.GetMaturedDeposits(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	var failures []string
+	for i := range deposits {
+		deposit := &deposits[i]
+		interest := depositInterest(deposit.Principal, deposit.Rate, float64(deposit.TermMonths)/12)
+
+		if deposit.Rollover {
+			err = d.repos.Deposits.RolloverDeposit(ctx, deposit, interest, deposit.MaturityDate.AddDate(0, deposit.TermMonths, 0))
+		} else {
+			err = d.repos.Deposits.CloseDeposit(ctx, deposit, interest, "MATURED")
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("deposit #%d: %v", deposit.ID, err))
+			continue
+		}
+		processed++
+	}
+	if len(failures) > 0 {
+		return processed, fmt.Errorf("failed to process %d of %d matured deposits: %s",
+			len(failures), len(deposits), strings.Join(failures, "; "))
+	}
+
+	return processed, nil
+}
+
+// depositInterest - calculates simple interest for annual rate in percents.
+func depositInterest(principal float64, rate float64, years float64) float64 {
+	if years <= 0 {
+		return 0
+	}
+	return utils.RoundMoney(principal * rate / 100 * years)
+}
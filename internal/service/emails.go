@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+const (
+	// emailMaxAttempts - number of attempts after which email is dead.
+	emailMaxAttempts = 6
+	// emailRetryBase - delay before the second attempt, doubled for every next one.
+	emailRetryBase = time.Minute
+	// emailBatchSize - number of emails sent by one run.
+	emailBatchSize = 50
+	// emailClaimLease - time after which email claimed by stopped worker is sent again.
+	emailClaimLease = 15 * time.Minute
+)
+
+// EmailStatuses - represents statuses of outbox emails.
+var EmailStatuses = []string{"PENDING", "SENDING", "SENT", "DEAD"}
+
+// EmailsService - represents email outbox service.
+type EmailsService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewEmailsService - creates instance of new email outbox service.
+func NewEmailsService(repos Repositories, apis APIs) *EmailsService {
+	return &EmailsService{repos, apis}
+}
+
+// SearchOutboxEmails is used for inspecting email queue.
+func (e *EmailsService) SearchOutboxEmails(ctx context.Context, filter *domain.Filter, status string) (*SearchOutboxEmails, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+	if status != "" && !isEmailStatus(status) {
+		return nil, &Error{Message: "Unknown status, allowed: " + strings.Join(EmailStatuses, ", ")}
+	}
+
+	return e.repos.Emails.SearchOutboxEmails(ctx, filter, status)
+}
+
+// RetryOutboxEmail is used for sending dead email again.
+func (e *EmailsService) RetryOutboxEmail(ctx context.Context, emailId int) (*domain.OutboxEmail, error) {
+	email, err := e.repos.Emails.GetOutboxEmailByID(ctx, emailId)
+	if err != nil {
+		return nil, err
+	}
+	if email.Status != "DEAD" {
+		return nil, &Error{Message: "Only dead emails can be retried"}
+	}
+
+	now := time.Now()
+	email.Status = "PENDING"
+	email.Attempts = 0
+	email.NextAttemptAt = &now
+	err = e.repos.Emails.UpdateOutboxEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return email, nil
+}
+
+// DeliverEmails is used for sending due emails and scheduling retries with exponential backoff.
+func (e *EmailsService) DeliverEmails(ctx context.Context, now time.Time) (int, error) {
+	emails, err := e.repos.Emails.ClaimDueOutboxEmails(ctx, now, emailBatchSize, emailClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range emails {
+		email := &emails[i]
+		err := e.apis.Emails.SendEmail(ctx, SendEmailInput{
+			To:          email.To,
+			Subject:     email.Subject,
+			ContentType: email.ContentType,
+			Body:        email.Body,
+			TextBody:    email.TextBody,
+		})
+
+		at := time.Now()
+		email.Attempts++
+		switch {
+		case err == nil:
+			email.Status = "SENT"
+			email.SentAt = &at
+			email.NextAttemptAt = nil
+			email.LastError = ""
+			sent++
+		case email.Attempts >= emailMaxAttempts:
+			email.Status = "DEAD"
+			email.NextAttemptAt = nil
+			email.LastError = err.Error()
+		default:
+			next := at.Add(emailRetryBase << (email.Attempts - 1))
+			email.Status = "PENDING"
+			email.NextAttemptAt = &next
+			email.LastError = err.Error()
+		}
+
+		err = e.repos.Emails.UpdateOutboxEmail(ctx, email)
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// SearchCapturedEmails is used for getting emails kept by capture api, newest first.
+func (e *EmailsService) SearchCapturedEmails(ctx context.Context) ([]CapturedEmail, error) {
+	capture, ok := e.apis.Emails.(EmailsCaptureAPI)
+	if !ok {
+		return nil, &Error{Message: "Email capture is disabled"}
+	}
+
+	captured := capture.CapturedEmails()
+	emails := make([]CapturedEmail, 0, len(captured))
+	for i := len(captured) - 1; i >= 0; i-- {
+		emails = append(emails, captured[i])
+	}
+
+	return emails, nil
+}
+
+// ClearCapturedEmails is used for removing emails kept by capture api.
+func (e *EmailsService) ClearCapturedEmails(ctx context.Context) error {
+	capture, ok := e.apis.Emails.(EmailsCaptureAPI)
+	if !ok {
+		return &Error{Message: "Email capture is disabled"}
+	}
+	capture.ClearCapturedEmails()
+
+	return nil
+}
+
+// newOutboxEmail - returns email queued for delivery right away.
+func newOutboxEmail(inp SendEmailInput) *domain.OutboxEmail {
+	now := time.Now()
+	return &domain.OutboxEmail{
+		To:            inp.To,
+		Subject:       inp.Subject,
+		ContentType:   inp.ContentType,
+		Body:          inp.Body,
+		TextBody:      inp.TextBody,
+		Status:        "PENDING",
+		NextAttemptAt: &now,
+	}
+}
+
+// isEmailStatus - reports whether outbox email status is supported.
+func isEmailStatus(status string) bool {
+	for _, s := range EmailStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
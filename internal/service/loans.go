@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	// third party
+	"golang.org/x/crypto/bcrypt"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// LoansService - represents consumer loans service.
+type LoansService struct {
+	repos Repositories
+}
+
+// NewLoanService - creates instance of new loan service.
+func NewLoanService(repos Repositories) *LoansService {
+	return &LoansService{repos}
+}
+
+// SearchLoanProducts is used for search loan products.
+func (l *LoansService) SearchLoanProducts(ctx context.Context, filter *domain.Filter) (*SearchLoanProducts, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	// search loan products from db
+	response, err := l.repos.Loans.SearchLoanProducts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CreateLoanProduct is used for defining new loan product.
+func (l *LoansService) CreateLoanProduct(ctx context.Context, inp *LoanProductInput) (*domain.LoanProduct, error) {
+	if inp.RepaymentType == "" {
+		inp.RepaymentType = "annuity"
+	}
+	if inp.RepaymentType != "annuity" && inp.RepaymentType != "differentiated" {
+		return nil, &Error{Message: "Repayment type must be annuity or differentiated"}
+	}
+	if inp.TermMonths < 1 || inp.Rate < 0 || inp.PenaltyFee < 0 {
+		return nil, &Error{Message: "Wrong loan product terms"}
+	}
+	if inp.MaxAmount > 0 && inp.MaxAmount < inp.MinAmount {
+		return nil, &Error{Message: "Maximum amount is less than minimum amount"}
+	}
+
+	// create loan product in db
+	product, err := l.repos.Loans.CreateLoanProduct(ctx, inp)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// SearchLoans is used for search loans.
+func (l *LoansService) SearchLoans(ctx context.Context, filter *domain.Filter, userId int, permissions domain.Permissions) (*SearchLoans, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	// search loans from db
+	response, err := l.repos.Loans.SearchLoans(ctx, filter, userId, permissions.Has(domain.PermissionLoansReadAll))
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ApplyLoan is used for creating loan application.
+func (l *LoansService) ApplyLoan(ctx context.Context, userId int, inp *ApplyLoanInput) (*domain.Loan, error) {
+	product, err := l.repos.Loans.GetLoanProductByID(ctx, inp.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status != "ACTIVE" {
+		return nil, &Error{Message: "Loan product is not available"}
+	}
+	if inp.Amount <= 0 || inp.Amount < product.MinAmount || (product.MaxAmount > 0 && inp.Amount > product.MaxAmount) {
+		return nil, &Error{Message: fmt.Sprintf("Loan amount must be between %0.2f and %0.2f", product.MinAmount, product.MaxAmount)}
+	}
+
+	// check disbursement account
+	account, err := l.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountPayer(ctx, l.repos, account, userId)
+	if err != nil {
+		return nil, err
+	}
+	if account.Type != "CURRENT" || account.Status != "ACTIVE" {
+		return nil, &Error{Message: "Loan can be disbursed only to active current account"}
+	}
+
+	// create loan application in db
+	loan, err := l.repos.Loans.CreateLoan(ctx, &domain.Loan{
+		ProductID:     product.ID,
+		BankAccountID: account.ID,
+		Principal:     utils.RoundMoney(inp.Amount),
+		Rate:          product.Rate,
+		TermMonths:    product.TermMonths,
+		RepaymentType: product.RepaymentType,
+		PenaltyFee:    product.PenaltyFee,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// ApproveLoan is used for approving loan application and disbursing principal.
+func (l *LoansService) ApproveLoan(ctx context.Context, loanId int) (*domain.Loan, error) {
+	loan, err := l.repos.Loans.GetLoanByID(ctx, loanId)
+	if err != nil {
+		return nil, err
+	}
+	if loan.Status != "PENDING" {
+		return nil, &Error{Message: "Loan is not pending"}
+	}
+
+	// build amortization schedule
+	start := time.Now()
+	dueDates := make([]time.Time, loan.TermMonths)
+	for i := range dueDates {
+		dueDates[i] = start.AddDate(0, i+1, 0)
+	}
+	schedule := buildLoanSchedule(loan, loan.Principal, dueDates, 1)
+
+	err = l.repos.Loans.DisburseLoan(ctx, loan, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.repos.Loans.GetLoanByID(ctx, loanId)
+}
+
+// RejectLoan is used for rejecting loan application.
+func (l *LoansService) RejectLoan(ctx context.Context, loanId int) (*domain.Loan, error) {
+	err := l.repos.Loans.RejectLoan(ctx, loanId)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.repos.Loans.GetLoanByID(ctx, loanId)
+}
+
+// GetLoanSchedule is used for getting loan amortization schedule.
+func (l *LoansService) GetLoanSchedule(ctx context.Context, userId int, permissions domain.Permissions, loanId int) ([]domain.LoanInstallment, error) {
+	loan, err := l.repos.Loans.GetLoanByID(ctx, loanId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !permissions.Has(domain.PermissionLoansReadAll) {
+		_, err = l.repos.Banks.GetAccountMember(ctx, loan.BankAccountID, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l.repos.Loans.GetLoanSchedule(ctx, loan.ID)
+}
+
+// PrepayLoan is used for settling overdue installments and repaying principal early.
+func (l *LoansService) PrepayLoan(ctx context.Context, userId int, inp *PrepayLoanInput) (*domain.Loan, error) {
+	loan, err := l.repos.Loans.GetLoanByID(ctx, inp.LoanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.Status != "ACTIVE" {
+		return nil, &Error{Message: "Loan is not active"}
+	}
+
+	// check owner and secret value
+	account, err := l.repos.Banks.GetBankAccountByID(ctx, loan.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountPayer(ctx, l.repos, account, userId)
+	if err != nil {
+		return nil, err
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(account.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+
+	schedule, err := l.repos.Loans.GetLoanSchedule(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// due installments are settled first, the rest reduces principal
+	now := time.Now()
+	var overdue []domain.LoanInstallment
+	var dueDates []time.Time
+	firstNumber := 0
+	overdueTotal, overduePrincipal := 0.0, 0.0
+	for _, installment := range schedule {
+		switch {
+		case installment.Status == "PAID":
+		case installment.Status == "OVERDUE" || !installment.DueDate.After(now):
+			overdue = append(overdue, installment)
+			overdueTotal += installment.AmountDue()
+			overduePrincipal += installment.Principal
+		default:
+			if firstNumber == 0 {
+				firstNumber = installment.Number
+			}
+			dueDates = append(dueDates, installment.DueDate)
+		}
+	}
+
+	amount := utils.RoundMoney(inp.Amount)
+	if amount <= 0 || amount < overdueTotal {
+		return nil, &Error{Message: fmt.Sprintf("Amount must cover overdue installments of %0.2f", overdueTotal)}
+	}
+	remaining := utils.RoundMoney(loan.Outstanding - overduePrincipal)
+	principal := math.Min(utils.RoundMoney(amount-overdueTotal), remaining)
+	if account.Balance < overdueTotal+principal {
+		return nil, ErrInsufficientFunds
+	}
+
+	var newSchedule []domain.LoanInstallment
+	if principal > 0 && remaining-principal > 0 && len(dueDates) > 0 {
+		newSchedule = buildLoanSchedule(loan, utils.RoundMoney(remaining-principal), dueDates, firstNumber)
+	}
+
+	err = l.repos.Loans.PrepayLoan(ctx, &PrepayLoanRepoInput{
+		Loan:      loan,
+		Overdue:   overdue,
+		Principal: principal,
+		Schedule:  newSchedule,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l.repos.Loans.GetLoanByID(ctx, loan.ID)
+}
+
+// ProcessLoanInstallments is used for auto-debiting due installments and tracking overdue ones.
+// Failed installment does not stop the run, failures are returned together after all installments and retried by the next run.
+func (l *LoansService) ProcessLoanInstallments(ctx context.Context, now time.Time) (int, error) {
+	installments, err := l.repos.Loans.GetDueInstallments(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	loans := map[int]*domain.Loan{}
+	paid := 0
+	var failures []string
+	for i := range installments {
+		installment := &installments[i]
+		loan, ok := loans[installment.LoanID]
+		if !ok {
+			loan, err = l.repos.Loans.GetLoanByID(ctx, installment.LoanID)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("installment #%d: %v", installment.ID, err))
+				continue
+			}
+			loans[loan.ID] = loan
+		}
+
+		err = l.repos.Loans.PayInstallment(ctx, loan, installment)
+		if err == nil {
+			paid++
+			continue
+		}
+		if !errors.Is(err, ErrInsufficientFunds) {
+			// closed business day or installment paid meanwhile, retried by the next run
+			if _, ok := err.(*Error); !ok {
+				failures = append(failures, fmt.Sprintf("installment #%d: %v", installment.ID, err))
+			}
+			continue
+		}
+
+		// not enough funds or account is not active, charge penalty fee once
+		if installment.Status == "SCHEDULED" {
+			err = l.repos.Loans.MarkInstallmentOverdue(ctx, installment, loan.PenaltyFee)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("installment #%d: %v", installment.ID, err))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return paid, fmt.Errorf("failed to process %d of %d due installments: %s",
+			len(failures), len(installments), strings.Join(failures, "; "))
+	}
+
+	return paid, nil
+}
+
+// buildLoanSchedule - builds annuity or differentiated schedule of principal over due dates.
+func buildLoanSchedule(loan *domain.Loan, principal float64, dueDates []time.Time, firstNumber int) []domain.LoanInstallment {
+	n := len(dueDates)
+	rate := loan.Rate / 100 / 12
+
+	annuity := principal / float64(n)
+	if rate > 0 {
+		annuity = principal * rate / (1 - math.Pow(1+rate, -float64(n)))
+	}
+
+	schedule := make([]domain.LoanInstallment, 0, n)
+	balance := principal
+	for i, dueDate := range dueDates {
+		interest := utils.RoundMoney(balance * rate)
+
+		var part float64
+		switch {
+		case i == n-1:
+			part = balance
+		case loan.RepaymentType == "differentiated":
+			part = utils.RoundMoney(principal / float64(n))
+		default:
+			part = utils.RoundMoney(annuity - interest)
+		}
+		balance = utils.RoundMoney(balance - part)
+
+		schedule = append(schedule, domain.LoanInstallment{
+			LoanID:    loan.ID,
+			Number:    firstNumber + i,
+			DueDate:   dueDate,
+			Principal: utils.RoundMoney(part),
+			Interest:  interest,
+			Status:    "SCHEDULED",
+		})
+	}
+
+	return schedule
+}
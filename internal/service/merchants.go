@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	// third party
+	"golang.org/x/crypto/bcrypt"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+const (
+	// apiKeyPrefix - marks merchant api keys, so they are not mistaken for jwt tokens.
+	apiKeyPrefix = "psk"
+	// apiKeyTouchInterval - how often last usage time of api key is updated.
+	apiKeyTouchInterval = time.Minute
+)
+
+// MerchantsService - represents merchants service.
+type MerchantsService struct {
+	repos Repositories
+}
+
+// NewMerchantsService - creates instance of new merchants service.
+func NewMerchantsService(repos Repositories) *MerchantsService {
+	return &MerchantsService{repos}
+}
+
+// CreateMerchant is used for creating merchant profile settled to user current account.
+func (m *MerchantsService) CreateMerchant(ctx context.Context, userId int, inp *MerchantInput) (*domain.Merchant, error) {
+	if strings.TrimSpace(inp.Name) == "" {
+		return nil, &Error{Message: "Merchant name is required"}
+	}
+	if inp.Category != "" && !domain.IsPaymentCategory(inp.Category) {
+		return nil, &Error{Message: "Unknown category, allowed: " + strings.Join(domain.PaymentCategories, ", ")}
+	}
+
+	account, err := m.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if account.Type != "CURRENT" || account.Status == "CLOSED" {
+		return nil, &Error{Message: "Settlement account must be open current account"}
+	}
+
+	member, err := m.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !member.IsOwner() {
+		return nil, &Error{Message: "Only owner can use bank account for settlement"}
+	}
+
+	// check secret value
+	err = bcrypt.CompareHashAndPassword([]byte(account.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+
+	return m.repos.Merchants.CreateMerchant(ctx, &domain.Merchant{
+		UserID:              userId,
+		Name:                strings.TrimSpace(inp.Name),
+		SettlementAccountID: account.ID,
+		Category:            inp.Category,
+	})
+}
+
+// SearchMerchants is used for getting user merchants.
+func (m *MerchantsService) SearchMerchants(ctx context.Context, userId int) ([]domain.Merchant, error) {
+	return m.repos.Merchants.SearchMerchants(ctx, userId)
+}
+
+// CreateAPIKey is used for issuing merchant api key.
+// Plain key is returned only here, database keeps its hash.
+func (m *MerchantsService) CreateAPIKey(ctx context.Context, userId int, inp *APIKeyInput) (*APIKeyOutput, error) {
+	merchant, err := getUserMerchant(ctx, m.repos, userId, inp.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status != "ACTIVE" {
+		return nil, &Error{Message: "Merchant is disabled"}
+	}
+
+	scopes, err := normalizeScopes(inp.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, key, err := newAPIKey(merchant.ID, inp.Name, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = m.repos.Merchants.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIKeyOutput{Key: plain, APIKey: key}, nil
+}
+
+// SearchAPIKeys is used for getting merchant api keys.
+func (m *MerchantsService) SearchAPIKeys(ctx context.Context, userId int, merchantId int) ([]domain.MerchantAPIKey, error) {
+	merchant, err := getUserMerchant(ctx, m.repos, userId, merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.repos.Merchants.SearchAPIKeys(ctx, merchant.ID)
+}
+
+// RotateAPIKey is used for replacing merchant api key with new one with the same name and scopes.
+// Old key stops working immediately.
+func (m *MerchantsService) RotateAPIKey(ctx context.Context, userId int, keyId int) (*APIKeyOutput, error) {
+	old, err := m.getUserAPIKey(ctx, userId, keyId)
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil {
+		return nil, &Error{Message: "API key is already revoked"}
+	}
+
+	plain, key, err := newAPIKey(old.MerchantID, old.Name, strings.Split(old.Scopes, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = m.repos.Merchants.RotateAPIKey(ctx, old.ID, key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIKeyOutput{Key: plain, APIKey: key}, nil
+}
+
+// RevokeAPIKey is used for revoking merchant api key.
+func (m *MerchantsService) RevokeAPIKey(ctx context.Context, userId int, keyId int) error {
+	key, err := m.getUserAPIKey(ctx, userId, keyId)
+	if err != nil {
+		return err
+	}
+
+	return m.repos.Merchants.RevokeAPIKey(ctx, key.ID, time.Now())
+}
+
+// VerifyAPIKey is used to verify merchant api key.
+// Returned key has its merchant loaded, keys of disabled merchants and locked owners are rejected.
+func (m *MerchantsService) VerifyAPIKey(ctx context.Context, plain string) (*domain.MerchantAPIKey, bool) {
+	parts := strings.Split(plain, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, false
+	}
+
+	key, err := m.repos.Merchants.GetAPIKeyByPrefix(ctx, parts[0]+"_"+parts[1])
+	if err != nil {
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(plain)), []byte(key.KeyHash)) != 1 {
+		return nil, false
+	}
+	if key.RevokedAt != nil || key.Merchant == nil || key.Merchant.Status != "ACTIVE" {
+		return nil, false
+	}
+	if key.Merchant.User == nil || key.Merchant.User.Status != "ACTIVE" {
+		return nil, false
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		// usage time is informational, so failed update does not reject request
+		_ = m.repos.Merchants.TouchAPIKey(ctx, key.ID, now)
+	}
+
+	return key, true
+}
+
+// getUserMerchant - returns merchant if it belongs to user.
+// Api key request gets only merchant of the key.
+func getUserMerchant(ctx context.Context, repos Repositories, userId int, merchantId int) (*domain.Merchant, error) {
+	if keyMerchant := contextMerchant(ctx); keyMerchant != nil && keyMerchant.ID != merchantId {
+		return nil, &Error{Message: "Merchant not found"}
+	}
+	merchant, err := repos.Merchants.GetMerchantByID(ctx, merchantId)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.UserID != userId {
+		return nil, &Error{Message: "Merchant not found"}
+	}
+
+	return merchant, nil
+}
+
+// merchantKey - context key of merchant whose api key authenticated request.
+type merchantKey struct{}
+
+// WithMerchant - returns context of request authenticated by merchant api key.
+// Services restrict such request to merchant settlement account.
+func WithMerchant(ctx context.Context, merchant *domain.Merchant) context.Context {
+	return context.WithValue(ctx, merchantKey{}, merchant)
+}
+
+// contextMerchant - returns merchant of api key request, nil for request of user.
+func contextMerchant(ctx context.Context) *domain.Merchant {
+	merchant, _ := ctx.Value(merchantKey{}).(*domain.Merchant)
+	return merchant
+}
+
+// checkMerchantAccount - rejects api key request to bank account other than merchant settlement account.
+func checkMerchantAccount(ctx context.Context, accountId int) error {
+	merchant := contextMerchant(ctx)
+	if merchant != nil && merchant.SettlementAccountID != accountId {
+		return &Error{Message: "API key gives access only to merchant settlement account"}
+	}
+
+	return nil
+}
+
+// merchantAccountIBAN - returns iban of merchant settlement account for api key request,
+// requested iban for request of user.
+func merchantAccountIBAN(ctx context.Context, repos Repositories, iban string) (string, error) {
+	merchant := contextMerchant(ctx)
+	if merchant == nil {
+		return iban, nil
+	}
+	account, err := repos.Banks.GetBankAccountByID(ctx, merchant.SettlementAccountID)
+	if err != nil {
+		return "", err
+	}
+	if iban != "" && iban != account.IBAN {
+		return "", &Error{Message: "API key gives access only to merchant settlement account"}
+	}
+
+	return account.IBAN, nil
+}
+
+// getUserAPIKey - returns api key if its merchant belongs to user.
+func (m *MerchantsService) getUserAPIKey(ctx context.Context, userId int, keyId int) (*domain.MerchantAPIKey, error) {
+	key, err := m.repos.Merchants.GetAPIKeyByID(ctx, keyId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = getUserMerchant(ctx, m.repos, userId, key.MerchantID)
+	if err != nil {
+		return nil, &Error{Message: "API key not found"}
+	}
+
+	return key, nil
+}
+
+// newAPIKey - generates plain api key "psk_<prefix>_<secret>" and its stored form.
+func newAPIKey(merchantId int, name string, scopes []string) (string, *domain.MerchantAPIKey, error) {
+	prefix := make([]byte, 6)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	visible := apiKeyPrefix + "_" + hex.EncodeToString(prefix)
+	plain := visible + "_" + hex.EncodeToString(secret)
+
+	return plain, &domain.MerchantAPIKey{
+		MerchantID: merchantId,
+		Name:       name,
+		Prefix:     visible,
+		KeyHash:    hashAPIKey(plain),
+		Scopes:     strings.Join(scopes, ","),
+	}, nil
+}
+
+// hashAPIKey - returns hex sha256 hash of plain api key.
+// Keys are random, so unsalted fast hash is enough.
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes - checks scopes and returns them sorted without duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, &Error{Message: "At least one scope is required"}
+	}
+	set := map[string]bool{}
+	for _, scope := range scopes {
+		known := false
+		for _, s := range domain.APIKeyScopes {
+			if s == scope {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, &Error{Message: "Unknown scope " + scope + ", allowed: " + strings.Join(domain.APIKeyScopes, ", ")}
+		}
+		set[scope] = true
+	}
+	list := make([]string, 0, len(set))
+	for scope := range set {
+		list = append(list, scope)
+	}
+	sort.Strings(list)
+
+	return list, nil
+}
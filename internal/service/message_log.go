@@ -26,6 +26,7 @@ func (m *MessageLogsService) CreateMessageLog(ctx context.Context, userId int, i
 
 	// create message log in db
 	message, err := m.repos.Messages.CreateMessageLog(ctx, &MessageLogInput{
+		ClientID:   client.ID,
 		Client:     client.FullName,
 		MessageLog: inp.MessageLog,
 	})
@@ -37,14 +38,14 @@ func (m *MessageLogsService) CreateMessageLog(ctx context.Context, userId int, i
 }
 
 // SearchLogs is used for search logs.
-func (m *MessageLogsService) SearchLogs(ctx context.Context, filter *domain.Filter, client string, role string) (*SearchLogs, error) {
+func (m *MessageLogsService) SearchLogs(ctx context.Context, filter *domain.Filter, userId int, permissions domain.Permissions) (*SearchLogs, error) {
 	if filter == nil {
 		filter = new(domain.Filter)
 		filter.Validate()
 	}
 
 	// search logs from db
-	response, err := m.repos.Messages.SearchLogs(ctx, filter, client, role)
+	response, err := m.repos.Messages.SearchLogs(ctx, filter, userId, permissions.Has(domain.PermissionLogsReadAll))
 	if err != nil {
 		return nil, err
 	}
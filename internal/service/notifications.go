@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/templates"
+)
+
+const (
+	// notificationMaxAttempts - number of attempts after which sms or push delivery is failed.
+	notificationMaxAttempts = 5
+	// notificationRetryBase - delay before the second attempt, doubled for every next one.
+	notificationRetryBase = 30 * time.Second
+	// notificationBatchSize - number of deliveries sent by one run.
+	notificationBatchSize = 100
+)
+
+// NotificationsService - represents user notifications service.
+type NotificationsService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewNotificationsService - creates instance of new notifications service.
+func NewNotificationsService(repos Repositories, apis APIs) *NotificationsService {
+	return &NotificationsService{repos, apis}
+}
+
+// SearchNotifications is used for getting user inbox notifications.
+func (n *NotificationsService) SearchNotifications(ctx context.Context, filter *domain.Filter, userId int, unread bool) (*SearchNotifications, error) {
+	return n.repos.Notifications.SearchNotifications(ctx, filter, userId, unread)
+}
+
+// MarkNotificationsRead is used for marking user notifications read, all of them when ids are empty.
+func (n *NotificationsService) MarkNotificationsRead(ctx context.Context, userId int, ids []int) (int64, error) {
+	return n.repos.Notifications.MarkNotificationsRead(ctx, userId, ids, time.Now())
+}
+
+// GetNotificationPreferences is used for getting channels enabled for every event.
+func (n *NotificationsService) GetNotificationPreferences(ctx context.Context, userId int) ([]domain.NotificationPreference, error) {
+	stored, err := n.repos.Notifications.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]domain.NotificationPreference, 0, len(domain.NotificationEvents))
+	for _, event := range domain.NotificationEvents {
+		preferences = append(preferences, notificationPreference(stored, userId, event))
+	}
+
+	return preferences, nil
+}
+
+// UpdateNotificationPreferences is used for enabling or disabling channels of events.
+func (n *NotificationsService) UpdateNotificationPreferences(ctx context.Context, userId int, inp []NotificationPreferenceInput) ([]domain.NotificationPreference, error) {
+	stored, err := n.repos.Notifications.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]domain.NotificationPreference, 0, len(inp))
+	for _, p := range inp {
+		if !domain.IsNotificationEvent(p.Event) {
+			return nil, &Error{Message: "Unknown event, allowed: " + strings.Join(domain.NotificationEvents, ", ")}
+		}
+		preference := notificationPreference(stored, userId, p.Event)
+		preference.Email = p.Email
+		preference.Inbox = p.Inbox
+		if p.SMS != nil {
+			preference.SMS = *p.SMS
+		}
+		if p.Push != nil {
+			preference.Push = *p.Push
+		}
+		preferences = append(preferences, preference)
+	}
+
+	err = n.repos.Notifications.SaveNotificationPreferences(ctx, preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.GetNotificationPreferences(ctx, userId)
+}
+
+// PreviewEmail is used for rendering email template in language with sample data.
+func (n *NotificationsService) PreviewEmail(ctx context.Context, name string, language string) (*templates.Email, error) {
+	if !templates.IsLanguage(language) {
+		return nil, &Error{Message: "Unknown language, allowed: " + strings.Join(templates.Languages, ", ")}
+	}
+	email, err := templates.Preview(name, language)
+	if err != nil {
+		return nil, &Error{Message: "Unknown template, allowed: " + strings.Join(templates.Names, ", ")}
+	}
+
+	return email, nil
+}
+
+// RegisterDevice is used for registering device token push notifications are sent to.
+func (n *NotificationsService) RegisterDevice(ctx context.Context, userId int, inp *DeviceInput) (*domain.DeviceToken, error) {
+	if strings.TrimSpace(inp.Token) == "" || len(inp.Token) > 255 {
+		return nil, &Error{Message: "Device token must be from 1 to 255 characters"}
+	}
+	if !isDevicePlatform(inp.Platform) {
+		return nil, &Error{Message: "Unknown platform, allowed: " + strings.Join(domain.DevicePlatforms, ", ")}
+	}
+
+	return n.repos.Notifications.SaveDeviceToken(ctx, &domain.DeviceToken{
+		UserID:   userId,
+		Token:    inp.Token,
+		Platform: inp.Platform,
+	})
+}
+
+// SearchDevices is used for getting devices of user.
+func (n *NotificationsService) SearchDevices(ctx context.Context, userId int) ([]domain.DeviceToken, error) {
+	return n.repos.Notifications.GetDeviceTokens(ctx, userId)
+}
+
+// DeleteDevice is used for removing device, so push notifications are not sent to it.
+func (n *NotificationsService) DeleteDevice(ctx context.Context, userId int, deviceId int) error {
+	return n.repos.Notifications.DeleteDeviceToken(ctx, userId, deviceId)
+}
+
+// SearchNotificationDeliveries is used for getting results of sms and push deliveries of user.
+func (n *NotificationsService) SearchNotificationDeliveries(ctx context.Context, filter *domain.Filter, userId int, channel string) (*SearchNotificationDeliveries, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+	if channel != "" && !isNotificationChannel(channel) {
+		return nil, &Error{Message: "Unknown channel, allowed: " + strings.Join(domain.NotificationChannels, ", ")}
+	}
+
+	return n.repos.Notifications.SearchNotificationDeliveries(ctx, filter, userId, channel)
+}
+
+// DeliverNotifications is used for sending due sms and push deliveries and scheduling retries with exponential backoff.
+func (n *NotificationsService) DeliverNotifications(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := n.repos.Notifications.GetDueNotificationDeliveries(ctx, now, notificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		channel := notificationChannel(n.apis, delivery.Channel)
+		if channel == nil {
+			delivery.Status = "FAILED"
+			delivery.NextAttemptAt = nil
+			delivery.LastError = "channel disabled"
+			if err := n.repos.Notifications.UpdateNotificationDelivery(ctx, delivery); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		err := channel.SendNotification(ctx, SendNotificationInput{
+			To:         delivery.Recipient,
+			Event:      delivery.Event,
+			Title:      delivery.Title,
+			Body:       delivery.Body,
+			DeliveryID: delivery.ID,
+		})
+
+		at := time.Now()
+		delivery.Attempts++
+		switch {
+		case err == nil:
+			delivery.Status = "SENT"
+			delivery.SentAt = &at
+			delivery.NextAttemptAt = nil
+			delivery.LastError = ""
+			sent++
+		case delivery.Attempts >= notificationMaxAttempts:
+			delivery.Status = "FAILED"
+			delivery.NextAttemptAt = nil
+			delivery.LastError = err.Error()
+		default:
+			next := at.Add(notificationRetryBase << (delivery.Attempts - 1))
+			delivery.NextAttemptAt = &next
+			delivery.LastError = err.Error()
+		}
+
+		err = n.repos.Notifications.UpdateNotificationDelivery(ctx, delivery)
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// notificationPreference - returns stored preference of event or default one with every channel enabled.
+func notificationPreference(stored []domain.NotificationPreference, userId int, event string) domain.NotificationPreference {
+	for _, p := range stored {
+		if p.Event == event {
+			return p
+		}
+	}
+
+	return domain.NotificationPreference{
+		UserID: userId,
+		Event:  event,
+		Email:  true,
+		Inbox:  true,
+		SMS:    true,
+		Push:   true,
+	}
+}
+
+// notifyUser - sends notification of event through channels user enabled for it.
+// Emails are queued to outbox, sms and push deliveries are queued only for configured channels.
+// Callers queue notification in transaction of the change it reports, so email is queued only
+// with committed change and change is rolled back when email can not be queued.
+func notifyUser(ctx context.Context, repos Repositories, apis APIs, userId int, event string, title string, body string) error {
+	stored, err := repos.Notifications.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return err
+	}
+	preference := notificationPreference(stored, userId, event)
+
+	var notification *domain.Notification
+	if preference.Inbox {
+		notification = &domain.Notification{
+			UserID: userId,
+			Event:  event,
+			Title:  title,
+			Body:   body,
+		}
+	}
+
+	user, err := repos.Users.GetUserByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	var outbox *domain.OutboxEmail
+	if preference.Email {
+		email, err := templates.Render(templates.Notification, user.Language, templates.NotificationData{
+			FullName: user.FullName,
+			Event:    event,
+			Title:    title,
+			Body:     body,
+		})
+		if err != nil {
+			return err
+		}
+		outbox = newOutboxEmail(SendEmailInput{
+			To:          user.Email,
+			Subject:     email.Subject,
+			ContentType: "text/html",
+			Body:        email.HTML,
+			TextBody:    email.Text,
+		})
+	}
+
+	now := time.Now()
+	var deliveries []domain.NotificationDelivery
+	delivery := func(channel string, recipient string) domain.NotificationDelivery {
+		return domain.NotificationDelivery{
+			UserID:        userId,
+			Event:         event,
+			Channel:       channel,
+			Recipient:     recipient,
+			Title:         title,
+			Body:          body,
+			Status:        "PENDING",
+			NextAttemptAt: &now,
+		}
+	}
+	if preference.SMS && user.Phone != "" && notificationChannel(apis, "sms") != nil {
+		deliveries = append(deliveries, delivery("sms", user.Phone))
+	}
+	if preference.Push && notificationChannel(apis, "push") != nil {
+		devices, err := repos.Notifications.GetDeviceTokens(ctx, userId)
+		if err != nil {
+			return err
+		}
+		for _, device := range devices {
+			deliveries = append(deliveries, delivery("push", device.Token))
+		}
+	}
+
+	if notification == nil && outbox == nil && len(deliveries) == 0 {
+		return nil
+	}
+	return repos.Notifications.CreateNotification(ctx, notification, outbox, deliveries)
+}
+
+// notifyAccountMembers - notifies every member of bank account about event.
+func notifyAccountMembers(ctx context.Context, repos Repositories, apis APIs, accountId int, event string, title string, body string) error {
+	members, err := repos.Banks.SearchAccountMembers(ctx, accountId)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		err = notifyUser(ctx, repos, apis, member.UserID, event, title, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyPaymentSent - notifies members of payer account about sent payment and members of internal recipient account about received one.
+func notifyPaymentSent(ctx context.Context, repos Repositories, apis APIs, payment *domain.Payment, payerId int, recipient *domain.BankAccount) error {
+	err := notifyAccountMembers(ctx, repos, apis, payerId, "payment.sent", "Payment sent",
+		fmt.Sprintf("Payment #%d of %.2f from %s to %s (%s) was sent: %s",
+			payment.ID, payment.OperationAmount, payment.FromClientIBAN, payment.ToClient, payment.ToClientIBAN, payment.Description))
+	if err != nil || recipient == nil {
+		return err
+	}
+
+	return notifyAccountMembers(ctx, repos, apis, recipient.ID, "payment.received", "Payment received",
+		fmt.Sprintf("%.2f received on %s from %s (%s): %s",
+			payment.OperationAmount, recipient.IBAN, payment.FromClient, payment.FromClientIBAN, payment.Description))
+}
+
+// notificationChannel - returns configured api of channel or nil when channel is disabled.
+func notificationChannel(apis APIs, channel string) NotificationChannel {
+	for _, c := range apis.Channels {
+		if c.Channel() == channel {
+			return c
+		}
+	}
+	return nil
+}
+
+// isNotificationChannel - reports whether notification channel is supported.
+func isNotificationChannel(channel string) bool {
+	for _, c := range domain.NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// isDevicePlatform - reports whether device platform is supported.
+func isDevicePlatform(platform string) bool {
+	for _, p := range domain.DevicePlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	// third party
+	"golang.org/x/crypto/bcrypt"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// PaymentLinksService - represents merchant payment links service.
+type PaymentLinksService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewPaymentLinksService - creates instance of new payment links service.
+func NewPaymentLinksService(repos Repositories, apis APIs) *PaymentLinksService {
+	return &PaymentLinksService{repos, apis}
+}
+
+// CreatePaymentLink is used for creating checkout link of user merchant.
+func (p *PaymentLinksService) CreatePaymentLink(ctx context.Context, userId int, inp *PaymentLinkInput) (*domain.PaymentLink, error) {
+	merchant, err := getUserMerchant(ctx, p.repos, userId, inp.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status != "ACTIVE" {
+		return nil, &Error{Message: "Merchant is disabled"}
+	}
+
+	amount := utils.RoundMoney(inp.Amount)
+	if amount <= 0 {
+		return nil, &Error{Message: "Payment link amount must be positive"}
+	}
+	if inp.ExpiresAt != nil && !inp.ExpiresAt.After(time.Now()) {
+		return nil, &Error{Message: "Payment link expiry must be in the future"}
+	}
+
+	code := make([]byte, 16)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+
+	return p.repos.PaymentLinks.CreatePaymentLink(ctx, &domain.PaymentLink{
+		MerchantID:  merchant.ID,
+		Code:        base64.RawURLEncoding.EncodeToString(code),
+		Amount:      amount,
+		Description: inp.Description,
+		ExpiresAt:   inp.ExpiresAt,
+		MultiUse:    inp.MultiUse,
+	})
+}
+
+// SearchPaymentLinks is used for getting payment links of user merchant.
+func (p *PaymentLinksService) SearchPaymentLinks(ctx context.Context, filter *domain.Filter, userId int, merchantId int) (*SearchPaymentLinks, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	merchant, err := getUserMerchant(ctx, p.repos, userId, merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := p.repos.PaymentLinks.SearchPaymentLinks(ctx, filter, merchant.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range response.Data {
+		response.Data[i].Status = response.Data[i].CurrentStatus(now)
+	}
+
+	return response, nil
+}
+
+// DisablePaymentLink is used for disabling payment link of user merchant.
+func (p *PaymentLinksService) DisablePaymentLink(ctx context.Context, userId int, linkId int) error {
+	link, err := p.repos.PaymentLinks.GetPaymentLinkByID(ctx, linkId)
+	if err != nil {
+		return err
+	}
+	_, err = getUserMerchant(ctx, p.repos, userId, link.MerchantID)
+	if err != nil {
+		return &Error{Message: "Payment link not found"}
+	}
+
+	return p.repos.PaymentLinks.DisablePaymentLink(ctx, link.ID)
+}
+
+// GetPaymentLink is used for opening payment link by its public code.
+func (p *PaymentLinksService) GetPaymentLink(ctx context.Context, code string) (*PaymentLinkOutput, error) {
+	link, err := p.repos.PaymentLinks.GetPaymentLinkByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentLinkOutput{
+		Code:        link.Code,
+		Merchant:    link.Merchant.Name,
+		Amount:      link.Amount,
+		Description: link.Description,
+		ExpiresAt:   link.ExpiresAt,
+		MultiUse:    link.MultiUse,
+		Status:      link.CurrentStatus(time.Now()),
+	}, nil
+}
+
+// PayPaymentLink is used for paying payment link from customer bank account.
+// Funds are credited to merchant settlement account and payment_link.paid event is sent to merchant.
+func (p *PaymentLinksService) PayPaymentLink(ctx context.Context, userId int, inp *PayPaymentLinkInput) (*PaymentOutput, error) {
+	link, err := p.repos.PaymentLinks.GetPaymentLinkByCode(ctx, inp.Code)
+	if err != nil {
+		return nil, err
+	}
+	if status := link.CurrentStatus(time.Now()); status != "ACTIVE" {
+		return nil, &Error{Message: "Payment link is " + status}
+	}
+	if link.Merchant.Status != "ACTIVE" {
+		return nil, &Error{Message: "Merchant is disabled"}
+	}
+
+	payer, err := p.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if payer.Type == "DEPOSIT" {
+		return nil, &Error{Message: "Payments from deposit account are not allowed"}
+	}
+	if payer.Status != "ACTIVE" {
+		return nil, &Error{Message: "Bank account is not active"}
+	}
+	if payer.ID == link.Merchant.SettlementAccountID {
+		return nil, &Error{Message: "Payment link cannot be paid from merchant settlement account"}
+	}
+
+	// check membership
+	err = checkAccountPayer(ctx, p.repos, payer, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// check secret value
+	err = bcrypt.CompareHashAndPassword([]byte(payer.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+
+	settlement, err := p.repos.Banks.GetBankAccountByID(ctx, link.Merchant.SettlementAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if settlement.Status == "CLOSED" {
+		return nil, &Error{Message: "Merchant bank account is closed"}
+	}
+
+	var payment *domain.Payment
+	err = p.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		payment, err = p.repos.PaymentLinks.PayPaymentLink(ctx, &PayPaymentLinkRepoInput{
+			Link:       link,
+			Payer:      payer,
+			Settlement: settlement,
+		})
+		if err != nil {
+			return err
+		}
+		return notifyPaymentSent(ctx, p.repos, p.apis, payment, payer.ID, settlement)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := &PaymentOutput{
+		ID:                   payment.ID,
+		PaymentStatus:        payment.PaymentStatus,
+		FromClientID:         payment.FromClientID,
+		FromClient:           payment.FromClient,
+		FromClientITN:        payment.FromClientITN,
+		FromClientIBAN:       payment.FromClientIBAN,
+		FromClientCardNumber: payment.FromClientCardNumber,
+		Description:          payment.Description,
+		ToClientIBAN:         payment.ToClientIBAN,
+		ToClient:             payment.ToClient,
+		OperationAmount:      payment.OperationAmount,
+		Direction:            "outgoing",
+		CreatedAt:            &payment.CreatedAt,
+	}
+	publishEvent(ctx, p.repos, "payment.sent", payer.ID, output)
+	publishEvent(ctx, p.repos, "payment_link.paid", settlement.ID, map[string]interface{}{
+		"paymentLinkId":   link.ID,
+		"code":            link.Code,
+		"merchantId":      link.MerchantID,
+		"paymentId":       payment.ID,
+		"fromClientIban":  payment.FromClientIBAN,
+		"operationAmount": payment.OperationAmount,
+	})
+	trackBudgets(ctx, p.repos, p.apis, payer.ID)
+
+	return output, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	// third party
+	"github.com/skip2/go-qrcode"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/qrpay"
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// qrImageSize - side of generated qr png in pixels.
+const qrImageSize = 256
+
+// GeneratePaymentQR is used for generating qr payload and png image to pay into bank account.
+func (p *PaymentsService) GeneratePaymentQR(ctx context.Context, userId int, permissions domain.Permissions, inp *PaymentQRInput) (*PaymentQROutput, error) {
+	format := inp.Format
+	if format == "" {
+		format = qrpay.FormatNBU
+	}
+	if format != qrpay.FormatNBU && format != qrpay.FormatEMV {
+		return nil, &Error{Message: "Unknown qr format, allowed: " + qrpay.FormatNBU + ", " + qrpay.FormatEMV}
+	}
+	amount := utils.RoundMoney(inp.Amount)
+	if amount < 0 {
+		return nil, &Error{Message: "QR amount must not be negative"}
+	}
+
+	account, err := p.repos.Banks.GetInfoByIBAN(ctx, inp.IBAN)
+	if err != nil {
+		return nil, err
+	}
+	if account.Type == "DEPOSIT" || account.Status == "CLOSED" {
+		return nil, &Error{Message: "Bank account does not accept payments"}
+	}
+	err = checkMerchantAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check user permission
+	if !permissions.Has(domain.PermissionAccountsReadAll) {
+		_, err = p.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := qrpay.Encode(format, qrpay.Payload{
+		Name:    account.Client,
+		IBAN:    account.IBAN,
+		Code:    strconv.FormatInt(account.ITN, 10),
+		Amount:  amount,
+		Purpose: inp.Purpose,
+	})
+	if err != nil {
+		return nil, &Error{Message: err.Error()}
+	}
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, qrImageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentQROutput{
+		Format:  format,
+		Payload: payload,
+		PNG:     png,
+	}, nil
+}
+
+// ParsePaymentQR is used for turning scanned qr payload into prefilled payment.
+// Payer account is left empty for client to choose.
+func (p *PaymentsService) ParsePaymentQR(ctx context.Context, payload string) (*PaymentInput, error) {
+	data, _, err := qrpay.Decode(payload)
+	if err != nil {
+		return nil, &Error{Message: "Unsupported or malformed qr payload"}
+	}
+
+	return &PaymentInput{
+		Description:     data.Purpose,
+		ToClientIBAN:    data.IBAN,
+		ToClient:        data.Name,
+		OperationAmount: data.Amount,
+	}, nil
+}
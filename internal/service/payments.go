@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 
 	// third party
 	"golang.org/x/crypto/bcrypt"
@@ -14,22 +15,54 @@ import (
 // PaymentsService - represents payments service.
 type PaymentsService struct {
 	repos Repositories
+	apis  APIs
 }
 
 // NewPaymentService - creates instance of new payment service.
-func NewPaymentService(repos Repositories) *PaymentsService {
-	return &PaymentsService{repos}
+func NewPaymentService(repos Repositories, apis APIs) *PaymentsService {
+	return &PaymentsService{repos, apis}
 }
 
-// SearchPayments is used for search payments.
-func (p *PaymentsService) SearchPayments(ctx context.Context, filter *domain.Filter, client string) (*SearchPayments, error) {
+// SearchPayments is used for search outgoing and incoming payments.
+func (p *PaymentsService) SearchPayments(ctx context.Context, filter *domain.Filter, userId int, inp *PaymentSearchInput) (*SearchPayments, error) {
 	if filter == nil {
 		filter = new(domain.Filter)
 		filter.Validate()
 	}
+	if inp == nil {
+		inp = new(PaymentSearchInput)
+	}
+
+	// check filters
+	switch inp.Direction {
+	case "":
+		inp.Direction = "all"
+	case "all", "outgoing", "incoming":
+	default:
+		return nil, &Error{Message: "Unknown direction, allowed: all, outgoing, incoming"}
+	}
+	if inp.Status != "" && inp.Status != "prepared" && inp.Status != "sent" {
+		return nil, &Error{Message: "Unknown payment status, allowed: prepared, sent"}
+	}
+	if inp.From != nil && inp.To != nil && !inp.From.Before(*inp.To) {
+		return nil, &Error{Message: "Date range start must be before its end"}
+	}
+	if inp.MinAmount != nil && inp.MaxAmount != nil && *inp.MinAmount > *inp.MaxAmount {
+		return nil, &Error{Message: "Minimal amount must not exceed maximal amount"}
+	}
+	if inp.Category != "" && !domain.IsPaymentCategory(inp.Category) {
+		return nil, &Error{Message: "Unknown category, allowed: " + strings.Join(domain.PaymentCategories, ", ")}
+	}
+
+	// api key request gets only payments of merchant settlement account
+	iban, err := merchantAccountIBAN(ctx, p.repos, inp.AccountIBAN)
+	if err != nil {
+		return nil, err
+	}
+	inp.AccountIBAN = iban
 
 	// search payments from db
-	response, err := p.repos.Payments.SearchPayments(ctx, filter, client)
+	response, err := p.repos.Payments.SearchPayments(ctx, filter, userId, inp)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +76,26 @@ func (p *PaymentsService) CreatePayment(ctx context.Context, userId int, inp *Pa
 	if err != nil {
 		return nil, err
 	}
+	if client.Type == "DEPOSIT" {
+		return nil, &Error{Message: "Payments from deposit account are not allowed"}
+	}
+	if client.Status == "CLOSED" {
+		return nil, &Error{Message: "Bank account is closed"}
+	}
+	err = checkMerchantAccount(ctx, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = getRecipientAccount(ctx, p.repos, inp.ToClientIBAN)
+	if err != nil {
+		return nil, err
+	}
+
+	// check membership
+	err = checkAccountPayer(ctx, p.repos, client, userId)
+	if err != nil {
+		return nil, err
+	}
 
 	// create payment in db
 	payment, err := p.repos.Payments.CreatePayment(ctx, inp, client)
@@ -50,9 +103,10 @@ func (p *PaymentsService) CreatePayment(ctx context.Context, userId int, inp *Pa
 		return nil, err
 	}
 
-	return &PaymentOutput{
+	output := &PaymentOutput{
 		ID:                   payment.ID,
 		PaymentStatus:        payment.PaymentStatus,
+		FromClientID:         payment.FromClientID,
 		FromClient:           payment.FromClient,
 		FromClientITN:        payment.FromClientITN,
 		FromClientIBAN:       payment.FromClientIBAN,
@@ -61,11 +115,16 @@ func (p *PaymentsService) CreatePayment(ctx context.Context, userId int, inp *Pa
 		ToClientIBAN:         payment.ToClientIBAN,
 		ToClient:             payment.ToClient,
 		OperationAmount:      payment.OperationAmount,
-	}, nil
+		Direction:            "outgoing",
+		CreatedAt:            &payment.CreatedAt,
+	}
+	publishEvent(ctx, p.repos, "payment.created", client.ID, output)
+
+	return output, nil
 }
 
 // SentPayment is used for senting payment.
-func (p *PaymentsService) SentPayment(ctx context.Context, paymentId int64, secretValue string, cardBalance float64) (string, error) {
+func (p *PaymentsService) SentPayment(ctx context.Context, userId int, paymentId int64, secretValue string) (string, error) {
 	// check payment
 	payment, err := p.repos.Payments.GetPaymentByID(ctx, paymentId)
 	if err != nil {
@@ -78,6 +137,16 @@ func (p *PaymentsService) SentPayment(ctx context.Context, paymentId int64, secr
 		return "", err
 	}
 
+	// check membership
+	err = checkMerchantAccount(ctx, bakn.ID)
+	if err != nil {
+		return "", err
+	}
+	err = checkAccountPayer(ctx, p.repos, bakn, userId)
+	if err != nil {
+		return "", err
+	}
+
 	// check secret value
 	err = bcrypt.CompareHashAndPassword([]byte(bakn.SecretValue), []byte(secretValue))
 	if err != nil {
@@ -87,18 +156,51 @@ func (p *PaymentsService) SentPayment(ctx context.Context, paymentId int64, secr
 		return "", err
 	}
 
-	// sent payment
-	status, err := p.repos.Payments.SentPayment(ctx, paymentId)
+	// internal recipient is credited together with sender debit
+	recipient, err := getRecipientAccount(ctx, p.repos, payment.ToClientIBAN)
 	if err != nil {
 		return "", err
 	}
 
-	// update balance
-	err = p.repos.Banks.TopUpBankAccount(ctx, &TopUpBankAccountInput{
-		CardNumber: bakn.CardNumber}, cardBalance)
+	// sent payment and queue its notifications
+	var status string
+	err = p.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		status, err = p.repos.Payments.SentPayment(ctx, payment, recipient)
+		if err != nil {
+			return err
+		}
+		return notifyPaymentSent(ctx, p.repos, p.apis, payment, bakn.ID, recipient)
+	})
 	if err != nil {
 		return "", err
 	}
+	publishEvent(ctx, p.repos, "payment.sent", bakn.ID, map[string]interface{}{
+		"paymentId":       payment.ID,
+		"fromClientIban":  payment.FromClientIBAN,
+		"toClientIban":    payment.ToClientIBAN,
+		"operationAmount": payment.OperationAmount,
+		"paymentStatus":   "sent",
+	})
+	trackBudgets(ctx, p.repos, p.apis, bakn.ID)
 
 	return status, nil
 }
+
+// getRecipientAccount - returns internal bank account for IBAN or nil when IBAN is external.
+func getRecipientAccount(ctx context.Context, repos Repositories, iban string) (*domain.BankAccount, error) {
+	account, err := repos.Banks.GetInfoByIBAN(ctx, iban)
+	if err != nil {
+		if _, ok := err.(*Error); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if account.Status == "CLOSED" {
+		return nil, &Error{Message: "Recipient bank account is closed"}
+	}
+	if account.Type == "DEPOSIT" {
+		return nil, &Error{Message: "Deposit account does not accept payments"}
+	}
+
+	return account, nil
+}
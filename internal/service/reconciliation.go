@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// ReconciliationService - represents balance reconciliation service.
+type ReconciliationService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewReconciliationService - creates instance of new reconciliation service.
+func NewReconciliationService(repos Repositories, apis APIs) *ReconciliationService {
+	return &ReconciliationService{repos, apis}
+}
+
+// Reconcile is used for comparing stored balances with recorded money movements.
+func (r *ReconciliationService) Reconcile(ctx context.Context, inp *ReconcileInput) (*ReconciliationReport, error) {
+	if inp.Threshold < 0 {
+		return nil, &Error{Message: "Threshold must not be negative"}
+	}
+
+	checks, err := r.repos.Reconciliation.GetBalanceChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &domain.ReconciliationRun{
+		Accounts:  len(checks),
+		Threshold: inp.Threshold,
+	}
+	discrepancies := []domain.Discrepancy{}
+	for _, check := range checks {
+		expected := utils.RoundMoney(check.Expected)
+		difference := utils.RoundMoney(check.Actual - expected)
+		if difference == 0 {
+			continue
+		}
+
+		frozen := inp.Freeze && (check.Status == "ACTIVE" || check.Status == "LOCK") && math.Abs(difference) > inp.Threshold
+		if frozen {
+			run.Frozen++
+		}
+		discrepancies = append(discrepancies, domain.Discrepancy{
+			BankAccountID: check.BankAccountID,
+			CardNumber:    check.CardNumber,
+			IBAN:          check.IBAN,
+			Client:        check.Client,
+			Expected:      expected,
+			Actual:        check.Actual,
+			Difference:    difference,
+			Frozen:        frozen,
+		})
+	}
+	run.Discrepancies = len(discrepancies)
+
+	// save run, freeze accounts and queue their notifications
+	err = r.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		err := r.repos.Reconciliation.CreateReconciliationRun(ctx, run, discrepancies)
+		if err != nil {
+			return err
+		}
+		for _, discrepancy := range discrepancies {
+			if !discrepancy.Frozen {
+				continue
+			}
+			err = notifyAccountMembers(ctx, r.repos, r.apis, discrepancy.BankAccountID, "account.locked", "Account frozen",
+				fmt.Sprintf("Account with card %d was frozen by the bank because of balance discrepancy. Please, turn to the nearest branch of our bank", discrepancy.CardNumber))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, discrepancy := range discrepancies {
+		if !discrepancy.Frozen {
+			continue
+		}
+		publishEvent(ctx, r.repos, "account.locked", discrepancy.BankAccountID, map[string]interface{}{
+			"cardNumber": discrepancy.CardNumber,
+			"status":     "FROZEN",
+			"reason":     "balance discrepancy",
+		})
+	}
+
+	return &ReconciliationReport{Run: run, Discrepancies: discrepancies}, nil
+}
+
+// GetReconciliationReport is used for getting reconciliation run report, the latest one when id is zero.
+func (r *ReconciliationService) GetReconciliationReport(ctx context.Context, runId int) (*ReconciliationReport, error) {
+	run, err := r.repos.Reconciliation.GetReconciliationRun(ctx, runId)
+	if err != nil {
+		return nil, err
+	}
+	discrepancies, err := r.repos.Reconciliation.GetDiscrepancies(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconciliationReport{Run: run, Discrepancies: discrepancies}, nil
+}
+
+// WriteCSV - writes report discrepancies as CSV with header row.
+func (r *ReconciliationReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"run_id", "created_at", "bank_account_id", "card_number", "iban", "client", "expected", "actual", "difference", "frozen"})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range r.Discrepancies {
+		err = writer.Write([]string{
+			strconv.Itoa(r.Run.ID),
+			r.Run.CreatedAt.Format(time.RFC3339),
+			strconv.Itoa(d.BankAccountID),
+			strconv.FormatInt(d.CardNumber, 10),
+			d.IBAN,
+			d.Client,
+			strconv.FormatFloat(d.Expected, 'f', 2, 64),
+			strconv.FormatFloat(d.Actual, 'f', 2, 64),
+			strconv.FormatFloat(d.Difference, 'f', 2, 64),
+			strconv.FormatBool(d.Frozen),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
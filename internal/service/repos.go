@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	// internal
 	"github.com/Shevchenkko/payment_system/internal/domain"
@@ -13,10 +14,24 @@ import (
 
 // Repositories contains all available repositories.
 type Repositories struct {
-	Users    UsersRepo
-	Banks    BankAccountsRepo
-	Payments PaymentsRepo
-	Messages MessageLogsRepo
+	Users          UsersRepo
+	Banks          BankAccountsRepo
+	Payments       PaymentsRepo
+	Messages       MessageLogsRepo
+	Deposits       DepositsRepo
+	Loans          LoansRepo
+	Reconciliation ReconciliationRepo
+	BusinessDays   BusinessDaysRepo
+	Webhooks       WebhooksRepo
+	Merchants      MerchantsRepo
+	PaymentLinks   PaymentLinksRepo
+	Splits         SplitsRepo
+	Spending       SpendingRepo
+	Budgets        BudgetsRepo
+	Notifications  NotificationsRepo
+	Emails         EmailsRepo
+	Roles          RolesRepo
+	Transactions   TransactionsRepo
 }
 
 // UsersRepo - represents users repository interface.
@@ -30,12 +45,24 @@ type UsersRepo interface {
 	DeleteToken(ctx context.Context, token string) error
 	ResetPassword(ctx context.Context, inp *ResetPasswordInput) error
 	ChangeUserStatus(ctx context.Context, userId int64, status string) (string, error)
+	UpdateUserLanguage(ctx context.Context, userId int, language string) error
+	UpdateUserPhone(ctx context.Context, userId int, phone string) error
+	UpdateUserPassword(ctx context.Context, userId int, password string) error
+	CreateSession(ctx context.Context, session *domain.Session) error
+	GetSessionByID(ctx context.Context, sessionId int) (*domain.Session, error)
+	GetSessionByRefreshToken(ctx context.Context, hash string) (*domain.Session, error)
+	RotateSession(ctx context.Context, session *domain.Session, hash string) error
+	RevokeSession(ctx context.Context, userId int, sessionId int, reason string, at time.Time) error
+	RevokeUserSessions(ctx context.Context, userId int, reason string, at time.Time) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error)
 }
 
 // GenerateTokenInput represents input used to generate token.
+// Message is email with token queued together with it.
 type GenerateTokenInput struct {
-	Email string `json:"email"`
-	Token string `json:"token"`
+	Email   string              `json:"email"`
+	Token   string              `json:"token"`
+	Message *domain.OutboxEmail `json:"-"`
 }
 
 // ResetPasswordInput - used to parameterize ResetPassword.
@@ -45,22 +72,231 @@ type ResetPasswordInput struct {
 }
 
 type BankAccountsRepo interface {
-	SearchBankAccounts(ctx context.Context, filter *domain.Filter, client string, role string) (*SearchBankAccounts, error)
-	CreateBankAccount(ctx context.Context, inp *BankAccountInput, client string) (*domain.BankAccount, error)
-	TopUpBankAccount(ctx context.Context, inp *TopUpBankAccountInput, balance float64) error
+	SearchBankAccounts(ctx context.Context, filter *domain.Filter, userId int, all bool, accountId int) (*SearchBankAccounts, error)
+	CreateBankAccount(ctx context.Context, inp *BankAccountInput, client *domain.User) (*domain.BankAccount, error)
+	TopUpBankAccount(ctx context.Context, account *domain.BankAccount, amount float64) error
 	CheckCreditCard(ctx context.Context, cardNumber int64) (*domain.BankAccount, error)
+	GetBankAccountByID(ctx context.Context, accountId int) (*domain.BankAccount, error)
 	GetInfoByIBAN(ctx context.Context, IBAN string) (*domain.BankAccount, error)
 	ChangeCreditCardStatus(ctx context.Context, cardNumber int64, status string) (string, error)
+	GetAccountMember(ctx context.Context, accountId int, userId int) (*domain.AccountMember, error)
+	SearchAccountMembers(ctx context.Context, accountId int) ([]AccountMemberOutput, error)
+	AddAccountMember(ctx context.Context, accountId int, userId int, role string) (*domain.AccountMember, error)
+	RemoveAccountMember(ctx context.Context, accountId int, userId int) error
+	CloseBankAccount(ctx context.Context, inp *CloseBankAccountRepoInput) error
+}
+
+// CloseBankAccountRepoInput represents input used to close bank account and sweep its balance.
+// Balance is credited to Target when set, otherwise it is paid out to external PayoutIBAN.
+type CloseBankAccountRepoInput struct {
+	Account    *domain.BankAccount
+	Target     *domain.BankAccount
+	PayoutIBAN string
+	PayoutName string
 }
 
 type PaymentsRepo interface {
-	SearchPayments(ctx context.Context, filter *domain.Filter, client string) (*SearchPayments, error)
+	SearchPayments(ctx context.Context, filter *domain.Filter, userId int, inp *PaymentSearchInput) (*SearchPayments, error)
 	CreatePayment(ctx context.Context, inp *PaymentInput, client *domain.BankAccount) (*domain.Payment, error)
-	SentPayment(ctx context.Context, paymentId int64) (string, error)
+	SentPayment(ctx context.Context, payment *domain.Payment, recipient *domain.BankAccount) (string, error)
 	GetPaymentByID(ctx context.Context, paymentId int64) (*domain.Payment, error)
 }
 
 type MessageLogsRepo interface {
 	CreateMessageLog(ctx context.Context, inp *MessageLogInput) (*domain.MessageLog, error)
-	SearchLogs(ctx context.Context, filter *domain.Filter, client string, role string) (*SearchLogs, error)
+	SearchLogs(ctx context.Context, filter *domain.Filter, userId int, all bool) (*SearchLogs, error)
+}
+
+type DepositsRepo interface {
+	SearchDepositProducts(ctx context.Context, filter *domain.Filter) (*SearchDepositProducts, error)
+	CreateDepositProduct(ctx context.Context, inp *DepositProductInput) (*domain.DepositProduct, error)
+	GetDepositProductByID(ctx context.Context, productId int) (*domain.DepositProduct, error)
+	SearchDeposits(ctx context.Context, filter *domain.Filter, userId int) (*SearchDeposits, error)
+	GetDepositByID(ctx context.Context, depositId int) (*domain.Deposit, error)
+	GetMaturedDeposits(ctx context.Context, now time.Time) ([]domain.Deposit, error)
+	OpenDeposit(ctx context.Context, inp *OpenDepositRepoInput) (*domain.Deposit, error)
+	RolloverDeposit(ctx context.Context, deposit *domain.Deposit, interest float64, maturityDate time.Time) error
+	CloseDeposit(ctx context.Context, deposit *domain.Deposit, interest float64, status string) error
+}
+
+// OpenDepositRepoInput - used to parameterize OpenDeposit.
+type OpenDepositRepoInput struct {
+	Product      *domain.DepositProduct
+	Source       *domain.BankAccount
+	Amount       float64
+	Rollover     bool
+	StartDate    time.Time
+	MaturityDate time.Time
+}
+
+type LoansRepo interface {
+	SearchLoanProducts(ctx context.Context, filter *domain.Filter) (*SearchLoanProducts, error)
+	CreateLoanProduct(ctx context.Context, inp *LoanProductInput) (*domain.LoanProduct, error)
+	GetLoanProductByID(ctx context.Context, productId int) (*domain.LoanProduct, error)
+	SearchLoans(ctx context.Context, filter *domain.Filter, userId int, all bool) (*SearchLoans, error)
+	CreateLoan(ctx context.Context, loan *domain.Loan) (*domain.Loan, error)
+	GetLoanByID(ctx context.Context, loanId int) (*domain.Loan, error)
+	RejectLoan(ctx context.Context, loanId int) error
+	DisburseLoan(ctx context.Context, loan *domain.Loan, schedule []domain.LoanInstallment) error
+	GetLoanSchedule(ctx context.Context, loanId int) ([]domain.LoanInstallment, error)
+	GetDueInstallments(ctx context.Context, now time.Time) ([]domain.LoanInstallment, error)
+	PayInstallment(ctx context.Context, loan *domain.Loan, installment *domain.LoanInstallment) error
+	MarkInstallmentOverdue(ctx context.Context, installment *domain.LoanInstallment, penaltyFee float64) error
+	PrepayLoan(ctx context.Context, inp *PrepayLoanRepoInput) error
+}
+
+// PrepayLoanRepoInput - used to parameterize PrepayLoan.
+type PrepayLoanRepoInput struct {
+	Loan      *domain.Loan
+	Overdue   []domain.LoanInstallment
+	Principal float64
+	Schedule  []domain.LoanInstallment
+}
+
+type ReconciliationRepo interface {
+	GetBalanceChecks(ctx context.Context) ([]AccountBalanceCheck, error)
+	CreateReconciliationRun(ctx context.Context, run *domain.ReconciliationRun, discrepancies []domain.Discrepancy) error
+	GetReconciliationRun(ctx context.Context, runId int) (*domain.ReconciliationRun, error)
+	GetDiscrepancies(ctx context.Context, runId int) ([]domain.Discrepancy, error)
+}
+
+type BusinessDaysRepo interface {
+	GetLastBusinessDay(ctx context.Context) (*domain.BusinessDay, error)
+	CloseBusinessDay(ctx context.Context, date time.Time) (*domain.BusinessDay, error)
+	SearchBalanceSnapshots(ctx context.Context, accountId int, from time.Time, to time.Time) ([]domain.BalanceSnapshot, error)
+	GetBalanceSnapshot(ctx context.Context, accountId int, date time.Time) (*domain.BalanceSnapshot, error)
+}
+
+type WebhooksRepo interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	SearchWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error)
+	GetWebhookByID(ctx context.Context, webhookId int) (*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId int) error
+	GetAccountWebhooks(ctx context.Context, accountId int) ([]domain.Webhook, error)
+	CreateWebhookDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error
+	SearchWebhookDeliveries(ctx context.Context, filter *domain.Filter, webhookId int) (*SearchWebhookDeliveries, error)
+	GetWebhookDeliveryByID(ctx context.Context, deliveryId int) (*domain.WebhookDelivery, error)
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+// MerchantsRepo - represents merchants repository interface.
+type MerchantsRepo interface {
+	CreateMerchant(ctx context.Context, merchant *domain.Merchant) (*domain.Merchant, error)
+	SearchMerchants(ctx context.Context, userId int) ([]domain.Merchant, error)
+	GetMerchantByID(ctx context.Context, merchantId int) (*domain.Merchant, error)
+	CreateAPIKey(ctx context.Context, key *domain.MerchantAPIKey) (*domain.MerchantAPIKey, error)
+	SearchAPIKeys(ctx context.Context, merchantId int) ([]domain.MerchantAPIKey, error)
+	GetAPIKeyByID(ctx context.Context, keyId int) (*domain.MerchantAPIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.MerchantAPIKey, error)
+	RevokeAPIKey(ctx context.Context, keyId int, at time.Time) error
+	RotateAPIKey(ctx context.Context, keyId int, key *domain.MerchantAPIKey, at time.Time) (*domain.MerchantAPIKey, error)
+	TouchAPIKey(ctx context.Context, keyId int, at time.Time) error
+}
+
+// PaymentLinksRepo - represents payment links repository interface.
+type PaymentLinksRepo interface {
+	CreatePaymentLink(ctx context.Context, link *domain.PaymentLink) (*domain.PaymentLink, error)
+	SearchPaymentLinks(ctx context.Context, filter *domain.Filter, merchantId int) (*SearchPaymentLinks, error)
+	GetPaymentLinkByID(ctx context.Context, linkId int) (*domain.PaymentLink, error)
+	GetPaymentLinkByCode(ctx context.Context, code string) (*domain.PaymentLink, error)
+	DisablePaymentLink(ctx context.Context, linkId int) error
+	PayPaymentLink(ctx context.Context, inp *PayPaymentLinkRepoInput) (*domain.Payment, error)
+}
+
+// PayPaymentLinkRepoInput represents input used to pay payment link in the database.
+type PayPaymentLinkRepoInput struct {
+	Link       *domain.PaymentLink
+	Payer      *domain.BankAccount
+	Settlement *domain.BankAccount
+}
+
+// SplitsRepo - represents split bills repository interface.
+type SplitsRepo interface {
+	CreateSplit(ctx context.Context, split *domain.Split) (*domain.Split, error)
+	SearchSplits(ctx context.Context, filter *domain.Filter, userId int) (*SearchSplits, error)
+	GetSplitByID(ctx context.Context, splitId int) (*domain.Split, error)
+	GetSplitShareByID(ctx context.Context, shareId int) (*domain.SplitShare, error)
+	PaySplitShare(ctx context.Context, inp *PaySplitShareRepoInput) (*domain.Payment, error)
+	CancelSplit(ctx context.Context, splitId int) error
+}
+
+// PaySplitShareRepoInput represents input used to pay split share in the database.
+type PaySplitShareRepoInput struct {
+	Split     *domain.Split
+	Share     *domain.SplitShare
+	Payer     *domain.BankAccount
+	Recipient *domain.BankAccount
+}
+
+// SpendingRepo - represents spending categorization and analytics repository interface.
+type SpendingRepo interface {
+	CategorizePayments(ctx context.Context, userId int) (int64, error)
+	GetUncategorizedPaymentSenders(ctx context.Context) ([]int, error)
+	ResetPaymentCategories(ctx context.Context, userId int) error
+	SetPaymentCategory(ctx context.Context, paymentId int64, category string) error
+	CreateCategoryRule(ctx context.Context, rule *domain.CategoryRule) (*domain.CategoryRule, error)
+	SearchCategoryRules(ctx context.Context, userId int) ([]domain.CategoryRule, error)
+	GetCategoryRuleByID(ctx context.Context, ruleId int) (*domain.CategoryRule, error)
+	DeleteCategoryRule(ctx context.Context, ruleId int) error
+	SumSpending(ctx context.Context, userId int, inp *SpendingQuery) ([]SpendingTotal, error)
+}
+
+// SpendingQuery represents sent payments summed in the database, To is exclusive.
+// GroupBy is category, month or counterparty, Limit is not applied when zero.
+type SpendingQuery struct {
+	AccountIBAN string
+	From        time.Time
+	To          time.Time
+	GroupBy     string
+	Limit       int
+}
+
+// BudgetsRepo - represents budgets repository interface.
+type BudgetsRepo interface {
+	CreateBudget(ctx context.Context, budget *domain.Budget) (*domain.Budget, error)
+	SearchBudgets(ctx context.Context, userId int) ([]domain.Budget, error)
+	GetBudgetByID(ctx context.Context, budgetId int) (*domain.Budget, error)
+	GetUserBudget(ctx context.Context, userId int, category string) (*domain.Budget, error)
+	UpdateBudgetAmount(ctx context.Context, budgetId int, amount float64) error
+	UpdateBudgetSpent(ctx context.Context, budgetId int, month string, spent float64) error
+	MarkBudgetAlerted(ctx context.Context, budgetId int, month string, threshold int) (bool, error)
+	DeleteBudget(ctx context.Context, budgetId int) error
+}
+
+// NotificationsRepo - represents notifications repository interface.
+type NotificationsRepo interface {
+	CreateNotification(ctx context.Context, notification *domain.Notification, email *domain.OutboxEmail, deliveries []domain.NotificationDelivery) error
+	SearchNotifications(ctx context.Context, filter *domain.Filter, userId int, unread bool) (*SearchNotifications, error)
+	MarkNotificationsRead(ctx context.Context, userId int, ids []int, at time.Time) (int64, error)
+	GetNotificationPreferences(ctx context.Context, userId int) ([]domain.NotificationPreference, error)
+	SaveNotificationPreferences(ctx context.Context, preferences []domain.NotificationPreference) error
+	SaveDeviceToken(ctx context.Context, device *domain.DeviceToken) (*domain.DeviceToken, error)
+	GetDeviceTokens(ctx context.Context, userId int) ([]domain.DeviceToken, error)
+	DeleteDeviceToken(ctx context.Context, userId int, deviceId int) error
+	SearchNotificationDeliveries(ctx context.Context, filter *domain.Filter, userId int, channel string) (*SearchNotificationDeliveries, error)
+	GetDueNotificationDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.NotificationDelivery, error)
+	UpdateNotificationDelivery(ctx context.Context, delivery *domain.NotificationDelivery) error
+}
+
+// EmailsRepo - represents email outbox repository interface.
+type EmailsRepo interface {
+	SearchOutboxEmails(ctx context.Context, filter *domain.Filter, status string) (*SearchOutboxEmails, error)
+	GetOutboxEmailByID(ctx context.Context, emailId int) (*domain.OutboxEmail, error)
+	ClaimDueOutboxEmails(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.OutboxEmail, error)
+	UpdateOutboxEmail(ctx context.Context, email *domain.OutboxEmail) error
+}
+
+// RolesRepo - represents roles repository interface.
+type RolesRepo interface {
+	SearchRoles(ctx context.Context) ([]domain.Role, error)
+	GetUserRoles(ctx context.Context, userId int) ([]domain.Role, error)
+	GetUserPermissions(ctx context.Context, userId int) (domain.Permissions, error)
+	SetUserRoles(ctx context.Context, userId int, names []string) ([]domain.Role, error)
+	CountRoleUsers(ctx context.Context, role string) (int64, error)
+}
+
+// TransactionsRepo - represents repository running calls of other repositories in one transaction interface.
+type TransactionsRepo interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
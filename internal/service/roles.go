@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// RolesService - represents roles and permissions service.
+type RolesService struct {
+	repos Repositories
+}
+
+// NewRolesService - creates instance of new roles service.
+func NewRolesService(repos Repositories) *RolesService {
+	return &RolesService{repos}
+}
+
+// SearchRoles is used for getting every role with permissions it grants.
+func (r *RolesService) SearchRoles(ctx context.Context) ([]domain.Role, error) {
+	return r.repos.Roles.SearchRoles(ctx)
+}
+
+// GetUserRoles is used for getting roles of user.
+func (r *RolesService) GetUserRoles(ctx context.Context, userId int) ([]domain.Role, error) {
+	return r.repos.Roles.GetUserRoles(ctx, userId)
+}
+
+// GetUserPermissions is used for getting permissions granted to user by all of their roles.
+func (r *RolesService) GetUserPermissions(ctx context.Context, userId int) (domain.Permissions, error) {
+	return r.repos.Roles.GetUserPermissions(ctx, userId)
+}
+
+// AssignUserRoles is used for replacing roles of user.
+// Admin role can not be taken from the last admin, so the bank is never left without one.
+func (r *RolesService) AssignUserRoles(ctx context.Context, userId int, roles []string) ([]domain.Role, error) {
+	names := make([]string, 0, len(roles))
+	keepsAdmin := false
+	seen := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		names = append(names, role)
+		keepsAdmin = keepsAdmin || role == domain.RoleAdmin
+	}
+	if len(names) == 0 {
+		return nil, &Error{Message: "At least one role is required"}
+	}
+
+	// admins are counted with their grants locked, so concurrent demotions cannot both pass the check
+	var assigned []domain.Role
+	err := r.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		if !keepsAdmin {
+			current, err := r.repos.Roles.GetUserRoles(ctx, userId)
+			if err != nil {
+				return err
+			}
+			if hasRole(current, domain.RoleAdmin) {
+				admins, err := r.repos.Roles.CountRoleUsers(ctx, domain.RoleAdmin)
+				if err != nil {
+					return err
+				}
+				if admins <= 1 {
+					return &Error{Message: "Admin role can not be taken from the last admin"}
+				}
+			}
+		}
+
+		var err error
+		assigned, err = r.repos.Roles.SetUserRoles(ctx, userId, names)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assigned, nil
+}
+
+// hasRole - reports whether roles include role with name.
+func hasRole(roles []domain.Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
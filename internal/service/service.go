@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/access"
 
 	// internal
 	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/templates"
 )
 
 ///
@@ -23,6 +28,9 @@ type Error struct {
 	Status int `json:"status,omitempty"`
 }
 
+// ErrInsufficientFunds - returned when bank account balance does not cover debit.
+var ErrInsufficientFunds = &Error{Message: "Insufficient funds"}
+
 // custom Error() method for Error
 func (err *Error) Error() string {
 	errData, e := json.Marshal(err)
@@ -43,6 +51,19 @@ type Services struct {
 	BankAccounts
 	Payments
 	MessageLogs
+	Deposits
+	Loans
+	Reconciliation
+	BusinessDays
+	Webhooks
+	Merchants
+	PaymentLinks
+	Splits
+	Spending
+	Budgets
+	Notifications
+	Emails
+	Roles
 }
 
 // Users - represents users service interface.
@@ -50,11 +71,17 @@ type Users interface {
 	SearchUsers(ctx context.Context, filter *domain.Filter) (*SearchUsers, error)
 	RegisterUser(ctx context.Context, inp *RegisterUserInput) (RegisterUserOutput, error)
 	LoginUser(ctx context.Context, inp *LoginUserInput) (LoginUserOutput, error)
-	VerifyAccessToken(ctx context.Context, token string) (bool, int, string)
+	RefreshSession(ctx context.Context, inp *RefreshSessionInput) (SessionTokens, error)
+	Logout(ctx context.Context, userId int, sessionId int, all bool) error
+	VerifyAccessToken(ctx context.Context, token string) (*access.Token, bool)
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error)
 	SendEmail(ctx context.Context, inp *SendUserEmailInput) error
 	ResetPassword(ctx context.Context, inp *ResetPasswordInput) error
-	LockUser(ctx context.Context, userId int64, userRole string) (string, error)
-	UnlockUser(ctx context.Context, userId int64, userRole string) (string, error)
+	LockUser(ctx context.Context, userId int64) (string, error)
+	UnlockUser(ctx context.Context, userId int64) (string, error)
+	UpdateUserLanguage(ctx context.Context, userId int, language string) error
+	UpdateUserPhone(ctx context.Context, userId int, phone string) error
+	SetUserPassword(ctx context.Context, userId int, password string) error
 }
 
 // SearchUsers represents user info.
@@ -74,28 +101,56 @@ type RegisterUserInput struct {
 	FullName string `json:"fullName"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Language string `json:"language"`
+
+	// client session is opened from
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 // RegisterUserOutput - output of RegisterUser.
 type RegisterUserOutput struct {
-	Token    string
-	UserID   int
-	FullName string
-	Email    string
+	Token        string
+	RefreshToken string
+	ExpiresIn    int64
+	UserID       int
+	FullName     string
+	Email        string
 }
 
 // LoginUserInput represents input used to login user.
 type LoginUserInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+
+	// client session is opened from
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 // LoginUserOutput - output of LoginUser.
 type LoginUserOutput struct {
-	Token    string
-	UserID   int
-	FullName string
-	Email    string
+	Token        string
+	RefreshToken string
+	ExpiresIn    int64
+	UserID       int
+	FullName     string
+	Email        string
+}
+
+// RefreshSessionInput represents input used to refresh session.
+type RefreshSessionInput struct {
+	RefreshToken string `json:"refreshToken"`
+	UserAgent    string `json:"-"`
+	IP           string `json:"-"`
+}
+
+// SessionTokens represents access token of session with refresh token used to get next one.
+// ExpiresIn is lifetime of access token in seconds.
+type SessionTokens struct {
+	Token        string
+	RefreshToken string
+	ExpiresIn    int64
 }
 
 // SendUserEmailInput represents input used to send user email.
@@ -106,11 +161,47 @@ type SendUserEmailInput struct {
 
 // BankAccounts - represents bank accounts service interface.
 type BankAccounts interface {
-	SearchBankAccounts(ctx context.Context, filter *domain.Filter, client string, role string) (*SearchBankAccounts, error)
+	SearchBankAccounts(ctx context.Context, filter *domain.Filter, userId int, permissions domain.Permissions) (*SearchBankAccounts, error)
 	CreateBankAccount(ctx context.Context, userId int, inp *BankAccountInput) (BankAccountOutput, error)
 	TopUpBankAccount(ctx context.Context, userId int, inp *TopUpBankAccountInput) (BankAccountOutput, error)
-	BlockBankAccount(ctx context.Context, client string, userRole string, inp *ChangeBankAccountInput) (string, error)
-	UnlockBankAccount(ctx context.Context, client string, userRole string, inp *ChangeBankAccountInput) (string, error)
+	BlockBankAccount(ctx context.Context, userId int, permissions domain.Permissions, inp *ChangeBankAccountInput) (string, error)
+	UnlockBankAccount(ctx context.Context, userId int, permissions domain.Permissions, inp *ChangeBankAccountInput) (string, error)
+	SearchAccountMembers(ctx context.Context, userId int, permissions domain.Permissions, cardNumber int64) ([]AccountMemberOutput, error)
+	AddAccountMember(ctx context.Context, userId int, inp *AccountMemberInput) (*domain.AccountMember, error)
+	RemoveAccountMember(ctx context.Context, userId int, inp *AccountMemberInput) error
+	CloseBankAccount(ctx context.Context, userId int, permissions domain.Permissions, inp *CloseBankAccountInput) (*domain.BankAccount, error)
+	GetBalanceHistory(ctx context.Context, userId int, permissions domain.Permissions, inp *BalanceHistoryInput) (*BalanceHistory, error)
+}
+
+// BalanceHistoryInput represents input used to get bank account balance history.
+// AsOf is optional date to get balance at the end of.
+type BalanceHistoryInput struct {
+	CardNumber int64
+	From       time.Time
+	To         time.Time
+	AsOf       *time.Time
+}
+
+// BalanceHistory represents daily balance series of bank account.
+type BalanceHistory struct {
+	CardNumber int64                 `json:"cardNumber"`
+	Series     []BalanceHistoryPoint `json:"series"`
+	AsOf       *BalanceHistoryPoint  `json:"asOf,omitempty"`
+}
+
+// BalanceHistoryPoint represents bank account balance at the end of day.
+type BalanceHistoryPoint struct {
+	Date    string  `json:"date"`
+	Balance float64 `json:"balance"`
+}
+
+// CloseBankAccountInput represents input used to close bank account.
+type CloseBankAccountInput struct {
+	CardNumber       int64  `json:"cardNumber"`
+	SecretValue      string `json:"secretValue"`
+	TargetCardNumber int64  `json:"targetCardNumber"`
+	PayoutIBAN       string `json:"payoutIban"`
+	PayoutName       string `json:"payoutName"`
 }
 
 // BankAccountInput represents input used to bank account.
@@ -126,12 +217,15 @@ type SearchBankAccounts struct {
 }
 
 type BankAccountOutput struct {
-	ID         int     `json:"id"`
-	Status     string  `json:"status"`
-	Client     string  `json:"client"`
-	CardNumber int64   `json:"cardNumber"`
-	IBAN       string  `json:"iban"`
-	Balance    float64 `json:"balance"`
+	ID           int        `json:"id"`
+	Status       string     `json:"status"`
+	Type         string     `json:"type"`
+	Client       string     `json:"client"`
+	CardNumber   int64      `json:"cardNumber"`
+	IBAN         string     `json:"iban"`
+	Balance      float64    `json:"balance"`
+	MaturityDate *time.Time `json:"maturityDate,omitempty"`
+	ClosedAt     *time.Time `json:"closedAt,omitempty"`
 }
 
 // TopUpBankAccountInput represents input used to top up bank account.
@@ -146,11 +240,28 @@ type ChangeBankAccountInput struct {
 	SecretValue string `json:"secretValue"`
 }
 
+// AccountMemberInput represents input used to add or remove bank account member.
+type AccountMemberInput struct {
+	CardNumber  int64  `json:"cardNumber"`
+	SecretValue string `json:"secretValue"`
+	UserID      int    `json:"userId"`
+	Role        string `json:"role"`
+}
+
+// AccountMemberOutput represents bank account member info.
+type AccountMemberOutput struct {
+	UserID   int    `json:"userId"`
+	FullName string `json:"fullName"`
+	Role     string `json:"role"`
+}
+
 // Payments - represents payments service interface.
 type Payments interface {
-	SearchPayments(ctx context.Context, filter *domain.Filter, client string) (*SearchPayments, error)
+	SearchPayments(ctx context.Context, filter *domain.Filter, userId int, inp *PaymentSearchInput) (*SearchPayments, error)
 	CreatePayment(ctx context.Context, userId int, inp *PaymentInput) (*PaymentOutput, error)
-	SentPayment(ctx context.Context, paymentId int64, secretValue string, cardBalance float64) (string, error)
+	SentPayment(ctx context.Context, userId int, paymentId int64, secretValue string) (string, error)
+	GeneratePaymentQR(ctx context.Context, userId int, permissions domain.Permissions, inp *PaymentQRInput) (*PaymentQROutput, error)
+	ParsePaymentQR(ctx context.Context, payload string) (*PaymentInput, error)
 }
 
 // PaymentInput represents input used to payment.
@@ -162,6 +273,21 @@ type PaymentInput struct {
 	OperationAmount float64 `json:"operationAmount"`
 }
 
+// PaymentQRInput represents input used to generate payment qr code.
+type PaymentQRInput struct {
+	IBAN    string  `json:"iban"`
+	Amount  float64 `json:"amount"`
+	Purpose string  `json:"purpose"`
+	Format  string  `json:"format"`
+}
+
+// PaymentQROutput represents payment qr payload and its png image.
+type PaymentQROutput struct {
+	Format  string `json:"format"`
+	Payload string `json:"payload"`
+	PNG     []byte `json:"png"`
+}
+
 // SearchPayments represents payments info.
 type SearchPayments struct {
 	Data       []PaymentOutput    `json:"data"`
@@ -169,26 +295,46 @@ type SearchPayments struct {
 }
 
 type PaymentOutput struct {
-	ID                   int64   `json:"id"`
-	PaymentStatus        string  `json:"paymentStatus"`
-	FromClient           string  `json:"fromClient"`
-	FromClientITN        int64   `json:"fromClientItn"`
-	FromClientIBAN       string  `json:"fromClientIban"`
-	FromClientCardNumber int64   `json:"fromClientCardNumber"`
-	Description          string  `json:"description"`
-	ToClientIBAN         string  `json:"toClientIban"`
-	ToClient             string  `json:"toClient"`
-	OperationAmount      float64 `json:"operationAmount"`
+	ID                   int64      `json:"id"`
+	PaymentStatus        string     `json:"paymentStatus"`
+	FromClientID         int        `json:"fromClientId"`
+	FromClient           string     `json:"fromClient"`
+	FromClientITN        int64      `json:"fromClientItn"`
+	FromClientIBAN       string     `json:"fromClientIban"`
+	FromClientCardNumber int64      `json:"fromClientCardNumber"`
+	Description          string     `json:"description"`
+	ToClientIBAN         string     `json:"toClientIban"`
+	ToClient             string     `json:"toClient"`
+	OperationAmount      float64    `json:"operationAmount"`
+	Direction            string     `json:"direction,omitempty"`
+	Category             *string    `json:"category,omitempty"`
+	CreatedAt            *time.Time `json:"createdAt,omitempty"`
+}
+
+// PaymentSearchInput represents payment search filters, empty fields are not applied.
+// Direction is outgoing, incoming or all, To is exclusive. Category selects outgoing payments only.
+type PaymentSearchInput struct {
+	Direction    string
+	From         *time.Time
+	To           *time.Time
+	MinAmount    *float64
+	MaxAmount    *float64
+	Status       string
+	Counterparty string
+	AccountIBAN  string
+	Description  string
+	Category     string
 }
 
 // MessageLogs - represents message logs service interface.
 type MessageLogs interface {
 	CreateMessageLog(ctx context.Context, userId int, inp *MessageLogInput) (*domain.MessageLog, error)
-	SearchLogs(ctx context.Context, filter *domain.Filter, client string, role string) (*SearchLogs, error)
+	SearchLogs(ctx context.Context, filter *domain.Filter, userId int, permissions domain.Permissions) (*SearchLogs, error)
 }
 
 // MessageLogInput represents input used to message logs.
 type MessageLogInput struct {
+	ClientID   int    `json:"clientId"`
 	Client     string `json:"client"`
 	MessageLog string `json:"messageLog"`
 }
@@ -198,3 +344,425 @@ type SearchLogs struct {
 	Data       []domain.MessageLog `json:"data"`
 	Pagination *domain.Pagination  `json:"pagination"`
 }
+
+// Deposits - represents term deposits service interface.
+type Deposits interface {
+	SearchDepositProducts(ctx context.Context, filter *domain.Filter) (*SearchDepositProducts, error)
+	CreateDepositProduct(ctx context.Context, inp *DepositProductInput) (*domain.DepositProduct, error)
+	SearchDeposits(ctx context.Context, filter *domain.Filter, userId int) (*SearchDeposits, error)
+	OpenDeposit(ctx context.Context, userId int, inp *OpenDepositInput) (*domain.Deposit, error)
+	WithdrawDeposit(ctx context.Context, userId int, inp *WithdrawDepositInput) (*domain.Deposit, error)
+	ProcessMaturedDeposits(ctx context.Context, now time.Time) (int, error)
+}
+
+// DepositProductInput represents input used to create deposit product.
+type DepositProductInput struct {
+	Name        string  `json:"name"`
+	TermMonths  int     `json:"termMonths"`
+	Rate        float64 `json:"rate"`
+	PenaltyRate float64 `json:"penaltyRate"`
+	MinAmount   float64 `json:"minAmount"`
+}
+
+// SearchDepositProducts represents deposit products info.
+type SearchDepositProducts struct {
+	Data       []domain.DepositProduct `json:"data"`
+	Pagination *domain.Pagination      `json:"pagination"`
+}
+
+// OpenDepositInput represents input used to open deposit.
+type OpenDepositInput struct {
+	ProductID   int     `json:"productId"`
+	CardNumber  int64   `json:"cardNumber"`
+	SecretValue string  `json:"secretValue"`
+	Amount      float64 `json:"amount"`
+	Rollover    bool    `json:"rollover"`
+}
+
+// WithdrawDepositInput represents input used to withdraw deposit before maturity.
+type WithdrawDepositInput struct {
+	DepositID   int    `json:"depositId"`
+	SecretValue string `json:"secretValue"`
+}
+
+// SearchDeposits represents deposits info.
+type SearchDeposits struct {
+	Data       []domain.Deposit   `json:"data"`
+	Pagination *domain.Pagination `json:"pagination"`
+}
+
+// Loans - represents consumer loans service interface.
+type Loans interface {
+	SearchLoanProducts(ctx context.Context, filter *domain.Filter) (*SearchLoanProducts, error)
+	CreateLoanProduct(ctx context.Context, inp *LoanProductInput) (*domain.LoanProduct, error)
+	SearchLoans(ctx context.Context, filter *domain.Filter, userId int, permissions domain.Permissions) (*SearchLoans, error)
+	ApplyLoan(ctx context.Context, userId int, inp *ApplyLoanInput) (*domain.Loan, error)
+	ApproveLoan(ctx context.Context, loanId int) (*domain.Loan, error)
+	RejectLoan(ctx context.Context, loanId int) (*domain.Loan, error)
+	GetLoanSchedule(ctx context.Context, userId int, permissions domain.Permissions, loanId int) ([]domain.LoanInstallment, error)
+	PrepayLoan(ctx context.Context, userId int, inp *PrepayLoanInput) (*domain.Loan, error)
+	ProcessLoanInstallments(ctx context.Context, now time.Time) (int, error)
+}
+
+// LoanProductInput represents input used to create loan product.
+type LoanProductInput struct {
+	Name          string  `json:"name"`
+	Rate          float64 `json:"rate"`
+	TermMonths    int     `json:"termMonths"`
+	RepaymentType string  `json:"repaymentType"`
+	PenaltyFee    float64 `json:"penaltyFee"`
+	MinAmount     float64 `json:"minAmount"`
+	MaxAmount     float64 `json:"maxAmount"`
+}
+
+// SearchLoanProducts represents loan products info.
+type SearchLoanProducts struct {
+	Data       []domain.LoanProduct `json:"data"`
+	Pagination *domain.Pagination   `json:"pagination"`
+}
+
+// ApplyLoanInput represents input used to apply for loan.
+type ApplyLoanInput struct {
+	ProductID  int     `json:"productId"`
+	CardNumber int64   `json:"cardNumber"`
+	Amount     float64 `json:"amount"`
+}
+
+// PrepayLoanInput represents input used to repay loan early.
+type PrepayLoanInput struct {
+	LoanID      int     `json:"loanId"`
+	SecretValue string  `json:"secretValue"`
+	Amount      float64 `json:"amount"`
+}
+
+// SearchLoans represents loans info.
+type SearchLoans struct {
+	Data       []domain.Loan      `json:"data"`
+	Pagination *domain.Pagination `json:"pagination"`
+}
+
+// Reconciliation - represents balance reconciliation service interface.
+type Reconciliation interface {
+	Reconcile(ctx context.Context, inp *ReconcileInput) (*ReconciliationReport, error)
+	GetReconciliationReport(ctx context.Context, runId int) (*ReconciliationReport, error)
+}
+
+// ReconcileInput represents input used to reconcile balances.
+// Accounts with mismatch above threshold are locked when Freeze is set.
+type ReconcileInput struct {
+	Threshold float64 `json:"threshold"`
+	Freeze    bool    `json:"freeze"`
+}
+
+// ReconciliationReport represents reconciliation run with found discrepancies.
+type ReconciliationReport struct {
+	Run           *domain.ReconciliationRun `json:"run"`
+	Discrepancies []domain.Discrepancy      `json:"discrepancies"`
+}
+
+// AccountBalanceCheck represents stored and expected balance of bank account.
+type AccountBalanceCheck struct {
+	BankAccountID int
+	CardNumber    int64
+	IBAN          string
+	Client        string
+	Status        string
+	Expected      float64
+	Actual        float64
+}
+
+// BusinessDays - represents end of day closing service interface.
+type BusinessDays interface {
+	CloseBusinessDays(ctx context.Context, now time.Time) (int, error)
+}
+
+// Webhooks - represents webhooks service interface.
+type Webhooks interface {
+	CreateWebhook(ctx context.Context, userId int, inp *WebhookInput) (*domain.Webhook, error)
+	SearchWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, userId int, webhookId int) error
+	SearchWebhookDeliveries(ctx context.Context, filter *domain.Filter, userId int, webhookId int) (*SearchWebhookDeliveries, error)
+	ReplayWebhookDelivery(ctx context.Context, userId int, deliveryId int) (*domain.WebhookDelivery, error)
+	PublishEvent(ctx context.Context, event string, accountId int, data interface{}) error
+	DeliverWebhooks(ctx context.Context, now time.Time) (int, error)
+}
+
+// WebhookInput represents input used to register webhook.
+type WebhookInput struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// SearchWebhookDeliveries represents webhook deliveries info.
+type SearchWebhookDeliveries struct {
+	Data       []domain.WebhookDelivery `json:"data"`
+	Pagination *domain.Pagination       `json:"pagination"`
+}
+
+// Merchants - represents merchants service interface.
+type Merchants interface {
+	CreateMerchant(ctx context.Context, userId int, inp *MerchantInput) (*domain.Merchant, error)
+	SearchMerchants(ctx context.Context, userId int) ([]domain.Merchant, error)
+	CreateAPIKey(ctx context.Context, userId int, inp *APIKeyInput) (*APIKeyOutput, error)
+	SearchAPIKeys(ctx context.Context, userId int, merchantId int) ([]domain.MerchantAPIKey, error)
+	RotateAPIKey(ctx context.Context, userId int, keyId int) (*APIKeyOutput, error)
+	RevokeAPIKey(ctx context.Context, userId int, keyId int) error
+	VerifyAPIKey(ctx context.Context, key string) (*domain.MerchantAPIKey, bool)
+}
+
+// MerchantInput represents input used to create merchant.
+type MerchantInput struct {
+	Name        string `json:"name"`
+	CardNumber  int64  `json:"cardNumber"`
+	SecretValue string `json:"secretValue"`
+	Category    string `json:"category"`
+}
+
+// APIKeyInput represents input used to create merchant api key.
+type APIKeyInput struct {
+	MerchantID int      `json:"merchantId"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+}
+
+// APIKeyOutput represents issued api key, plain key is shown only once.
+type APIKeyOutput struct {
+	Key    string                 `json:"key"`
+	APIKey *domain.MerchantAPIKey `json:"apiKey"`
+}
+
+// PaymentLinks - represents merchant payment links service interface.
+type PaymentLinks interface {
+	CreatePaymentLink(ctx context.Context, userId int, inp *PaymentLinkInput) (*domain.PaymentLink, error)
+	SearchPaymentLinks(ctx context.Context, filter *domain.Filter, userId int, merchantId int) (*SearchPaymentLinks, error)
+	DisablePaymentLink(ctx context.Context, userId int, linkId int) error
+	GetPaymentLink(ctx context.Context, code string) (*PaymentLinkOutput, error)
+	PayPaymentLink(ctx context.Context, userId int, inp *PayPaymentLinkInput) (*PaymentOutput, error)
+}
+
+// PaymentLinkInput represents input used to create payment link.
+type PaymentLinkInput struct {
+	MerchantID  int        `json:"merchantId"`
+	Amount      float64    `json:"amount"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	MultiUse    bool       `json:"multiUse"`
+}
+
+// PaymentLinkOutput represents payment link details shown to customer.
+type PaymentLinkOutput struct {
+	Code        string     `json:"code"`
+	Merchant    string     `json:"merchant"`
+	Amount      float64    `json:"amount"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	MultiUse    bool       `json:"multiUse"`
+	Status      string     `json:"status"`
+}
+
+// PayPaymentLinkInput represents input used to pay payment link.
+type PayPaymentLinkInput struct {
+	Code        string `json:"code"`
+	CardNumber  int64  `json:"cardNumber"`
+	SecretValue string `json:"secretValue"`
+}
+
+// SearchPaymentLinks represents payment links info.
+type SearchPaymentLinks struct {
+	Data       []domain.PaymentLink `json:"data"`
+	Pagination *domain.Pagination   `json:"pagination"`
+}
+
+// Splits - represents split bills service interface.
+type Splits interface {
+	CreateSplit(ctx context.Context, userId int, inp *SplitInput) (*domain.Split, error)
+	SearchSplits(ctx context.Context, filter *domain.Filter, userId int) (*SearchSplits, error)
+	PaySplitShare(ctx context.Context, userId int, inp *PaySplitShareInput) (*domain.Split, error)
+	CancelSplit(ctx context.Context, userId int, splitId int) (*domain.Split, error)
+}
+
+// SplitInput represents input used to split bill.
+type SplitInput struct {
+	CardNumber   int64                   `json:"cardNumber"`
+	Description  string                  `json:"description"`
+	Total        float64                 `json:"total"`
+	Method       string                  `json:"method"`
+	Participants []SplitParticipantInput `json:"participants"`
+}
+
+// SplitParticipantInput represents participant of split, amount is used by fixed and percentage by percentage method.
+type SplitParticipantInput struct {
+	UserID     int     `json:"userId"`
+	Amount     float64 `json:"amount"`
+	Percentage float64 `json:"percentage"`
+}
+
+// PaySplitShareInput represents input used to pay split share.
+type PaySplitShareInput struct {
+	ShareID     int    `json:"shareId"`
+	CardNumber  int64  `json:"cardNumber"`
+	SecretValue string `json:"secretValue"`
+}
+
+// SearchSplits represents splits info.
+type SearchSplits struct {
+	Data       []domain.Split     `json:"data"`
+	Pagination *domain.Pagination `json:"pagination"`
+}
+
+// Spending - represents spending categorization and analytics service interface.
+type Spending interface {
+	GetSpendingAnalytics(ctx context.Context, userId int, inp *SpendingAnalyticsInput) (*SpendingAnalytics, error)
+	SetPaymentCategory(ctx context.Context, userId int, inp *PaymentCategoryInput) (*domain.Payment, error)
+	CreateCategoryRule(ctx context.Context, userId int, inp *CategoryRuleInput) (*domain.CategoryRule, error)
+	SearchCategoryRules(ctx context.Context, userId int) ([]domain.CategoryRule, error)
+	DeleteCategoryRule(ctx context.Context, userId int, ruleId int) error
+	CategorizePayments(ctx context.Context) (int64, error)
+}
+
+// SpendingAnalyticsInput represents analytics period, To is exclusive.
+// Period is the current month by default, AccountIBAN limits analytics to one account.
+type SpendingAnalyticsInput struct {
+	From        *time.Time
+	To          *time.Time
+	AccountIBAN string
+}
+
+// SpendingAnalytics represents spending of period compared with previous period of the same length.
+type SpendingAnalytics struct {
+	From           time.Time            `json:"from"`
+	To             time.Time            `json:"to"`
+	PreviousFrom   time.Time            `json:"previousFrom"`
+	PreviousTo     time.Time            `json:"previousTo"`
+	Total          float64              `json:"total"`
+	PreviousTotal  float64              `json:"previousTotal"`
+	Change         *float64             `json:"change"`
+	Categories     []SpendingComparison `json:"categories"`
+	Counterparties []SpendingComparison `json:"counterparties"`
+	Months         []SpendingTotal      `json:"months"`
+}
+
+// SpendingComparison represents spending of category or counterparty in period and previous period.
+// Change is percentage change, empty when nothing was spent in previous period.
+type SpendingComparison struct {
+	Key           string   `json:"key"`
+	Name          string   `json:"name,omitempty"`
+	Total         float64  `json:"total"`
+	Count         int64    `json:"count"`
+	PreviousTotal float64  `json:"previousTotal"`
+	Change        *float64 `json:"change"`
+}
+
+// SpendingTotal represents sum and count of payments in group.
+type SpendingTotal struct {
+	Key   string  `json:"key"`
+	Name  string  `json:"name,omitempty"`
+	Total float64 `json:"total"`
+	Count int64   `json:"count"`
+}
+
+// PaymentCategoryInput represents category chosen by user for payment, empty category resets it.
+type PaymentCategoryInput struct {
+	PaymentID int64  `json:"paymentId"`
+	Category  string `json:"category"`
+}
+
+// CategoryRuleInput represents input used to create category rule.
+type CategoryRuleInput struct {
+	Keyword  string `json:"keyword"`
+	Category string `json:"category"`
+}
+
+// Budgets - represents monthly budgets service interface.
+type Budgets interface {
+	CreateBudget(ctx context.Context, userId int, inp *BudgetInput) (*BudgetProgress, error)
+	UpdateBudget(ctx context.Context, userId int, inp *UpdateBudgetInput) (*BudgetProgress, error)
+	SearchBudgets(ctx context.Context, userId int) ([]BudgetProgress, error)
+	DeleteBudget(ctx context.Context, userId int, budgetId int) error
+}
+
+// BudgetInput represents input used to create budget, empty category is overall budget.
+type BudgetInput struct {
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+// UpdateBudgetInput represents input used to change budget amount.
+type UpdateBudgetInput struct {
+	BudgetID int     `json:"budgetId"`
+	Amount   float64 `json:"amount"`
+}
+
+// BudgetProgress represents budget consumption in current month.
+type BudgetProgress struct {
+	domain.Budget
+	Remaining float64 `json:"remaining"`
+	Percent   float64 `json:"percent"`
+}
+
+// Notifications - represents user notifications service interface.
+type Notifications interface {
+	SearchNotifications(ctx context.Context, filter *domain.Filter, userId int, unread bool) (*SearchNotifications, error)
+	MarkNotificationsRead(ctx context.Context, userId int, ids []int) (int64, error)
+	GetNotificationPreferences(ctx context.Context, userId int) ([]domain.NotificationPreference, error)
+	UpdateNotificationPreferences(ctx context.Context, userId int, inp []NotificationPreferenceInput) ([]domain.NotificationPreference, error)
+	PreviewEmail(ctx context.Context, name string, language string) (*templates.Email, error)
+	RegisterDevice(ctx context.Context, userId int, inp *DeviceInput) (*domain.DeviceToken, error)
+	SearchDevices(ctx context.Context, userId int) ([]domain.DeviceToken, error)
+	DeleteDevice(ctx context.Context, userId int, deviceId int) error
+	SearchNotificationDeliveries(ctx context.Context, filter *domain.Filter, userId int, channel string) (*SearchNotificationDeliveries, error)
+	DeliverNotifications(ctx context.Context, now time.Time) (int, error)
+}
+
+// SearchNotifications represents notifications info, Unread counts all unread notifications of user.
+type SearchNotifications struct {
+	Data       []domain.Notification `json:"data"`
+	Unread     int64                 `json:"unread"`
+	Pagination *domain.Pagination    `json:"pagination"`
+}
+
+// NotificationPreferenceInput represents channels user enables for event.
+// SMS and Push keep their current value when nil.
+type NotificationPreferenceInput struct {
+	Event string `json:"event"`
+	Email bool   `json:"email"`
+	Inbox bool   `json:"inbox"`
+	SMS   *bool  `json:"sms"`
+	Push  *bool  `json:"push"`
+}
+
+// DeviceInput represents input used to register device for push notifications.
+type DeviceInput struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`
+}
+
+// SearchNotificationDeliveries represents sms and push deliveries info.
+type SearchNotificationDeliveries struct {
+	Data       []domain.NotificationDelivery `json:"data"`
+	Pagination *domain.Pagination            `json:"pagination"`
+}
+
+// Emails - represents email outbox service interface.
+type Emails interface {
+	SearchOutboxEmails(ctx context.Context, filter *domain.Filter, status string) (*SearchOutboxEmails, error)
+	RetryOutboxEmail(ctx context.Context, emailId int) (*domain.OutboxEmail, error)
+	DeliverEmails(ctx context.Context, now time.Time) (int, error)
+	SearchCapturedEmails(ctx context.Context) ([]CapturedEmail, error)
+	ClearCapturedEmails(ctx context.Context) error
+}
+
+// SearchOutboxEmails represents outbox emails info, Counts holds number of emails in every status.
+type SearchOutboxEmails struct {
+	Data       []domain.OutboxEmail `json:"data"`
+	Counts     map[string]int64     `json:"counts"`
+	Pagination *domain.Pagination   `json:"pagination"`
+}
+
+// Roles - represents roles and permissions service interface.
+type Roles interface {
+	SearchRoles(ctx context.Context) ([]domain.Role, error)
+	GetUserRoles(ctx context.Context, userId int) ([]domain.Role, error)
+	GetUserPermissions(ctx context.Context, userId int) (domain.Permissions, error)
+	AssignUserRoles(ctx context.Context, userId int, roles []string) ([]domain.Role, error)
+}
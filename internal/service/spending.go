@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+const (
+	// topCounterparties - how many counterparties with largest spending are shown in analytics.
+	topCounterparties = 10
+	// maxCategoryRules - how many category rules user can have.
+	maxCategoryRules = 100
+)
+
+// SpendingService - represents spending categorization and analytics service.
+type SpendingService struct {
+	repos Repositories
+}
+
+// NewSpendingService - creates instance of new spending service.
+func NewSpendingService(repos Repositories) *SpendingService {
+	return &SpendingService{repos}
+}
+
+// GetSpendingAnalytics is used for getting spending per category, month and counterparty
+// compared with previous period of the same length.
+func (s *SpendingService) GetSpendingAnalytics(ctx context.Context, userId int, inp *SpendingAnalyticsInput) (*SpendingAnalytics, error) {
+	now := time.Now()
+	to := now
+	if inp.To != nil {
+		to = *inp.To
+	}
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, to.Location())
+	if inp.From != nil {
+		from = *inp.From
+	}
+	if !from.Before(to) {
+		return nil, &Error{Message: "Date range start must be before its end"}
+	}
+	previousFrom := from.Add(-to.Sub(from))
+
+	// api key request gets only spending of merchant settlement account
+	iban, err := merchantAccountIBAN(ctx, s.repos, inp.AccountIBAN)
+	if err != nil {
+		return nil, err
+	}
+	inp.AccountIBAN = iban
+
+	// check membership
+	if inp.AccountIBAN != "" {
+		account, err := s.repos.Banks.GetInfoByIBAN(ctx, inp.AccountIBAN)
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sum := func(groupBy string, from, to time.Time, limit int) ([]SpendingTotal, error) {
+		return s.repos.Spending.SumSpending(ctx, userId, &SpendingQuery{
+			AccountIBAN: inp.AccountIBAN,
+			From:        from,
+			To:          to,
+			GroupBy:     groupBy,
+			Limit:       limit,
+		})
+	}
+
+	categories, err := sum("category", from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	previousCategories, err := sum("category", previousFrom, from, 0)
+	if err != nil {
+		return nil, err
+	}
+	counterparties, err := sum("counterparty", from, to, topCounterparties)
+	if err != nil {
+		return nil, err
+	}
+	previousCounterparties, err := sum("counterparty", previousFrom, from, 0)
+	if err != nil {
+		return nil, err
+	}
+	months, err := sum("month", from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range months {
+		months[i].Total = roundAmount(months[i].Total)
+	}
+
+	analytics := &SpendingAnalytics{
+		From:           from,
+		To:             to,
+		PreviousFrom:   previousFrom,
+		PreviousTo:     from,
+		Categories:     compareSpending(categories, previousCategories),
+		Counterparties: compareSpending(counterparties, previousCounterparties),
+		Months:         months,
+	}
+	for _, c := range categories {
+		analytics.Total += c.Total
+	}
+	for _, c := range previousCategories {
+		analytics.PreviousTotal += c.Total
+	}
+	analytics.Total = roundAmount(analytics.Total)
+	analytics.PreviousTotal = roundAmount(analytics.PreviousTotal)
+	analytics.Change = spendingChange(analytics.Total, analytics.PreviousTotal)
+
+	return analytics, nil
+}
+
+// SetPaymentCategory is used for choosing category of payment sent from user account.
+func (s *SpendingService) SetPaymentCategory(ctx context.Context, userId int, inp *PaymentCategoryInput) (*domain.Payment, error) {
+	if inp.Category != "" && !domain.IsPaymentCategory(inp.Category) {
+		return nil, &Error{Message: "Unknown category, allowed: " + strings.Join(domain.PaymentCategories, ", ")}
+	}
+
+	payment, err := s.repos.Payments.GetPaymentByID(ctx, inp.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check membership
+	account, err := s.repos.Banks.GetInfoByIBAN(ctx, payment.FromClientIBAN)
+	if err != nil {
+		return nil, err
+	}
+	err = checkMerchantAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+	if err != nil {
+		return nil, &Error{Message: "Payment not found"}
+	}
+
+	err = s.repos.Spending.SetPaymentCategory(ctx, payment.ID, inp.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repos.Payments.GetPaymentByID(ctx, payment.ID)
+}
+
+// CreateCategoryRule is used for creating keyword rule, payments categorized by rules are categorized again.
+func (s *SpendingService) CreateCategoryRule(ctx context.Context, userId int, inp *CategoryRuleInput) (*domain.CategoryRule, error) {
+	keyword := strings.TrimSpace(inp.Keyword)
+	if keyword == "" {
+		return nil, &Error{Message: "Keyword is required"}
+	}
+	if !domain.IsPaymentCategory(inp.Category) {
+		return nil, &Error{Message: "Unknown category, allowed: " + strings.Join(domain.PaymentCategories, ", ")}
+	}
+
+	rules, err := s.repos.Spending.SearchCategoryRules(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) >= maxCategoryRules {
+		return nil, &Error{Message: "Too many category rules"}
+	}
+
+	rule, err := s.repos.Spending.CreateCategoryRule(ctx, &domain.CategoryRule{
+		UserID:   userId,
+		Keyword:  keyword,
+		Category: inp.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recategorizePayments(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// SearchCategoryRules is used for getting user category rules.
+func (s *SpendingService) SearchCategoryRules(ctx context.Context, userId int) ([]domain.CategoryRule, error) {
+	return s.repos.Spending.SearchCategoryRules(ctx, userId)
+}
+
+// DeleteCategoryRule is used for deleting user category rule, payments categorized by rules are categorized again.
+func (s *SpendingService) DeleteCategoryRule(ctx context.Context, userId int, ruleId int) error {
+	rule, err := s.repos.Spending.GetCategoryRuleByID(ctx, ruleId)
+	if err != nil {
+		return err
+	}
+	if rule.UserID != userId {
+		return &Error{Message: "Category rule not found"}
+	}
+
+	err = s.repos.Spending.DeleteCategoryRule(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.recategorizePayments(ctx, userId)
+}
+
+// CategorizePayments is used for categorizing sent payments not categorized when they were sent,
+// like transfers on account closure and deposit payouts.
+func (s *SpendingService) CategorizePayments(ctx context.Context) (int64, error) {
+	users, err := s.repos.Spending.GetUncategorizedPaymentSenders(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, userId := range users {
+		categorized, err := s.repos.Spending.CategorizePayments(ctx, userId)
+		if err != nil {
+			return count, err
+		}
+		count += categorized
+	}
+
+	return count, nil
+}
+
+// recategorizePayments - categorizes payments categorized by rules again after rules of user changed.
+func (s *SpendingService) recategorizePayments(ctx context.Context, userId int) error {
+	err := s.repos.Spending.ResetPaymentCategories(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repos.Spending.CategorizePayments(ctx, userId)
+	return err
+}
+
+// compareSpending - adds previous period totals to totals of period.
+func compareSpending(totals, previous []SpendingTotal) []SpendingComparison {
+	previousTotals := make(map[string]float64, len(previous))
+	for _, p := range previous {
+		previousTotals[p.Key] = p.Total
+	}
+
+	comparison := make([]SpendingComparison, 0, len(totals))
+	for _, t := range totals {
+		total := roundAmount(t.Total)
+		previousTotal := roundAmount(previousTotals[t.Key])
+		comparison = append(comparison, SpendingComparison{
+			Key:           t.Key,
+			Name:          t.Name,
+			Total:         total,
+			Count:         t.Count,
+			PreviousTotal: previousTotal,
+			Change:        spendingChange(total, previousTotal),
+		})
+	}
+
+	return comparison
+}
+
+// spendingChange - returns percentage change of total against previous total.
+func spendingChange(total, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := roundAmount((total - previous) / previous * 100)
+	return &change
+}
+
+// roundAmount - rounds amount to cents.
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	// third party
+	"golang.org/x/crypto/bcrypt"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+// SplitsService - represents split bills service.
+type SplitsService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewSplitsService - creates instance of new splits service.
+func NewSplitsService(repos Repositories, apis APIs) *SplitsService {
+	return &SplitsService{repos, apis}
+}
+
+// CreateSplit is used for splitting bill among participants.
+// Every participant gets money request for its share, share of creator is paid already.
+func (s *SplitsService) CreateSplit(ctx context.Context, userId int, inp *SplitInput) (*domain.Split, error) {
+	total := utils.RoundMoney(inp.Total)
+	if total <= 0 {
+		return nil, &Error{Message: "Split total must be positive"}
+	}
+	if len(inp.Participants) == 0 {
+		return nil, &Error{Message: "At least one participant is required"}
+	}
+
+	// creator account receives shares
+	account, err := s.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if account.Type != "CURRENT" || account.Status == "CLOSED" {
+		return nil, &Error{Message: "Shares can be collected only to open current account"}
+	}
+	_, err = s.repos.Banks.GetAccountMember(ctx, account.ID, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := splitShares(inp.Method, total, inp.Participants)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	requested := 0
+	seen := map[int]bool{}
+	for i := range shares {
+		if seen[shares[i].UserID] {
+			return nil, &Error{Message: "Participant is listed twice"}
+		}
+		seen[shares[i].UserID] = true
+
+		if shares[i].UserID == userId {
+			shares[i].Status = "PAID"
+			shares[i].PaidAt = &now
+			continue
+		}
+		_, err = s.repos.Users.GetUserByID(ctx, shares[i].UserID)
+		if err != nil {
+			return nil, err
+		}
+		requested++
+	}
+	if requested == 0 {
+		return nil, &Error{Message: "At least one participant besides creator is required"}
+	}
+
+	return s.repos.Splits.CreateSplit(ctx, &domain.Split{
+		CreatorID:     userId,
+		BankAccountID: account.ID,
+		Description:   inp.Description,
+		Total:         total,
+		Method:        inp.Method,
+		Shares:        shares,
+	})
+}
+
+// SearchSplits is used for getting splits user created or takes part in.
+func (s *SplitsService) SearchSplits(ctx context.Context, filter *domain.Filter, userId int) (*SearchSplits, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	return s.repos.Splits.SearchSplits(ctx, filter, userId)
+}
+
+// PaySplitShare is used for paying user share into creator account.
+func (s *SplitsService) PaySplitShare(ctx context.Context, userId int, inp *PaySplitShareInput) (*domain.Split, error) {
+	share, err := s.repos.Splits.GetSplitShareByID(ctx, inp.ShareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.UserID != userId {
+		return nil, &Error{Message: "Split share not found"}
+	}
+	if share.Status != "REQUESTED" {
+		return nil, &Error{Message: "Split share is not requested"}
+	}
+
+	split, err := s.repos.Splits.GetSplitByID(ctx, share.SplitID)
+	if err != nil {
+		return nil, err
+	}
+
+	payer, err := s.repos.Banks.CheckCreditCard(ctx, inp.CardNumber)
+	if err != nil {
+		return nil, err
+	}
+	if payer.Type == "DEPOSIT" {
+		return nil, &Error{Message: "Payments from deposit account are not allowed"}
+	}
+	if payer.Status != "ACTIVE" {
+		return nil, &Error{Message: "Bank account is not active"}
+	}
+	if payer.ID == split.BankAccountID {
+		return nil, &Error{Message: "Share cannot be paid from the account it is collected to"}
+	}
+
+	// check membership
+	err = checkAccountPayer(ctx, s.repos, payer, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// check secret value
+	err = bcrypt.CompareHashAndPassword([]byte(payer.SecretValue), []byte(inp.SecretValue))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, &Error{Message: "Wrong secret value"}
+		}
+		return nil, err
+	}
+
+	recipient, err := s.repos.Banks.GetBankAccountByID(ctx, split.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.Status == "CLOSED" {
+		return nil, &Error{Message: "Recipient bank account is closed"}
+	}
+
+	var payment *domain.Payment
+	err = s.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		payment, err = s.repos.Splits.PaySplitShare(ctx, &PaySplitShareRepoInput{
+			Split:     split,
+			Share:     share,
+			Payer:     payer,
+			Recipient: recipient,
+		})
+		if err != nil {
+			return err
+		}
+		return notifyPaymentSent(ctx, s.repos, s.apis, payment, payer.ID, recipient)
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishEvent(ctx, s.repos, "payment.sent", payer.ID, map[string]interface{}{
+		"paymentId":       payment.ID,
+		"fromClientIban":  payment.FromClientIBAN,
+		"toClientIban":    payment.ToClientIBAN,
+		"operationAmount": payment.OperationAmount,
+		"paymentStatus":   payment.PaymentStatus,
+	})
+	trackBudgets(ctx, s.repos, s.apis, payer.ID)
+
+	return s.repos.Splits.GetSplitByID(ctx, split.ID)
+}
+
+// CancelSplit is used by creator for cancelling unpaid shares of open split.
+func (s *SplitsService) CancelSplit(ctx context.Context, userId int, splitId int) (*domain.Split, error) {
+	split, err := s.repos.Splits.GetSplitByID(ctx, splitId)
+	if err != nil {
+		return nil, err
+	}
+	if split.CreatorID != userId {
+		return nil, &Error{Message: "Only creator can cancel split"}
+	}
+
+	err = s.repos.Splits.CancelSplit(ctx, split.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repos.Splits.GetSplitByID(ctx, split.ID)
+}
+
+// splitShares - calculates participant shares of total.
+// Cents left after rounding go to the first shares, so shares always add up to total.
+func splitShares(method string, total float64, participants []SplitParticipantInput) ([]domain.SplitShare, error) {
+	shares := make([]domain.SplitShare, len(participants))
+	cents := int64(math.Round(total * 100))
+
+	switch method {
+	case "equal":
+		part := cents / int64(len(participants))
+		rest := cents % int64(len(participants))
+		for i, p := range participants {
+			amount := part
+			if int64(i) < rest {
+				amount++
+			}
+			if amount == 0 {
+				return nil, &Error{Message: "Split total is too small for all participants"}
+			}
+			shares[i] = domain.SplitShare{UserID: p.UserID, Amount: float64(amount) / 100}
+		}
+	case "fixed":
+		var sum int64
+		for i, p := range participants {
+			amount := int64(math.Round(p.Amount * 100))
+			if amount <= 0 {
+				return nil, &Error{Message: "Every fixed share must be positive"}
+			}
+			sum += amount
+			shares[i] = domain.SplitShare{UserID: p.UserID, Amount: float64(amount) / 100}
+		}
+		if sum != cents {
+			return nil, &Error{Message: "Fixed shares must add up to split total"}
+		}
+	case "percentage":
+		var percents, sum int64
+		for i, p := range participants {
+			if p.Percentage <= 0 {
+				return nil, &Error{Message: "Every percentage share must be positive"}
+			}
+			percents += int64(math.Round(p.Percentage * 100))
+			amount := int64(math.Floor(float64(cents) * p.Percentage / 100))
+			sum += amount
+			shares[i] = domain.SplitShare{UserID: p.UserID, Amount: float64(amount) / 100, Percentage: p.Percentage}
+		}
+		if percents != 100*100 {
+			return nil, &Error{Message: "Percentage shares must add up to 100"}
+		}
+		for i := 0; sum < cents; i = (i + 1) % len(shares) {
+			shares[i].Amount = utils.RoundMoney(shares[i].Amount + 0.01)
+			sum++
+		}
+		for i := range shares {
+			if shares[i].Amount == 0 {
+				return nil, &Error{Message: "Split total is too small for all participants"}
+			}
+		}
+	default:
+		return nil, &Error{Message: "Unknown split method, allowed: equal, fixed, percentage"}
+	}
+
+	return shares, nil
+}
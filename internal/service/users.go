@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	// third party
@@ -13,9 +18,22 @@ import (
 
 	// external
 	"github.com/Shevchenkko/payment_system/pkg/access"
+	"github.com/Shevchenkko/payment_system/pkg/utils"
 
 	// internal
 	"github.com/Shevchenkko/payment_system/internal/domain"
+	"github.com/Shevchenkko/payment_system/internal/templates"
+)
+
+const (
+	// accessTokenTTL - lifetime of access token, its session is checked on every request anyway.
+	accessTokenTTL = 15 * time.Minute
+	// refreshTokenTTL - lifetime of refresh token, every refresh starts it again.
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// sessionUserAgentSize - size of session user agent column.
+	sessionUserAgentSize = 255
+	// sessionIPSize - size of session ip column.
+	sessionIPSize = 64
 )
 
 // UsersService - represents users service.
@@ -47,42 +65,29 @@ func (us *UsersService) SearchUsers(ctx context.Context, filter *domain.Filter)
 
 // RegisterUser is used for creating user.
 func (us *UsersService) RegisterUser(ctx context.Context, inp *RegisterUserInput) (RegisterUserOutput, error) {
+	if inp.Language != "" && !templates.IsLanguage(inp.Language) {
+		return RegisterUserOutput{}, &Error{Message: "Unknown language, allowed: " + strings.Join(templates.Languages, ", ")}
+	}
+
 	// create user in db
 	user, err := us.repos.Users.CreateUser(ctx, inp)
 	if err != nil {
 		return RegisterUserOutput{}, err
 	}
 
-	var role access.UserRole
-	if user.FullName == os.Getenv("ADMIN_USER_FULLNAME") && user.Email == os.Getenv("ADMIN_USER_EMAIL") {
-		role = access.UserRoleAdmin
-	} else {
-		role = access.UserRoleUser
-	}
-
-	// sign auth token
-	token, err := access.EncodeToken(
-		&access.Token{
-			StandardClaims: jwt.StandardClaims{
-				ExpiresAt: time.Now().Add(time.Hour * 24 * 14).Unix(), // 14 days
-				NotBefore: time.Now().Unix(),
-				Issuer:    "pay-system-api",
-				IssuedAt:  time.Now().Unix(),
-			},
-			UserID:   user.ID,
-			UserRole: role,
-		},
-		os.Getenv("HMAC_SECRET"),
-	)
+	// open session and sign auth tokens
+	tokens, err := us.openSession(ctx, user, inp.UserAgent, inp.IP)
 	if err != nil {
 		return RegisterUserOutput{}, err
 	}
 
 	return RegisterUserOutput{
-		Token:    token,
-		UserID:   user.ID,
-		FullName: user.FullName,
-		Email:    user.Email,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		UserID:       user.ID,
+		FullName:     user.FullName,
+		Email:        user.Email,
 	}, nil
 }
 
@@ -102,52 +107,127 @@ func (us *UsersService) LoginUser(ctx context.Context, inp *LoginUserInput) (Log
 
 		return LoginUserOutput{}, err
 	}
-
-	var role access.UserRole
-	if user.FullName == os.Getenv("ADMIN_USER_FULLNAME") && user.Email == os.Getenv("ADMIN_USER_EMAIL") {
-		role = access.UserRoleAdmin
-	} else {
-		role = access.UserRoleUser
+	if user.Status == "LOCK" {
+		return LoginUserOutput{}, &Error{Message: "Your account is blocked! Please, turn to the nearest branch of our bank"}
 	}
 
-	// sign auth token
-	t := time.Now()
-	token, err := access.EncodeToken(
-		&access.Token{
-			StandardClaims: jwt.StandardClaims{
-				ExpiresAt: t.Add(time.Hour * 24 * 14).Unix(), // 14 days
-				NotBefore: t.Unix(),
-				Issuer:    "pay-system-api",
-				IssuedAt:  t.Unix(),
-			},
-			UserID:   user.ID,
-			UserRole: role,
-		},
-		os.Getenv("HMAC_SECRET"),
-	)
+	// open session, sign auth tokens and queue login notification
+	var tokens SessionTokens
+	err = us.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		tokens, err = us.openSession(ctx, user, inp.UserAgent, inp.IP)
+		if err != nil {
+			return err
+		}
+		return notifyUser(ctx, us.repos, us.apis, user.ID, "user.login", "New login",
+			fmt.Sprintf("New login to your profile at %s", time.Now().Format("2006-01-02 15:04:05")))
+	})
 	if err != nil {
 		return LoginUserOutput{}, err
 	}
 
 	return LoginUserOutput{
-		Token:    token,
-		UserID:   user.ID,
-		FullName: user.FullName,
-		Email:    user.Email,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		UserID:       user.ID,
+		FullName:     user.FullName,
+		Email:        user.Email,
 	}, nil
 }
 
+// RefreshSession is used to rotate refresh token of session and sign new access token.
+// Refresh token that was already rotated revokes its session, as it was used by someone else.
+func (us *UsersService) RefreshSession(ctx context.Context, inp *RefreshSessionInput) (SessionTokens, error) {
+	hash := hashRefreshToken(inp.RefreshToken)
+	session, err := us.repos.Users.GetSessionByRefreshToken(ctx, hash)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil {
+		return SessionTokens{}, &Error{Message: "Session is revoked, please, login again"}
+	}
+	if session.RefreshTokenHash != hash {
+		err = us.repos.Users.RevokeSession(ctx, session.UserID, session.ID, "refresh_token_reuse", now)
+		if err != nil {
+			return SessionTokens{}, err
+		}
+		return SessionTokens{}, &Error{Message: "Refresh token was already used, session is revoked"}
+	}
+	if session.ExpiresAt.Before(now) {
+		return SessionTokens{}, &Error{Message: "Refresh token expired, please, login again"}
+	}
+
+	user, err := us.repos.Users.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	if user.Status == "LOCK" {
+		return SessionTokens{}, &Error{Message: "Your account is blocked! Please, turn to the nearest branch of our bank"}
+	}
+
+	// rotate refresh token
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	session.RefreshTokenHash = hashRefreshToken(refreshToken)
+	session.UserAgent = utils.Truncate(inp.UserAgent, sessionUserAgentSize)
+	session.IP = utils.Truncate(inp.IP, sessionIPSize)
+	session.ExpiresAt = now.Add(refreshTokenTTL)
+	err = us.repos.Users.RotateSession(ctx, session, hash)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	token, err := signAccessToken(user, session.ID, now)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	return SessionTokens{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Logout is used to revoke current session of user, or every session when all is set.
+func (us *UsersService) Logout(ctx context.Context, userId int, sessionId int, all bool) error {
+	if all {
+		_, err := us.repos.Users.RevokeUserSessions(ctx, userId, "logout", time.Now())
+		return err
+	}
+
+	return us.repos.Users.RevokeSession(ctx, userId, sessionId, "logout", time.Now())
+}
+
 // VerifyAccessToken is used to verify user jwt access token.
-func (us *UsersService) VerifyAccessToken(ctx context.Context, token string) (bool, int, string) {
+// Token is valid only while its session is not revoked or expired.
+func (us *UsersService) VerifyAccessToken(ctx context.Context, token string) (*access.Token, bool) {
 	tokenData, err := access.DecodeToken(token, os.Getenv("HMAC_SECRET"))
+	if err != nil || tokenData.SessionID == 0 {
+		return nil, false
+	}
+
+	session, err := us.repos.Users.GetSessionByID(ctx, tokenData.SessionID)
 	if err != nil {
-		return false, 0, ""
+		return nil, false
 	}
+	if session.UserID != tokenData.UserID || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		return nil, false
+	}
+
+	return tokenData, true
+}
 
-	return true, tokenData.UserID, string(tokenData.UserRole)
+// DeleteExpiredSessions is used for removing sessions which refresh tokens expired before time.
+func (us *UsersService) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	return us.repos.Users.DeleteExpiredSessions(ctx, before)
 }
 
-// SendEmail is used for sending email.
+// SendEmail is used for queueing reset password email.
 func (us *UsersService) SendEmail(ctx context.Context, inp *SendUserEmailInput) error {
 	// get user from db
 	user, err := us.repos.Users.GetUser(ctx, inp.Email)
@@ -155,13 +235,6 @@ func (us *UsersService) SendEmail(ctx context.Context, inp *SendUserEmailInput)
 		return err
 	}
 
-	var role access.UserRole
-	if user.FullName == os.Getenv("ADMIN_USER_FULLNAME") && user.Email == os.Getenv("ADMIN_USER_EMAIL") {
-		role = access.UserRoleAdmin
-	} else {
-		role = access.UserRoleUser
-	}
-
 	// generate token
 	t := time.Now()
 	token, err := access.EncodeToken(
@@ -172,8 +245,7 @@ func (us *UsersService) SendEmail(ctx context.Context, inp *SendUserEmailInput)
 				Issuer:    inp.Email,
 				IssuedAt:  t.Unix(),
 			},
-			UserID:   user.ID,
-			UserRole: role,
+			UserID: user.ID,
 		},
 		os.Getenv("HMAC_SECRET"),
 	)
@@ -181,39 +253,38 @@ func (us *UsersService) SendEmail(ctx context.Context, inp *SendUserEmailInput)
 		return err
 	}
 
-	// create token in db
+	email, err := templates.Render(templates.ResetPassword, user.Language, templates.ResetPasswordData{
+		FullName: user.FullName,
+		Token:    token,
+	})
+	if err != nil {
+		return err
+	}
+
+	// create token in db and queue email with it
 	err = us.repos.Users.CreateToken(ctx,
 		GenerateTokenInput{
 			Email: inp.Email,
 			Token: token,
+			Message: newOutboxEmail(SendEmailInput{
+				To:          inp.Email,
+				Subject:     email.Subject,
+				ContentType: "text/html",
+				Body:        email.HTML,
+				TextBody:    email.Text,
+			}),
 		})
 	if err != nil {
 		return err
 	}
 
-	err = us.apis.Emails.SendEmail(ctx, SendEmailInput{
-		To:          inp.Email,
-		Subject:     "PaySystem: reset password",
-		ContentType: "text/html",
-		Body: fmt.Sprintf(`
-			<h2>PaySystem: reset password</h1>
-	    <p>Hello!</p>
-	    <p>Someone (we hope it was you) decided to change the forgotten password for the PaySystem account associated with this email address.<p>
-		<p>Please, use this token for to change your account password:</p>
-	    <p>token=%s</p>
-		`, token),
-	})
-	if err != nil {
-		return err
-	}
-
 	return err
 }
 
 // ResetPassword is used for reset password.
 func (us *UsersService) ResetPassword(ctx context.Context, inp *ResetPasswordInput) error {
 	// get token from db
-	_, err := us.repos.Users.GetToken(ctx, inp.Token)
+	userToken, err := us.repos.Users.GetToken(ctx, inp.Token)
 	if err != nil {
 		return err
 	}
@@ -239,6 +310,16 @@ func (us *UsersService) ResetPassword(ctx context.Context, inp *ResetPasswordInp
 		return err
 	}
 
+	// revoke sessions opened with old password
+	user, err := us.repos.Users.GetUser(ctx, userToken.Email)
+	if err != nil {
+		return err
+	}
+	_, err = us.repos.Users.RevokeUserSessions(ctx, user.ID, "password_reset", time.Now())
+	if err != nil {
+		return err
+	}
+
 	// delete token in db
 	err = us.repos.Users.DeleteToken(ctx, inp.Token)
 	if err != nil {
@@ -249,49 +330,151 @@ func (us *UsersService) ResetPassword(ctx context.Context, inp *ResetPasswordInp
 }
 
 // LockUser is used for lock user.
-func (us *UsersService) LockUser(ctx context.Context, userId int64, userRole string) (string, error) {
+func (us *UsersService) LockUser(ctx context.Context, userId int64) (string, error) {
 	status, err := us.repos.Users.GetUserByID(ctx, int(userId))
 	if err != nil {
 		return "", err
 	}
-	var accountChange string
+	if status.Status != "ACTIVE" {
+		return "The account has already been blocked", nil
+	}
 
-	// check user role
-	if userRole == "admin" {
-		if status.Status == "ACTIVE" {
-			accountChange, err = us.repos.Users.ChangeUserStatus(ctx, userId, "LOCK")
-			if err != nil {
-				return "", err
-			}
-		} else {
-			accountChange = "The account has already been blocked"
+	var accountChange string
+	err = us.repos.Transactions.InTransaction(ctx, func(ctx context.Context) error {
+		accountChange, err = us.repos.Users.ChangeUserStatus(ctx, userId, "LOCK")
+		if err != nil {
+			return err
+		}
+		_, err = us.repos.Users.RevokeUserSessions(ctx, status.ID, "locked", time.Now())
+		if err != nil {
+			return err
 		}
-	} else {
-		accountChange = "You need admin rights!"
+		return notifyUser(ctx, us.repos, us.apis, status.ID, "user.locked", "Profile locked",
+			"Your profile was locked by the bank. Please, turn to the nearest branch of our bank")
+	})
+	if err != nil {
+		return "", err
 	}
+
 	return accountChange, nil
 }
 
 // UnlockUser is used for unlock user.
-func (us *UsersService) UnlockUser(ctx context.Context, userId int64, userRole string) (string, error) {
+func (us *UsersService) UnlockUser(ctx context.Context, userId int64) (string, error) {
 	status, err := us.repos.Users.GetUserByID(ctx, int(userId))
 	if err != nil {
 		return "", err
 	}
-	var accountChange string
+	if status.Status != "LOCK" {
+		return "The account has already been active", nil
+	}
 
-	// check user role
-	if userRole == "admin" {
-		if status.Status == "LOCK" {
-			accountChange, err = us.repos.Users.ChangeUserStatus(ctx, userId, "ACTIVE")
-			if err != nil {
-				return "", err
-			}
-		} else {
-			accountChange = "The account has already been active"
-		}
-	} else {
-		accountChange = "You need admin rights!"
+	return us.repos.Users.ChangeUserStatus(ctx, userId, "ACTIVE")
+}
+
+// UpdateUserLanguage is used for changing language user receives emails in.
+func (us *UsersService) UpdateUserLanguage(ctx context.Context, userId int, language string) error {
+	if !templates.IsLanguage(language) {
+		return &Error{Message: "Unknown language, allowed: " + strings.Join(templates.Languages, ", ")}
 	}
-	return accountChange, nil
+
+	return us.repos.Users.UpdateUserLanguage(ctx, userId, language)
+}
+
+// phonePattern - matches phone number in E.164 format.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// UpdateUserPhone is used for changing phone number sms notifications are sent to, empty phone removes it.
+func (us *UsersService) UpdateUserPhone(ctx context.Context, userId int, phone string) error {
+	phone = strings.ReplaceAll(phone, " ", "")
+	if phone != "" && !phonePattern.MatchString(phone) {
+		return &Error{Message: "Phone number must be in international format, for example +380501234567"}
+	}
+
+	return us.repos.Users.UpdateUserPhone(ctx, userId, phone)
+}
+
+// SetUserPassword is used for setting new password of user without reset token and revoking their sessions.
+func (us *UsersService) SetUserPassword(ctx context.Context, userId int, password string) error {
+	if password == "" {
+		return &Error{Message: "Password must not be empty"}
+	}
+
+	passwordBytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	if err != nil {
+		return err
+	}
+
+	err = us.repos.Users.UpdateUserPassword(ctx, userId, string(passwordBytes))
+	if err != nil {
+		return err
+	}
+
+	_, err = us.repos.Users.RevokeUserSessions(ctx, userId, "password_reset", time.Now())
+	return err
+}
+
+// openSession - creates session of user with new refresh token and signs access token bound to it.
+func (us *UsersService) openSession(ctx context.Context, user *domain.User, userAgent string, ip string) (SessionTokens, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	t := time.Now()
+	session := &domain.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		UserAgent:        utils.Truncate(userAgent, sessionUserAgentSize),
+		IP:               utils.Truncate(ip, sessionIPSize),
+		ExpiresAt:        t.Add(refreshTokenTTL),
+	}
+	err = us.repos.Users.CreateSession(ctx, session)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	token, err := signAccessToken(user, session.ID, t)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	return SessionTokens{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// signAccessToken - signs short-lived access token of user bound to session.
+// Permissions are not signed into token, they are read from user roles on every request.
+func signAccessToken(user *domain.User, sessionId int, t time.Time) (string, error) {
+	return access.EncodeToken(
+		&access.Token{
+			StandardClaims: jwt.StandardClaims{
+				ExpiresAt: t.Add(accessTokenTTL).Unix(),
+				NotBefore: t.Unix(),
+				Issuer:    "pay-system-api",
+				IssuedAt:  t.Unix(),
+			},
+			UserID:    user.ID,
+			SessionID: sessionId,
+		},
+		os.Getenv("HMAC_SECRET"),
+	)
+}
+
+// newRefreshToken - generates random opaque refresh token.
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashRefreshToken - returns hash refresh token is stored by.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	// external
+	"github.com/Shevchenkko/payment_system/pkg/utils"
+
+	// internal
+	"github.com/Shevchenkko/payment_system/internal/domain"
+)
+
+const (
+	// webhookMaxAttempts - number of attempts after which delivery is failed.
+	webhookMaxAttempts = 8
+	// webhookRetryBase - delay before the second attempt, doubled for every next one.
+	webhookRetryBase = 30 * time.Second
+	// webhookBatchSize - number of deliveries sent by one run.
+	webhookBatchSize = 100
+)
+
+// WebhooksService - represents webhooks service.
+type WebhooksService struct {
+	repos Repositories
+	apis  APIs
+}
+
+// NewWebhooksService - creates instance of new webhooks service.
+func NewWebhooksService(repos Repositories, apis APIs) *WebhooksService {
+	return &WebhooksService{repos, apis}
+}
+
+// CreateWebhook is used for registering webhook url for event types.
+// Signing secret is returned only here.
+func (w *WebhooksService) CreateWebhook(ctx context.Context, userId int, inp *WebhookInput) (*domain.Webhook, error) {
+	u, err := url.Parse(inp.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, &Error{Message: "Webhook url must be absolute http or https url"}
+	}
+	err = checkWebhookHost(ctx, u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if len(inp.Events) == 0 {
+		return nil, &Error{Message: "At least one event type is required"}
+	}
+	events := map[string]bool{}
+	for _, event := range inp.Events {
+		if !isWebhookEvent(event) {
+			return nil, &Error{Message: "Unknown event type " + event + ", allowed: " + strings.Join(domain.WebhookEvents, ", ")}
+		}
+		events[event] = true
+	}
+	list := make([]string, 0, len(events))
+	for event := range events {
+		list = append(list, event)
+	}
+	sort.Strings(list)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	// create webhook in db
+	webhook, err := w.repos.Webhooks.CreateWebhook(ctx, &domain.Webhook{
+		UserID: userId,
+		URL:    inp.URL,
+		Secret: hex.EncodeToString(secret),
+		Events: strings.Join(list, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// SearchWebhooks is used for getting user webhooks.
+func (w *WebhooksService) SearchWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error) {
+	webhooks, err := w.repos.Webhooks.SearchWebhooks(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook is used for removing user webhook.
+func (w *WebhooksService) DeleteWebhook(ctx context.Context, userId int, webhookId int) error {
+	webhook, err := w.getUserWebhook(ctx, userId, webhookId)
+	if err != nil {
+		return err
+	}
+
+	return w.repos.Webhooks.DeleteWebhook(ctx, webhook.ID)
+}
+
+// SearchWebhookDeliveries is used for getting delivery log of user webhook.
+func (w *WebhooksService) SearchWebhookDeliveries(ctx context.Context, filter *domain.Filter, userId int, webhookId int) (*SearchWebhookDeliveries, error) {
+	if filter == nil {
+		filter = new(domain.Filter)
+		filter.Validate()
+	}
+
+	webhook, err := w.getUserWebhook(ctx, userId, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.repos.Webhooks.SearchWebhookDeliveries(ctx, filter, webhook.ID)
+}
+
+// ReplayWebhookDelivery is used for sending delivery again.
+func (w *WebhooksService) ReplayWebhookDelivery(ctx context.Context, userId int, deliveryId int) (*domain.WebhookDelivery, error) {
+	delivery, err := w.repos.Webhooks.GetWebhookDeliveryByID(ctx, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.getUserWebhook(ctx, userId, delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == "PENDING" {
+		return nil, &Error{Message: "Delivery is already scheduled"}
+	}
+
+	now := time.Now()
+	delivery.Status = "PENDING"
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	err = w.repos.Webhooks.UpdateWebhookDelivery(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// PublishEvent is used for scheduling event deliveries to webhooks of bank account members.
+func (w *WebhooksService) PublishEvent(ctx context.Context, event string, accountId int, data interface{}) error {
+	return schedulePublishedEvent(ctx, w.repos, event, accountId, data)
+}
+
+// publishEvent - schedules event deliveries after operation is committed.
+// Operation is not failed when event can not be scheduled.
+func publishEvent(ctx context.Context, repos Repositories, event string, accountId int, data interface{}) {
+	_ = schedulePublishedEvent(ctx, repos, event, accountId, data)
+}
+
+// schedulePublishedEvent - schedules event deliveries to webhooks of bank account members.
+func schedulePublishedEvent(ctx context.Context, repos Repositories, event string, accountId int, data interface{}) error {
+	webhooks, err := repos.Webhooks.GetAccountWebhooks(ctx, accountId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":     event,
+		"createdAt": now,
+		"data":      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	var deliveries []domain.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event) {
+			continue
+		}
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        "PENDING",
+			NextAttemptAt: &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return repos.Webhooks.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+// DeliverWebhooks is used for sending due deliveries and scheduling retries with exponential backoff.
+func (w *WebhooksService) DeliverWebhooks(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := w.repos.Webhooks.GetDueWebhookDeliveries(ctx, now, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if delivery.Webhook == nil {
+			delivery.Status = "FAILED"
+			delivery.NextAttemptAt = nil
+			delivery.LastError = "webhook deleted"
+			if err := w.repos.Webhooks.UpdateWebhookDelivery(ctx, delivery); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		code, err := w.apis.Webhooks.SendWebhook(ctx, SendWebhookInput{
+			URL:        delivery.Webhook.URL,
+			Secret:     delivery.Webhook.Secret,
+			Event:      delivery.Event,
+			DeliveryID: delivery.ID,
+			Payload:    []byte(delivery.Payload),
+		})
+
+		at := time.Now()
+		delivery.Attempts++
+		delivery.ResponseCode = code
+		switch {
+		case err == nil:
+			delivery.Status = "DELIVERED"
+			delivery.DeliveredAt = &at
+			delivery.NextAttemptAt = nil
+			delivery.LastError = ""
+			delivered++
+		case delivery.Attempts >= webhookMaxAttempts:
+			delivery.Status = "FAILED"
+			delivery.NextAttemptAt = nil
+			delivery.LastError = err.Error()
+		default:
+			next := at.Add(webhookRetryBase << (delivery.Attempts - 1))
+			delivery.NextAttemptAt = &next
+			delivery.LastError = err.Error()
+		}
+
+		err = w.repos.Webhooks.UpdateWebhookDelivery(ctx, delivery)
+		if err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// getUserWebhook - returns webhook if it belongs to user.
+func (w *WebhooksService) getUserWebhook(ctx context.Context, userId int, webhookId int) (*domain.Webhook, error) {
+	webhook, err := w.repos.Webhooks.GetWebhookByID(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != userId {
+		return nil, &Error{Message: "Webhook not found"}
+	}
+
+	return webhook, nil
+}
+
+// checkWebhookHost - checks every address of webhook host is public, so webhooks can not reach
+// internal services. Deliveries check the address they connect to again, host can be resolved differently later.
+func checkWebhookHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return &Error{Message: "Webhook host can not be resolved"}
+	}
+	for _, addr := range addrs {
+		if !utils.IsPublicIP(addr.IP) {
+			return &Error{Message: "Webhook url must point to public address"}
+		}
+	}
+
+	return nil
+}
+
+// isWebhookEvent - reports whether event type is supported.
+func isWebhookEvent(event string) bool {
+	for _, e := range domain.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
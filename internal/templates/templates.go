@@ -0,0 +1,147 @@
+// Package templates renders localized emails from html/template and text/template
+// files embedded into the binary. Every email is rendered into the shared layout
+// in both HTML and plain text.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	// LanguageUK - Ukrainian emails, used when language is unknown.
+	LanguageUK = "uk"
+	// LanguageEN - English emails.
+	LanguageEN = "en"
+
+	// ResetPassword - email with password reset token, rendered with ResetPasswordData.
+	ResetPassword = "reset_password"
+	// Notification - email of notification event, rendered with NotificationData.
+	Notification = "notification"
+)
+
+// Languages - represents languages emails are translated to.
+var Languages = []string{LanguageUK, LanguageEN}
+
+// Names - represents templates of emails.
+var Names = []string{ResetPassword, Notification}
+
+//go:embed emails
+var files embed.FS
+
+var (
+	htmlTemplates = map[string]*htmltemplate.Template{}
+	textTemplates = map[string]*texttemplate.Template{}
+)
+
+// every template is parsed at start, so broken template fails application start instead of email
+func init() {
+	for _, language := range Languages {
+		for _, name := range Names {
+			htmlTemplates[key(language, name)] = htmltemplate.Must(htmltemplate.ParseFS(files,
+				"emails/layout.html",
+				path.Join("emails", language, "common.tmpl"),
+				path.Join("emails", language, name+".html"),
+			))
+			textTemplates[key(language, name)] = texttemplate.Must(texttemplate.ParseFS(files,
+				"emails/layout.txt",
+				path.Join("emails", language, "common.tmpl"),
+				path.Join("emails", language, name+".txt"),
+			))
+		}
+	}
+}
+
+// Email - represents rendered email.
+type Email struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// ResetPasswordData - represents data of reset password email.
+type ResetPasswordData struct {
+	FullName string
+	Token    string
+}
+
+// NotificationData - represents data of notification email.
+// Event selects translated heading, Title is used for events without one.
+type NotificationData struct {
+	FullName string
+	Event    string
+	Title    string
+	Body     string
+}
+
+// samples - represents data emails are previewed with.
+var samples = map[string]interface{}{
+	ResetPassword: ResetPasswordData{
+		FullName: "Taras Shevchenko",
+		Token:    "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.sample.token",
+	},
+	Notification: NotificationData{
+		FullName: "Taras Shevchenko",
+		Event:    "payment.received",
+		Title:    "Payment received",
+		Body:     "150.00 received on UA213223130000026007233566001 from Lesya Ukrainka (UA903052992990004149123456789): dinner",
+	},
+}
+
+// IsLanguage reports whether emails are translated to language.
+func IsLanguage(language string) bool {
+	for _, l := range Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Render - returns email of template in language, Ukrainian one when language is unknown.
+func Render(name, language string, data interface{}) (*Email, error) {
+	if !IsLanguage(language) {
+		language = LanguageUK
+	}
+	html, ok := htmlTemplates[key(language, name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	text := textTemplates[key(language, name)]
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := text.ExecuteTemplate(&textBody, "layout", data); err != nil {
+		return nil, err
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return nil, err
+	}
+
+	return &Email{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    htmlBody.String(),
+		Text:    textBody.String(),
+	}, nil
+}
+
+// Preview - returns email of template in language rendered with sample data.
+func Preview(name, language string) (*Email, error) {
+	data, ok := samples[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	return Render(name, language, data)
+}
+
+// key - returns key of template in language.
+func key(language, name string) string {
+	return language + "/" + name
+}
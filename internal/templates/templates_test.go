@@ -0,0 +1,105 @@
+package templates
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update - rewrites golden files with rendered emails: go test ./internal/templates -update
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGolden(t *testing.T) {
+	for _, language := range Languages {
+		for _, name := range Names {
+			t.Run(language+"/"+name, func(t *testing.T) {
+				email, err := Preview(name, language)
+				if err != nil {
+					t.Fatalf("Preview(%q, %q) error = %v", name, language, err)
+				}
+
+				checkGolden(t, name+"."+language+".html.golden", email.HTML)
+				checkGolden(t, name+"."+language+".txt.golden", "Subject: "+email.Subject+"\n\n"+email.Text)
+				checkHeadings(t, email.HTML)
+			})
+		}
+	}
+}
+
+func TestRenderUnknownLanguage(t *testing.T) {
+	want, err := Preview(ResetPassword, LanguageUK)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	got, err := Preview(ResetPassword, "fr")
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if *got != *want {
+		t.Errorf("email in unknown language is not Ukrainian one")
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("welcome", LanguageEN, nil); err == nil {
+		t.Errorf("Render() of unknown template error = nil")
+	}
+	if _, err := Preview("welcome", LanguageEN); err == nil {
+		t.Errorf("Preview() of unknown template error = nil")
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	email, err := Render(Notification, LanguageEN, NotificationData{
+		FullName: "<script>alert(1)</script>",
+		Event:    "custom.event",
+		Title:    "Title & more",
+		Body:     "<b>body</b>",
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(email.HTML, "<script>") || strings.Contains(email.HTML, "<b>body</b>") {
+		t.Errorf("html email is not escaped:\n%s", email.HTML)
+	}
+	if !strings.Contains(email.Text, "<b>body</b>") {
+		t.Errorf("text email is escaped:\n%s", email.Text)
+	}
+}
+
+// checkGolden - compares rendered email with golden file, rewrites it with -update.
+func checkGolden(t *testing.T, file string, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", file)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v, run go test ./internal/templates -update", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from rendered email:\n--- want\n%s\n--- got\n%s", golden, want, got)
+	}
+}
+
+// checkHeadings - checks every heading is closed by tag of the same level.
+func checkHeadings(t *testing.T, html string) {
+	t.Helper()
+	for _, tag := range []string{"h1", "h2", "h3"} {
+		opened := strings.Count(html, "<"+tag+">") + strings.Count(html, "<"+tag+" ")
+		closed := strings.Count(html, "</"+tag+">")
+		if opened != closed {
+			t.Errorf("%d <%s> opened, %d closed", opened, tag, closed)
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"os"
+
 	"github.com/Shevchenkko/payment_system/internal/app"
 )
 
 func main() {
+	// run reconciliation command
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		app.Reconcile(os.Args[2:])
+		return
+	}
+
 	// run app
 	app.Run()
 }
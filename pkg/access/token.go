@@ -9,22 +9,14 @@ import (
 
 // Token represents authentication token struct.
 // Used for protecting private endpoints.
+// SessionID is set only in access tokens, which are valid while their session is not revoked.
 type Token struct {
 	jwt.StandardClaims
 
-	UserID   int      `json:"userId"`
-	UserRole UserRole `json:"userRole"`
+	UserID    int `json:"userId"`
+	SessionID int `json:"sessionId,omitempty"`
 }
 
-// Role represents a user role.
-type UserRole string
-
-// RoleUser represents a plain user role.
-var UserRoleUser UserRole = "user"
-
-// RoleAdmin represents a plain user role.
-var UserRoleAdmin UserRole = "admin"
-
 // EncodeToken is used to encode Token to string.
 func EncodeToken(token *Token, hmacSecret string) (string, error) {
 	// create jwt token object
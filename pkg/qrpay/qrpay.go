@@ -0,0 +1,312 @@
+// Package qrpay encodes and decodes credit transfer QR payloads in EMVCo
+// merchant-presented format and in the NBU (National Bank of Ukraine) format.
+package qrpay
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// FormatEMV - EMVCo merchant-presented mode payload.
+	FormatEMV = "emv"
+	// FormatNBU - NBU credit transfer payload wrapped into bank.gov.ua link.
+	FormatNBU = "nbu"
+
+	// nbuURL - prefix of NBU payload link, payload follows base64url encoded.
+	nbuURL = "https://bank.gov.ua/qr/"
+	// emvAccountGUI - globally unique id of account information template.
+	emvAccountGUI = "UA.IBAN"
+	// emvCurrencyUAH - ISO 4217 numeric code of hryvnia.
+	emvCurrencyUAH = "980"
+)
+
+// ErrInvalidPayload - returned when payload is not recognized or malformed.
+var ErrInvalidPayload = errors.New("invalid qr payload")
+
+// Payload - represents credit transfer carried by qr code.
+type Payload struct {
+	Name    string
+	IBAN    string
+	Code    string
+	Amount  float64
+	Purpose string
+	City    string
+}
+
+// Encode - returns payload in requested format.
+func Encode(format string, p Payload) (string, error) {
+	switch format {
+	case FormatEMV:
+		return EncodeEMV(p)
+	case FormatNBU:
+		return EncodeNBU(p), nil
+	}
+	return "", fmt.Errorf("unknown qr format %q", format)
+}
+
+// Decode - detects payload format and decodes it.
+func Decode(s string) (*Payload, string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, nbuURL), strings.HasPrefix(s, "BCD"):
+		p, err := DecodeNBU(s)
+		return p, FormatNBU, err
+	case strings.HasPrefix(s, "000201"):
+		p, err := DecodeEMV(s)
+		return p, FormatEMV, err
+	}
+	return nil, "", ErrInvalidPayload
+}
+
+// EncodeNBU - returns NBU version 002 payload link.
+func EncodeNBU(p Payload) string {
+	amount := ""
+	if p.Amount > 0 {
+		amount = "UAH" + strconv.FormatFloat(p.Amount, 'f', 2, 64)
+	}
+	lines := []string{
+		"BCD",     // service mark
+		"002",     // format version
+		"1",       // encoding, utf-8
+		"UCT",     // function, ukrainian credit transfer
+		"",        // bic
+		p.Name,    // recipient
+		p.IBAN,    // recipient account
+		amount,    // currency and amount
+		p.Code,    // recipient code
+		"",        // purpose code
+		"",        // reference
+		p.Purpose, // purpose of payment
+		"",        // display
+	}
+	return nbuURL + base64.RawURLEncoding.EncodeToString([]byte(strings.Join(lines, "\n")))
+}
+
+// DecodeNBU - decodes NBU payload, either link or plain text of version 001 or 002.
+func DecodeNBU(s string) (*Payload, error) {
+	if strings.HasPrefix(s, nbuURL) {
+		encoded := strings.TrimRight(strings.TrimPrefix(s, nbuURL), "=")
+		data, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidPayload
+		}
+		s = string(data)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if len(lines) < 7 || lines[0] != "BCD" || (lines[1] != "001" && lines[1] != "002") || lines[3] != "UCT" {
+		return nil, ErrInvalidPayload
+	}
+	line := func(i int) string {
+		if i < len(lines) {
+			return strings.TrimSpace(lines[i])
+		}
+		return ""
+	}
+
+	p := &Payload{
+		Name:    line(5),
+		IBAN:    line(6),
+		Code:    line(8),
+		Purpose: line(11),
+	}
+	if amount := line(7); amount != "" {
+		if !strings.HasPrefix(amount, "UAH") {
+			return nil, ErrInvalidPayload
+		}
+		value, err := strconv.ParseFloat(strings.TrimPrefix(amount, "UAH"), 64)
+		if err != nil {
+			return nil, ErrInvalidPayload
+		}
+		p.Amount = value
+	}
+	if p.IBAN == "" {
+		return nil, ErrInvalidPayload
+	}
+
+	return p, nil
+}
+
+// EncodeEMV - returns EMVCo merchant-presented payload.
+// Payload with amount is dynamic, without amount static.
+func EncodeEMV(p Payload) (string, error) {
+	account, err := tlv("00", emvAccountGUI)
+	if err != nil {
+		return "", err
+	}
+	iban, err := tlv("01", p.IBAN)
+	if err != nil {
+		return "", err
+	}
+
+	initiation := "11"
+	if p.Amount > 0 {
+		initiation = "12"
+	}
+	city := p.City
+	if city == "" {
+		city = "Kyiv"
+	}
+
+	fields := [][2]string{
+		{"00", "01"},
+		{"01", initiation},
+		{"26", account + iban},
+		{"52", "0000"},
+		{"53", emvCurrencyUAH},
+	}
+	if p.Amount > 0 {
+		fields = append(fields, [2]string{"54", strconv.FormatFloat(p.Amount, 'f', 2, 64)})
+	}
+	fields = append(fields,
+		[2]string{"58", "UA"},
+		[2]string{"59", truncate(p.Name, 25)},
+		[2]string{"60", truncate(city, 15)},
+	)
+	if p.Purpose != "" {
+		purpose, err := tlv("08", truncate(p.Purpose, 95))
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, [2]string{"62", purpose})
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		field, err := tlv(f[0], f[1])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(field)
+	}
+	b.WriteString("6304")
+	b.WriteString(fmt.Sprintf("%04X", crc16(b.String())))
+
+	return b.String(), nil
+}
+
+// DecodeEMV - decodes EMVCo merchant-presented payload and checks its crc.
+func DecodeEMV(s string) (*Payload, error) {
+	if len(s) < 8 || s[len(s)-8:len(s)-4] != "6304" {
+		return nil, ErrInvalidPayload
+	}
+	crc, err := strconv.ParseUint(s[len(s)-4:], 16, 16)
+	if err != nil || uint16(crc) != crc16(s[:len(s)-4]) {
+		return nil, ErrInvalidPayload
+	}
+
+	fields, err := parseTLV(s[:len(s)-8])
+	if err != nil {
+		return nil, err
+	}
+	if fields["53"] != "" && fields["53"] != emvCurrencyUAH {
+		return nil, ErrInvalidPayload
+	}
+
+	p := &Payload{
+		Name: fields["59"],
+		City: fields["60"],
+	}
+	for tag := 26; tag <= 51; tag++ {
+		value, ok := fields[strconv.Itoa(tag)]
+		if !ok {
+			continue
+		}
+		account, err := parseTLV(value)
+		if err != nil {
+			return nil, err
+		}
+		if account["00"] == emvAccountGUI {
+			p.IBAN = account["01"]
+			break
+		}
+	}
+	if p.IBAN == "" {
+		return nil, ErrInvalidPayload
+	}
+	if amount := fields["54"]; amount != "" {
+		p.Amount, err = strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return nil, ErrInvalidPayload
+		}
+	}
+	if additional := fields["62"]; additional != "" {
+		data, err := parseTLV(additional)
+		if err != nil {
+			return nil, err
+		}
+		p.Purpose = data["08"]
+	}
+
+	return p, nil
+}
+
+// tlv - returns EMV tag-length-value field.
+func tlv(tag string, value string) (string, error) {
+	if len(value) > 99 {
+		return "", fmt.Errorf("qr field %s is too long", tag)
+	}
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value), nil
+}
+
+// parseTLV - splits EMV tag-length-value fields.
+func parseTLV(s string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(s) > 0 {
+		if len(s) < 4 {
+			return nil, ErrInvalidPayload
+		}
+		// length is exactly two ascii digits, strconv would accept sign
+		if !isDigit(s[2]) || !isDigit(s[3]) {
+			return nil, ErrInvalidPayload
+		}
+		length := int(s[2]-'0')*10 + int(s[3]-'0')
+		if len(s) < 4+length {
+			return nil, ErrInvalidPayload
+		}
+		fields[s[:2]] = s[4 : 4+length]
+		s = s[4+length:]
+	}
+	return fields, nil
+}
+
+// isDigit - reports whether byte is ascii digit.
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// truncate - cuts value to at most n bytes without breaking utf-8 characters.
+func truncate(value string, n int) string {
+	if len(value) <= n {
+		return value
+	}
+	cut := 0
+	for i, r := range value {
+		if i+utf8.RuneLen(r) > n {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	return value[:cut]
+}
+
+// crc16 - returns CRC-16/CCITT-FALSE checksum used by EMV payloads.
+func crc16(s string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,165 @@
+package qrpay
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// withCRC - appends crc field to EMV payload body, so malformed bodies pass the crc check.
+func withCRC(body string) string {
+	body += "6304"
+	return body + fmt.Sprintf("%04X", crc16(body))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		payload Payload
+		want    Payload
+	}{
+		{
+			name:   "emv static",
+			format: FormatEMV,
+			payload: Payload{
+				Name: "Ivan Petrenko",
+				IBAN: "UA213223130000026007233566001",
+			},
+			want: Payload{
+				Name: "Ivan Petrenko",
+				IBAN: "UA213223130000026007233566001",
+				City: "Kyiv",
+			},
+		},
+		{
+			name:   "emv dynamic with purpose",
+			format: FormatEMV,
+			payload: Payload{
+				Name:    "Coffee Shop",
+				IBAN:    "UA213223130000026007233566001",
+				Amount:  125.5,
+				Purpose: "Order 42",
+				City:    "Lviv",
+			},
+			want: Payload{
+				Name:    "Coffee Shop",
+				IBAN:    "UA213223130000026007233566001",
+				Amount:  125.5,
+				Purpose: "Order 42",
+				City:    "Lviv",
+			},
+		},
+		{
+			name:   "emv long name is truncated",
+			format: FormatEMV,
+			payload: Payload{
+				Name: "Товариство з обмеженою відповідальністю",
+				IBAN: "UA213223130000026007233566001",
+				City: "Kyiv",
+			},
+			want: Payload{
+				Name: "Товариство з ",
+				IBAN: "UA213223130000026007233566001",
+				City: "Kyiv",
+			},
+		},
+		{
+			name:   "nbu",
+			format: FormatNBU,
+			payload: Payload{
+				Name:    "Ivan Petrenko",
+				IBAN:    "UA213223130000026007233566001",
+				Code:    "1234567890",
+				Amount:  10,
+				Purpose: "Rent for May",
+			},
+			want: Payload{
+				Name:    "Ivan Petrenko",
+				IBAN:    "UA213223130000026007233566001",
+				Code:    "1234567890",
+				Amount:  10,
+				Purpose: "Rent for May",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Encode(tt.format, tt.payload)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			got, format, err := Decode(encoded)
+			if err != nil {
+				t.Fatalf("Decode(%q) error = %v", encoded, err)
+			}
+			if format != tt.format {
+				t.Errorf("Decode() format = %q, want %q", format, tt.format)
+			}
+			if *got != tt.want {
+				t.Errorf("Decode() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		payload Payload
+	}{
+		{"unknown format", "pdf", Payload{IBAN: "UA213223130000026007233566001"}},
+		{"emv iban too long", FormatEMV, Payload{IBAN: string(make([]byte, 100))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Encode(tt.format, tt.payload); err == nil {
+				t.Errorf("Encode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	account := "0007UA.IBAN0129UA213223130000026007233566001"
+	valid := "000201010211" + "26" + fmt.Sprintf("%02d", len(account)) + account + "5303980"
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"empty", ""},
+		{"unknown prefix", "hello"},
+		{"emv without crc", valid},
+		{"emv wrong crc", valid + "63040000"},
+		{"emv negative length", withCRC(valid + "59-1")},
+		{"emv signed length", withCRC(valid + "59+1A")},
+		{"emv length with space", withCRC(valid + "59 1A")},
+		{"emv length beyond payload", withCRC(valid + "5999A")},
+		{"emv truncated field", withCRC(valid + "59")},
+		{"emv negative length in account", withCRC("000201010211" + "2604" + "00-1")},
+		{"emv negative length in additional data", withCRC(valid + "6204" + "08-1")},
+		{"emv other currency", withCRC("000201010211" + "26" + fmt.Sprintf("%02d", len(account)) + account + "5303840")},
+		{"emv without iban", withCRC("0002010102115303980")},
+		{"emv bad amount", withCRC(valid + "5403abc")},
+		{"nbu bad base64", nbuURL + "!!!"},
+		{"nbu short", nbuURL + base64.RawURLEncoding.EncodeToString([]byte("BCD\n002\n1\nUCT"))},
+		{"nbu wrong version", "BCD\n003\n1\nUCT\n\nIvan\nUA213223130000026007233566001\n"},
+		{"nbu wrong currency", "BCD\n002\n1\nUCT\n\nIvan\nUA213223130000026007233566001\nUSD10.00\n"},
+		{"nbu without iban", "BCD\n002\n1\nUCT\n\nIvan\n\nUAH10.00\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := Decode(tt.payload)
+			if !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("Decode(%q) = %+v, %v, want ErrInvalidPayload", tt.payload, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultShutdownTimeout = 10 * time.Second
+)
+
+// Job - represents periodic job function.
+type Job func(ctx context.Context) error
+
+// job - represents registered periodic job.
+type job struct {
+	name     string
+	interval time.Duration
+	fn       Job
+}
+
+// Scheduler - represents periodic jobs runner.
+type Scheduler struct {
+	jobs            []job
+	onError         func(name string, err error)
+	shutdownTimeout time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option - represents scheduler option.
+type Option func(*Scheduler)
+
+// ErrorHandler - configures handler called when job returns error.
+func ErrorHandler(fn func(name string, err error)) Option {
+	return func(s *Scheduler) {
+		s.onError = fn
+	}
+}
+
+// ShutdownTimeout - configures scheduler shutdown timeout.
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Scheduler) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// New - creates instance of new scheduler.
+func New(opts ...Option) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		onError:         func(string, error) {},
+		shutdownTimeout: _defaultShutdownTimeout,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+
+	// add custom options
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add - registers job which runs every interval.
+func (s *Scheduler) Add(name string, interval time.Duration, fn Job) {
+	s.jobs = append(s.jobs, job{name, interval, fn})
+}
+
+// Start - runs all registered jobs in background.
+func (s *Scheduler) Start() {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.run(j)
+	}
+}
+
+// run - runs job immediately and then on every tick until shutdown.
+func (s *Scheduler) run(j job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.fn(s.ctx); err != nil && s.ctx.Err() == nil {
+			s.onError(j.name, err)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown - stops scheduler and waits for running jobs.
+func (s *Scheduler) Shutdown() error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(s.shutdownTimeout):
+		return context.DeadlineExceeded
+	}
+}
@@ -0,0 +1,19 @@
+package utils
+
+import "net"
+
+// sharedAddressSpace - carrier-grade NAT range (RFC 6598), not routable from the internet.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP reports whether ip is public unicast address. Loopback, private, link-local
+// (cloud metadata 169.254.169.254 included), shared, unspecified and multicast addresses are not.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
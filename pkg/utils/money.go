@@ -0,0 +1,8 @@
+package utils
+
+import "math"
+
+// RoundMoney rounds amount to whole cents.
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,13 @@
+package utils
+
+// Truncate cuts text to at most n characters, not splitting multibyte characters.
+func Truncate(text string, n int) string {
+	count := 0
+	for i := range text {
+		if count == n {
+			return text[:i]
+		}
+		count++
+	}
+	return text
+}